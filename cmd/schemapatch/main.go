@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 
+	"github.com/starvpn/schemapatch/internal/cli"
 	"github.com/starvpn/schemapatch/internal/gui"
 	"go.uber.org/zap"
 )
@@ -16,6 +17,14 @@ func main() {
 	defer logger.Sync()
 
 	zap.ReplaceGlobals(logger)
+
+	// 带子命令时进入命令行模式（无需图形环境，适用于CI）
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		code := cli.Run(os.Args[1:])
+		logger.Sync()
+		os.Exit(code)
+	}
+
 	zap.S().Info("SchemaPatch 启动中...")
 
 	// 启动GUI应用
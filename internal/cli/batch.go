@@ -0,0 +1,333 @@
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/extractor"
+	"github.com/starvpn/schemapatch/internal/sqlgen"
+)
+
+// batchTarget 批量对比中的单个目标
+type batchTarget struct {
+	Name  string `json:"name"`
+	Error string `json:"error,omitempty"`
+
+	key         string
+	schemaDiff  *diff.SchemaDiff
+	script      *sqlgen.MigrationScript
+	fingerprint string
+}
+
+// batchGroup 生成脚本完全相同的目标分组
+type batchGroup struct {
+	ID          int               `json:"id"`
+	Targets     []string          `json:"targets"`
+	Items       []diff.DiffItem   `json:"items"`
+	MaxSeverity diff.DiffSeverity `json:"max_severity"`
+	Statements  int               `json:"statements"`
+	Warnings    []string          `json:"warnings,omitempty"`
+	ScriptPath  string            `json:"script_path,omitempty"`
+
+	schemaDiff *diff.SchemaDiff
+	script     *sqlgen.MigrationScript
+}
+
+// batchRow 差异矩阵中的一行，Groups 为存在该差异的分组
+type batchRow struct {
+	Item   diff.DiffItem `json:"item"`
+	Groups []int         `json:"groups"`
+}
+
+// batchReport 批量对比报告
+type batchReport struct {
+	Source string        `json:"source"`
+	Groups []*batchGroup `json:"groups"`
+	Matrix []batchRow    `json:"matrix"`
+	Failed []batchTarget `json:"failed,omitempty"`
+}
+
+// runBatch batch 子命令
+func runBatch(ctx context.Context, args []string) int {
+	var flags commonFlags
+	var genFlags generateFlags
+	var targetKeys, outputDir string
+	var parallel int
+	fs := newFlagSet("batch", "提取一次源环境，并发对比多个目标环境，按生成的脚本对目标分组并输出差异矩阵")
+	fs.StringVar(&flags.configPath, "config", "", "配置文件路径（默认 ~/.schemapatch/config.yaml）")
+	fs.StringVar(&flags.project, "project", "", "项目ID或名称（默认当前活动项目）")
+	fs.StringVar(&flags.source, "source", "", "源环境ID或名称，或 snapshot:<文件>、ddl:<文件或目录>（默认第一个dev环境）")
+	fs.StringVar(&targetKeys, "targets", "", "逗号分隔的目标环境ID或名称，或 snapshot:<文件>、ddl:<文件或目录>；all 表示源环境以外的所有环境（默认所有prod环境）")
+	fs.IntVar(&parallel, "parallel", 4, "同时提取的目标数量")
+	fs.StringVar(&outputDir, "o", "", "导出目录，每个分组写入一组升级/回滚脚本和 batch_report.json")
+	fs.StringVar(&flags.format, "format", "text", "输出格式: text / json")
+	fs.StringVar(&flags.failOn, "fail-on", "info", "任一目标的差异达到该级别时返回非零退出码: info / warning / danger / none")
+	genFlags.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if err := flags.validate(); err != nil {
+		return fail(ExitUsage, "%v", err)
+	}
+	if parallel < 1 {
+		return fail(ExitUsage, "-parallel 必须大于0")
+	}
+
+	project, err := loadProject(&flags)
+	if err != nil {
+		// 源和目标都是快照或DDL文件时不依赖项目配置
+		if !isSchemaFile(flags.source) || !allSchemaFiles(targetKeys) {
+			return fail(ExitError, "%v", err)
+		}
+		project = &config.Project{Name: "files"}
+	}
+
+	if multiDatabase(project, flags.source, config.EnvTypeDev) {
+		return fail(ExitUsage, "批量对比不支持多库环境")
+	}
+	sourceSchema, sourceEnv, err := loadSchema(ctx, project, flags.source, config.EnvTypeDev, "source")
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	targets, err := batchTargets(project, targetKeys, sourceEnv)
+	if err != nil {
+		return fail(ExitUsage, "%v", err)
+	}
+	options := genFlags.options()
+	if outputDir != "" {
+		options.IncludeRollback = true
+	}
+	compareTargets(ctx, project, sourceSchema, targets, options, parallel)
+
+	sourceName := flags.source
+	if sourceEnv != nil {
+		sourceName = sourceEnv.Name
+	}
+	report := groupTargets(sourceName, targets)
+	if outputDir != "" {
+		if err := writeBatch(outputDir, report); err != nil {
+			return fail(ExitError, "%v", err)
+		}
+	}
+
+	if flags.format == "json" {
+		if err := writeJSON(os.Stdout, report); err != nil {
+			return fail(ExitError, "输出报告失败: %v", err)
+		}
+	} else {
+		printBatch(os.Stdout, report)
+	}
+
+	if len(report.Failed) > 0 {
+		return ExitError
+	}
+	code := ExitOK
+	for _, group := range report.Groups {
+		if groupCode := diffExitCode(group.schemaDiff, flags.failOn); groupCode > code {
+			code = groupCode
+		}
+	}
+	return code
+}
+
+// batchTargets 解析 -targets 选项
+func batchTargets(project *config.Project, keys string, sourceEnv *config.Environment) ([]batchTarget, error) {
+	var targets []batchTarget
+	if keys == "" || keys == "all" {
+		for _, env := range project.Environments {
+			if sourceEnv != nil && env.ID == sourceEnv.ID {
+				continue
+			}
+			if (keys == "" && env.Type != config.EnvTypeProd) || len(env.Databases) > 0 {
+				continue
+			}
+			targets = append(targets, batchTarget{Name: env.Name, key: env.ID})
+		}
+		if len(targets) == 0 {
+			return nil, fmt.Errorf("项目 %s 中没有可对比的目标环境，请使用 -targets 指定", project.Name)
+		}
+		return targets, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		name := key
+		if !isSchemaFile(key) {
+			env := project.FindEnvironment(key)
+			if env == nil {
+				return nil, fmt.Errorf("环境不存在: %s", key)
+			}
+			if len(env.Databases) > 0 {
+				return nil, fmt.Errorf("批量对比不支持多库环境: %s", env.Name)
+			}
+			name = env.Name
+		}
+		targets = append(targets, batchTarget{Name: name, key: key})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("-targets 中没有目标")
+	}
+	return targets, nil
+}
+
+// allSchemaFiles 判断 -targets 是否全部指向本地Schema文件
+func allSchemaFiles(keys string) bool {
+	if keys == "" || keys == "all" {
+		return false
+	}
+	for _, key := range strings.Split(keys, ",") {
+		if key = strings.TrimSpace(key); key != "" && !isSchemaFile(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareTargets 以有限并发提取各目标并与源Schema对比，单个目标失败不影响其他目标
+func compareTargets(ctx context.Context, project *config.Project, sourceSchema *extractor.DatabaseSchema,
+	targets []batchTarget, options sqlgen.GenerateOptions, parallel int) {
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(target *batchTarget) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			targetSchema, targetEnv, err := loadSchema(ctx, project, target.key, config.EnvTypeProd, "target")
+			if err != nil {
+				target.Error = err.Error()
+				return
+			}
+
+			diffEngine := diff.NewDiffEngine(project.IgnoreRules)
+			diffEngine.SetRenameRules(project.RenameRules)
+			diffEngine.SetDefinerPolicy(project.DefinerPolicy)
+			target.schemaDiff = diffEngine.Compare(sourceSchema, targetSchema)
+
+			targetOptions := options
+			targetOptions.DefinerPolicy = project.DefinerPolicy
+			if targetEnv != nil {
+				targetOptions.TargetVersion = targetEnv.MySQLVersion
+			}
+			script, err := sqlgen.NewMySQLGenerator().Generate(target.schemaDiff, targetOptions)
+			if err != nil {
+				target.Error = fmt.Sprintf("生成脚本失败: %v", err)
+				return
+			}
+			target.script = script
+			target.fingerprint = scriptFingerprint(script)
+		}(&targets[i])
+	}
+	wg.Wait()
+}
+
+// scriptFingerprint 根据升级、回滚语句和在线变更命令计算脚本指纹，指纹相同的目标可以共用一个脚本
+func scriptFingerprint(script *sqlgen.MigrationScript) string {
+	hash := sha256.New()
+	for _, stmt := range script.Statements {
+		hash.Write([]byte(stmt.SQL))
+		hash.Write([]byte{0})
+		hash.Write([]byte(stmt.RollbackSQL))
+		hash.Write([]byte{0})
+		hash.Write([]byte(stmt.OnlineCommand))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// groupTargets 按脚本指纹分组并构建差异矩阵，分组按首个目标出现的顺序编号
+func groupTargets(source string, targets []batchTarget) *batchReport {
+	report := &batchReport{Source: source}
+	byFingerprint := make(map[string]*batchGroup)
+	for _, target := range targets {
+		if target.Error != "" {
+			report.Failed = append(report.Failed, target)
+			continue
+		}
+
+		group := byFingerprint[target.fingerprint]
+		if group == nil {
+			group = &batchGroup{
+				ID:          len(report.Groups) + 1,
+				Items:       target.schemaDiff.Items(),
+				MaxSeverity: target.schemaDiff.GetMaxSeverity(),
+				Statements:  len(target.script.Statements),
+				Warnings:    target.script.Warnings,
+				schemaDiff:  target.schemaDiff,
+				script:      target.script,
+			}
+			byFingerprint[target.fingerprint] = group
+			report.Groups = append(report.Groups, group)
+		}
+		group.Targets = append(group.Targets, target.Name)
+	}
+
+	rows := make(map[string]*batchRow)
+	var keys []string
+	for _, group := range report.Groups {
+		for _, item := range group.Items {
+			row := rows[item.Key]
+			if row == nil {
+				row = &batchRow{Item: item}
+				rows[item.Key] = row
+				keys = append(keys, item.Key)
+			}
+			if item.Severity > row.Item.Severity {
+				row.Item.Severity = item.Severity
+			}
+			row.Groups = append(row.Groups, group.ID)
+		}
+	}
+	for _, key := range keys {
+		report.Matrix = append(report.Matrix, *rows[key])
+	}
+	return report
+}
+
+// writeBatch 将每个有差异的分组写入升级/回滚脚本和脚本JSON，并写入报告
+func writeBatch(outputDir string, report *batchReport) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("创建导出目录失败: %w", err)
+	}
+
+	for _, group := range report.Groups {
+		if !group.schemaDiff.HasDiff() {
+			continue
+		}
+		prefix := filepath.Join(outputDir, fmt.Sprintf("group_%02d", group.ID))
+		header := fmt.Sprintf("-- 适用目标: %s\n", strings.Join(group.Targets, ", "))
+
+		if err := os.WriteFile(prefix+"_up.sql", []byte(header+group.script.UpSQL), 0644); err != nil {
+			return fmt.Errorf("写入升级脚本失败: %w", err)
+		}
+		if err := os.WriteFile(prefix+"_down.sql", []byte(header+group.script.DownSQL), 0644); err != nil {
+			return fmt.Errorf("写入回滚脚本失败: %w", err)
+		}
+		if err := writeJSONFile(prefix+".json", group.script); err != nil {
+			return fmt.Errorf("写入脚本失败: %w", err)
+		}
+		group.ScriptPath = prefix + "_up.sql"
+	}
+
+	if err := writeJSONFile(filepath.Join(outputDir, "batch_report.json"), report); err != nil {
+		return fmt.Errorf("写入批量对比报告失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/diff"
+)
+
+// 退出码
+const (
+	ExitOK          = 0 // 无差异（或差异低于 -fail-on 阈值）
+	ExitError       = 1 // 运行错误
+	ExitUsage       = 2 // 参数错误
+	ExitDiffInfo    = 3 // 存在信息级差异
+	ExitDiffWarning = 4 // 存在警告级差异
+	ExitDiffDanger  = 5 // 存在危险级差异
+	ExitValidation  = 6 // Docker验证失败
+	ExitApply       = 7 // 在目标环境执行脚本失败
+)
+
+// command 子命令
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, args []string) int
+}
+
+// commands 返回所有子命令
+func commands() []command {
+	return []command{
+		{name: "compare", summary: "对比源环境与目标环境的Schema差异", run: runCompare},
+		{name: "generate", summary: "对比并生成升级SQL（输出到标准输出或文件）", run: runGenerate},
+		{name: "batch", summary: "提取一次源环境，并发对比多个目标环境，按差异分组输出矩阵和脚本", run: runBatch},
+		{name: "validate", summary: "对比、生成并在Docker环境中验证升级脚本", run: runValidate},
+		{name: "export", summary: "对比、生成并导出升级/回滚脚本及差异报告", run: runExport},
+		{name: "apply", summary: "在目标环境上逐条执行升级脚本并记录执行结果，支持失败后续跑", run: runApply},
+		{name: "migrate", summary: "版本化迁移: new 生成迁移文件，status 查看执行状态和漂移，up 执行待执行版本", run: runMigrate},
+		{name: "snapshot", summary: "提取环境Schema并保存为离线快照文件", run: runSnapshot},
+	}
+}
+
+// IsCommand 判断参数是否为命令行子命令
+func IsCommand(arg string) bool {
+	if arg == "help" || arg == "-h" || arg == "--help" {
+		return true
+	}
+	for _, cmd := range commands() {
+		if cmd.name == arg {
+			return true
+		}
+	}
+	return false
+}
+
+// Run 执行命令行模式，返回进程退出码
+func Run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		printUsage(os.Stdout)
+		return ExitOK
+	}
+
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return cmd.run(context.Background(), args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", args[0])
+	printUsage(os.Stderr)
+	return ExitUsage
+}
+
+// printUsage 打印帮助信息
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "SchemaPatch - MySQL数据库Schema对比与升级工具")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "用法:")
+	fmt.Fprintln(w, "  schemapatch                  启动图形界面")
+	fmt.Fprintln(w, "  schemapatch <命令> [选项]    命令行模式")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "命令:")
+	for _, cmd := range commands() {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "退出码:")
+	fmt.Fprintf(w, "  %d 无差异  %d 运行错误  %d 参数错误\n", ExitOK, ExitError, ExitUsage)
+	fmt.Fprintf(w, "  %d 信息级差异  %d 警告级差异  %d 危险级差异\n", ExitDiffInfo, ExitDiffWarning, ExitDiffDanger)
+	fmt.Fprintf(w, "  %d Docker验证失败  %d 执行脚本失败\n", ExitValidation, ExitApply)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "使用 schemapatch <命令> -h 查看命令选项")
+}
+
+// commonFlags 各子命令共用的选项
+type commonFlags struct {
+	configPath string
+	project    string
+	source     string
+	target     string
+	format     string
+	failOn     string
+	preflight  bool
+	base       string
+}
+
+// register 注册共用选项
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.configPath, "config", "", "配置文件路径（默认 ~/.schemapatch/config.yaml）")
+	fs.StringVar(&c.project, "project", "", "项目ID或名称（默认当前活动项目）")
+	fs.StringVar(&c.source, "source", "", "源环境ID或名称，或 snapshot:<文件>、ddl:<文件或目录>（默认第一个dev环境）")
+	fs.StringVar(&c.target, "target", "", "目标环境ID或名称，或 snapshot:<文件>、ddl:<文件或目录>（默认第一个prod环境）")
+	fs.StringVar(&c.format, "format", "text", "输出格式: text / json")
+	fs.StringVar(&c.failOn, "fail-on", "info", "差异达到该级别时返回非零退出码: info / warning / danger / none")
+	fs.StringVar(&c.base, "base", "", "三方对比的基线（通常为上次发布的 snapshot:<文件>），只生成源环境相对基线的变更，保留目标环境的热修复")
+	fs.BoolVar(&c.preflight, "preflight", false, "在目标环境上执行只读的数据预检（NOT NULL、类型收缩、唯一索引、外键）")
+}
+
+// validate 校验共用选项
+func (c *commonFlags) validate() error {
+	if c.format != "text" && c.format != "json" {
+		return fmt.Errorf("不支持的输出格式: %s", c.format)
+	}
+	if _, err := parseFailOn(c.failOn); err != nil {
+		return err
+	}
+	return nil
+}
+
+// newFlagSet 创建子命令的FlagSet
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "用法: schemapatch %s [选项]\n\n%s\n\n选项:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseFailOn 解析 -fail-on 选项，返回阈值（-1 表示从不失败）
+func parseFailOn(value string) (diff.DiffSeverity, error) {
+	switch strings.ToLower(value) {
+	case "info":
+		return diff.SeverityInfo, nil
+	case "warning":
+		return diff.SeverityWarning, nil
+	case "danger":
+		return diff.SeverityDanger, nil
+	case "none":
+		return -1, nil
+	default:
+		return 0, fmt.Errorf("不支持的 -fail-on 级别: %s", value)
+	}
+}
+
+// diffExitCode 根据差异最高严重程度计算退出码
+func diffExitCode(schemaDiff *diff.SchemaDiff, failOn string) int {
+	if schemaDiff == nil || !schemaDiff.HasDiff() {
+		return ExitOK
+	}
+
+	threshold, err := parseFailOn(failOn)
+	if err != nil || threshold < 0 {
+		return ExitOK
+	}
+
+	severity := schemaDiff.GetMaxSeverity()
+	if severity < threshold {
+		return ExitOK
+	}
+
+	switch severity {
+	case diff.SeverityDanger:
+		return ExitDiffDanger
+	case diff.SeverityWarning:
+		return ExitDiffWarning
+	default:
+		return ExitDiffInfo
+	}
+}
+
+// loadProject 加载配置并定位项目
+func loadProject(flags *commonFlags) (*config.Project, error) {
+	var store *config.Store
+	var err error
+	if flags.configPath != "" {
+		store, err = config.NewStoreAt(flags.configPath)
+	} else {
+		store, err = config.NewStore()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("加载配置失败: %w", err)
+	}
+
+	var project *config.Project
+	if flags.project != "" {
+		project = store.FindProject(flags.project)
+		if project == nil {
+			return nil, fmt.Errorf("项目不存在: %s", flags.project)
+		}
+	} else {
+		project = store.GetActiveProject()
+		if project == nil {
+			return nil, fmt.Errorf("未找到可用项目，请先在图形界面中配置或使用 -project 指定")
+		}
+	}
+
+	if err := project.DefinerPolicy.Validate(); err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+// resolveEnvironment 按ID/名称或默认类型定位环境
+// 密码可通过 SCHEMAPATCH_<ROLE>_PASSWORD 环境变量覆盖，便于CI中注入
+func resolveEnvironment(project *config.Project, key string, defaultType config.EnvironmentType, role string) (*config.Environment, error) {
+	var env *config.Environment
+	if key != "" {
+		env = project.FindEnvironment(key)
+		if env == nil {
+			return nil, fmt.Errorf("环境不存在: %s", key)
+		}
+	} else {
+		env = project.FirstEnvironmentOfType(defaultType)
+		if env == nil {
+			return nil, fmt.Errorf("项目 %s 中没有类型为 %s 的环境，请使用 -%s 指定", project.Name, defaultType, role)
+		}
+	}
+
+	envCopy := *env
+	if password, ok := os.LookupEnv("SCHEMAPATCH_" + strings.ToUpper(role) + "_PASSWORD"); ok {
+		envCopy.Password = password
+	}
+	return &envCopy, nil
+}
+
+// fail 打印错误并返回退出码
+func fail(code int, format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "错误: "+format+"\n", args...)
+	return code
+}
@@ -0,0 +1,822 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/docker"
+	"github.com/starvpn/schemapatch/internal/executor"
+	"github.com/starvpn/schemapatch/internal/extractor"
+	"github.com/starvpn/schemapatch/internal/sqlgen"
+)
+
+// snapshotPrefix 快照文件参数前缀，如 -target snapshot:prod.json
+const snapshotPrefix = "snapshot:"
+
+// ddlPrefix DDL文件参数前缀，如 -source ddl:./schema
+const ddlPrefix = "ddl:"
+
+// compareResult 对比结果
+type compareResult struct {
+	project      *config.Project
+	sourceSchema *extractor.DatabaseSchema
+	targetSchema *extractor.DatabaseSchema
+	schemaDiff   *diff.SchemaDiff
+	targetEnv    *config.Environment   // 目标为快照或DDL文件时为nil
+	threeWay     *diff.ThreeWayDiff    // 指定 -base 时的三方对比结果
+	multi        *diff.MultiSchemaDiff // 多库对比结果，此时 schemaDiff 为合并后的差异，sourceSchema/targetSchema 为nil
+}
+
+// exitCode 根据差异计算退出码，三方对比存在冲突时按危险级差异处理
+func (r *compareResult) exitCode(failOn string) int {
+	code := diffExitCode(r.schemaDiff, failOn)
+	if r.threeWay != nil && r.threeWay.HasConflicts() {
+		if threshold, err := parseFailOn(failOn); err == nil && threshold >= 0 {
+			return ExitDiffDanger
+		}
+	}
+	return code
+}
+
+// generate 生成升级脚本，多库对比时按库生成并以 USE 切换
+func (r *compareResult) generate(options sqlgen.GenerateOptions) (*sqlgen.MigrationScript, error) {
+	if r.multi != nil {
+		return sqlgen.NewMySQLGenerator().GenerateDatabases(r.multi, options)
+	}
+	return sqlgen.NewMySQLGenerator().Generate(r.schemaDiff, options)
+}
+
+// generateOptions 补充与项目和目标环境相关的生成选项
+func (r *compareResult) generateOptions(options sqlgen.GenerateOptions) sqlgen.GenerateOptions {
+	if r.project != nil {
+		options.DefinerPolicy = r.project.DefinerPolicy
+	}
+	if r.targetEnv != nil {
+		options.TargetVersion = r.targetEnv.MySQLVersion
+	}
+	return options
+}
+
+// generateFlags 脚本生成相关选项
+type generateFlags struct {
+	rollback    bool
+	transaction bool
+	noComments  bool
+	splitAlters bool
+	online      string
+	onlineArgs  string
+}
+
+// register 注册脚本生成选项
+func (g *generateFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&g.rollback, "rollback", false, "同时生成回滚脚本")
+	fs.BoolVar(&g.transaction, "transaction", false, "使用事务包装脚本")
+	fs.BoolVar(&g.noComments, "no-comments", false, "不在脚本中添加注释")
+	fs.BoolVar(&g.splitAlters, "split-alters", false, "每项列/索引变更单独生成一条 ALTER（默认按表合并）")
+	fs.StringVar(&g.online, "online", "", "在线变更模式: native（ALGORITHM/LOCK）| gh-ost | pt-osc")
+	fs.StringVar(&g.onlineArgs, "online-args", "", "追加到 gh-ost / pt-osc 命令的参数，如 \"--host=db1 --user=dba\"")
+}
+
+// options 转换为生成选项
+func (g *generateFlags) options() sqlgen.GenerateOptions {
+	options := sqlgen.DefaultGenerateOptions()
+	options.IncludeRollback = g.rollback
+	options.WrapTransaction = g.transaction
+	options.AddComments = !g.noComments
+	options.SplitAlters = g.splitAlters
+	if g.online != "" {
+		options.OnlineMode = true
+		options.OnlineTool = g.online
+		options.OnlineToolArgs = g.onlineArgs
+	}
+	return options
+}
+
+// runCompare compare 子命令
+func runCompare(ctx context.Context, args []string) int {
+	var flags commonFlags
+	fs := newFlagSet("compare", "对比源环境与目标环境的Schema，按最高差异级别返回退出码")
+	flags.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if err := flags.validate(); err != nil {
+		return fail(ExitUsage, "%v", err)
+	}
+
+	result, err := compareEnvironments(ctx, &flags)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	if flags.format == "json" {
+		var output interface{} = result.schemaDiff
+		if result.threeWay != nil {
+			output = result.threeWay
+		} else if result.multi != nil {
+			output = result.multi
+		}
+		if err := writeJSON(os.Stdout, output); err != nil {
+			return fail(ExitError, "输出差异失败: %v", err)
+		}
+	} else {
+		if result.multi != nil {
+			printDatabases(os.Stdout, result.multi)
+		}
+		printDiff(os.Stdout, result.schemaDiff)
+		if result.threeWay != nil {
+			printThreeWay(os.Stdout, result.threeWay)
+		}
+	}
+
+	return result.exitCode(flags.failOn)
+}
+
+// runGenerate generate 子命令
+func runGenerate(ctx context.Context, args []string) int {
+	var flags commonFlags
+	var genFlags generateFlags
+	var output string
+	fs := newFlagSet("generate", "对比并生成升级SQL，默认输出到标准输出")
+	flags.register(fs)
+	genFlags.register(fs)
+	fs.StringVar(&output, "o", "", "升级脚本输出文件（默认标准输出）")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if err := flags.validate(); err != nil {
+		return fail(ExitUsage, "%v", err)
+	}
+
+	result, err := compareEnvironments(ctx, &flags)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	script, err := result.generate(result.generateOptions(genFlags.options()))
+	if err != nil {
+		return fail(ExitError, "生成脚本失败: %v", err)
+	}
+
+	var content []byte
+	if flags.format == "json" {
+		content, err = json.MarshalIndent(script, "", "  ")
+		if err != nil {
+			return fail(ExitError, "序列化脚本失败: %v", err)
+		}
+		content = append(content, '\n')
+	} else {
+		content = []byte(script.UpSQL)
+		if genFlags.rollback && script.DownSQL != "" {
+			content = append(content, []byte("\n"+script.DownSQL)...)
+		}
+	}
+
+	if output != "" {
+		if err := os.WriteFile(output, content, 0644); err != nil {
+			return fail(ExitError, "写入文件失败: %v", err)
+		}
+		fmt.Fprintf(os.Stderr, "脚本已写入 %s（%d 条语句）\n", output, len(script.Statements))
+	} else {
+		os.Stdout.Write(content)
+	}
+
+	for _, warning := range script.Warnings {
+		fmt.Fprintf(os.Stderr, "警告: %s\n", warning)
+	}
+	printConflictWarnings(result.threeWay)
+
+	return result.exitCode(flags.failOn)
+}
+
+// runValidate validate 子命令
+func runValidate(ctx context.Context, args []string) int {
+	var flags commonFlags
+	var image string
+	var timeout time.Duration
+	var keep, verifyRollback bool
+	var seed, mask string
+	var seedRows int
+	fs := newFlagSet("validate", "对比并生成升级SQL，然后在Docker容器中导入目标Schema并执行验证")
+	flags.register(fs)
+	fs.StringVar(&image, "image", "", "MySQL镜像（默认使用项目Docker配置）")
+	fs.DurationVar(&timeout, "timeout", 0, "MySQL启动超时（默认使用项目Docker配置）")
+	fs.BoolVar(&keep, "keep", false, "验证后保留容器")
+	fs.BoolVar(&verifyRollback, "verify-rollback", false, "升级后在同一容器中执行回滚语句，并与目标环境对比")
+	fs.StringVar(&seed, "seed", "", "执行脚本前填充样本数据: sample(从目标环境抽样) / synthetic(按列类型合成) / none（默认使用项目Docker配置）")
+	fs.IntVar(&seedRows, "seed-rows", 0, "每张表填充的样本行数（默认100）")
+	fs.StringVar(&mask, "mask", "", "抽样时脱敏的列，逗号分隔，如 users.email,*.phone")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if err := flags.validate(); err != nil {
+		return fail(ExitUsage, "%v", err)
+	}
+
+	result, err := compareEnvironments(ctx, &flags)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	if result.multi != nil {
+		return fail(ExitUsage, "Docker验证不支持多库环境")
+	}
+	if !result.schemaDiff.HasDiff() {
+		fmt.Fprintln(os.Stdout, "没有差异，无需验证")
+		return ExitOK
+	}
+
+	script, err := result.generate(result.generateOptions(sqlgen.DefaultGenerateOptions()))
+	if err != nil {
+		return fail(ExitError, "生成脚本失败: %v", err)
+	}
+
+	options := validationOptions(result.project)
+	if image != "" {
+		options.MySQLImage = image
+	}
+	if timeout > 0 {
+		options.Timeout = timeout
+	}
+	if keep {
+		options.Cleanup = false
+	}
+	options.VerifyRollback = verifyRollback
+	if seed != "" {
+		mode, err := docker.ParseSeedMode(seed)
+		if err != nil {
+			return fail(ExitUsage, "%v", err)
+		}
+		options.Seed.Mode = mode
+	}
+	if seedRows > 0 {
+		options.Seed.RowLimit = seedRows
+	}
+	if mask != "" {
+		options.Seed.MaskColumns = append(options.Seed.MaskColumns, strings.Split(mask, ",")...)
+	}
+	options.Seed.Source = result.targetEnv
+
+	validator := docker.NewValidator()
+	if options.Cleanup {
+		defer validator.Cleanup(ctx)
+	}
+
+	validation, err := validator.Validate(ctx, result.sourceSchema, result.targetSchema, script, options,
+		func(step, total int, message string, stepErr error) {
+			status := "✅"
+			if stepErr != nil {
+				status = "❌"
+			}
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s %s\n", step, total, status, message)
+		})
+	if err != nil {
+		return fail(ExitValidation, "验证失败: %v", err)
+	}
+
+	if flags.format == "json" {
+		if err := writeJSON(os.Stdout, validation); err != nil {
+			return fail(ExitError, "输出验证结果失败: %v", err)
+		}
+	} else {
+		printValidation(os.Stdout, validation)
+	}
+
+	if !validation.Success || (options.CompareSchema && !validation.SchemaMatch) ||
+		(validation.Rollback != nil && !validation.Rollback.Match) {
+		return ExitValidation
+	}
+	return result.exitCode(flags.failOn)
+}
+
+// runExport export 子命令
+func runExport(ctx context.Context, args []string) int {
+	var flags commonFlags
+	var genFlags generateFlags
+	var outputDir string
+	fs := newFlagSet("export", "对比并将升级脚本、回滚脚本和差异报告导出到目录")
+	flags.register(fs)
+	genFlags.register(fs)
+	fs.StringVar(&outputDir, "o", ".", "导出目录")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if err := flags.validate(); err != nil {
+		return fail(ExitUsage, "%v", err)
+	}
+
+	result, err := compareEnvironments(ctx, &flags)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	// 导出时总是生成回滚脚本
+	options := result.generateOptions(genFlags.options())
+	options.IncludeRollback = true
+
+	script, err := result.generate(options)
+	if err != nil {
+		return fail(ExitError, "生成脚本失败: %v", err)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fail(ExitError, "创建导出目录失败: %v", err)
+	}
+
+	upPath := filepath.Join(outputDir, script.Version+"_up.sql")
+	downPath := filepath.Join(outputDir, script.Version+"_down.sql")
+	reportPath := filepath.Join(outputDir, script.Version+"_diff.json")
+
+	if err := os.WriteFile(upPath, []byte(script.UpSQL), 0644); err != nil {
+		return fail(ExitError, "写入升级脚本失败: %v", err)
+	}
+	if err := os.WriteFile(downPath, []byte(script.DownSQL), 0644); err != nil {
+		return fail(ExitError, "写入回滚脚本失败: %v", err)
+	}
+
+	var onlinePath string
+	if len(script.OnlineCommands) > 0 {
+		onlinePath = filepath.Join(outputDir, script.Version+"_online.sh")
+		content := "#!/bin/sh\nset -e\n\n" + strings.Join(script.OnlineCommands, "\n\n") + "\n"
+		if err := os.WriteFile(onlinePath, []byte(content), 0755); err != nil {
+			return fail(ExitError, "写入在线变更脚本失败: %v", err)
+		}
+	}
+
+	report, err := os.Create(reportPath)
+	if err != nil {
+		return fail(ExitError, "写入差异报告失败: %v", err)
+	}
+	if err := writeJSON(report, result.schemaDiff); err != nil {
+		report.Close()
+		return fail(ExitError, "写入差异报告失败: %v", err)
+	}
+	if err := report.Close(); err != nil {
+		return fail(ExitError, "写入差异报告失败: %v", err)
+	}
+
+	if flags.format == "json" {
+		paths := map[string]string{
+			"up":     upPath,
+			"down":   downPath,
+			"report": reportPath,
+		}
+		if onlinePath != "" {
+			paths["online"] = onlinePath
+		}
+		if err := writeJSON(os.Stdout, paths); err != nil {
+			return fail(ExitError, "输出导出结果失败: %v", err)
+		}
+	} else {
+		if result.multi != nil {
+			printDatabases(os.Stdout, result.multi)
+		}
+		printDiff(os.Stdout, result.schemaDiff)
+		if result.threeWay != nil {
+			printThreeWay(os.Stdout, result.threeWay)
+		}
+		fmt.Fprintf(os.Stdout, "\n已导出:\n  %s\n  %s\n  %s\n", upPath, downPath, reportPath)
+		if onlinePath != "" {
+			fmt.Fprintf(os.Stdout, "  %s\n", onlinePath)
+		}
+	}
+
+	return result.exitCode(flags.failOn)
+}
+
+// compareEnvironments 提取源、目标Schema并执行对比
+func compareEnvironments(ctx context.Context, flags *commonFlags) (*compareResult, error) {
+	project, err := loadProject(flags)
+	if err != nil {
+		// 两侧都是快照或DDL文件时不依赖项目配置
+		if !isSchemaFile(flags.source) || !isSchemaFile(flags.target) || (flags.base != "" && !isSchemaFile(flags.base)) {
+			return nil, err
+		}
+		project = &config.Project{Name: "files"}
+	}
+
+	if multiDatabase(project, flags.source, config.EnvTypeDev) || multiDatabase(project, flags.target, config.EnvTypeProd) {
+		if flags.base != "" || flags.preflight {
+			return nil, fmt.Errorf("多库对比不支持 -base 和 -preflight")
+		}
+		return compareDatabases(ctx, project, flags)
+	}
+
+	// 源、目标（和基线）并发提取
+	var sourceSchema, targetSchema, baseSchema *extractor.DatabaseSchema
+	var targetEnv *config.Environment
+	tasks := []func(context.Context) error{
+		func(ctx context.Context) (err error) {
+			sourceSchema, _, err = loadSchema(ctx, project, flags.source, config.EnvTypeDev, "source")
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			targetSchema, targetEnv, err = loadSchema(ctx, project, flags.target, config.EnvTypeProd, "target")
+			return err
+		},
+	}
+	if flags.base != "" {
+		tasks = append(tasks, func(ctx context.Context) (err error) {
+			baseSchema, _, err = loadSchema(ctx, project, flags.base, config.EnvTypeProd, "base")
+			return err
+		})
+	}
+	if err := runConcurrently(ctx, tasks...); err != nil {
+		return nil, err
+	}
+
+	diffEngine := diff.NewDiffEngine(project.IgnoreRules)
+	diffEngine.SetRenameRules(project.RenameRules)
+	diffEngine.SetDefinerPolicy(project.DefinerPolicy)
+
+	var schemaDiff *diff.SchemaDiff
+	var threeWay *diff.ThreeWayDiff
+	if baseSchema != nil {
+		threeWay = diffEngine.CompareThreeWay(baseSchema, sourceSchema, targetSchema)
+		schemaDiff = threeWay.Diff
+	} else {
+		schemaDiff = diffEngine.Compare(sourceSchema, targetSchema)
+	}
+
+	if flags.preflight {
+		if targetEnv == nil {
+			return nil, fmt.Errorf("数据预检需要连接目标环境，不支持快照或DDL文件")
+		}
+		if err := runPreflight(ctx, targetEnv, schemaDiff); err != nil {
+			return nil, err
+		}
+	}
+
+	return &compareResult{
+		project:      project,
+		sourceSchema: sourceSchema,
+		targetSchema: targetSchema,
+		schemaDiff:   schemaDiff,
+		targetEnv:    targetEnv,
+		threeWay:     threeWay,
+	}, nil
+}
+
+// compareDatabases 提取源、目标环境的所有数据库并按库名映射逐库对比
+func compareDatabases(ctx context.Context, project *config.Project, flags *commonFlags) (*compareResult, error) {
+	var sourceSchemas, targetSchemas map[string]*extractor.DatabaseSchema
+	var sourceName, targetName string
+	var targetEnv *config.Environment
+	err := runConcurrently(ctx,
+		func(ctx context.Context) (err error) {
+			sourceSchemas, sourceName, _, err = loadDatabases(ctx, project, flags.source, config.EnvTypeDev, "source")
+			return err
+		},
+		func(ctx context.Context) (err error) {
+			targetSchemas, targetName, targetEnv, err = loadDatabases(ctx, project, flags.target, config.EnvTypeProd, "target")
+			return err
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	diffEngine := diff.NewDiffEngine(project.IgnoreRules)
+	diffEngine.SetRenameRules(project.RenameRules)
+	diffEngine.SetDefinerPolicy(project.DefinerPolicy)
+	multi := diffEngine.CompareDatabases(sourceSchemas, targetSchemas, project.MapDatabase)
+	multi.SourceEnv = sourceName
+	multi.TargetEnv = targetName
+
+	return &compareResult{
+		project:    project,
+		schemaDiff: multi.Combined(),
+		targetEnv:  targetEnv,
+		multi:      multi,
+	}, nil
+}
+
+// runConcurrently 并发执行任务并等待全部完成，返回最先出现的错误
+// 任一任务失败时取消传给其他任务的 ctx，避免继续等待另一侧的提取
+func runConcurrently(ctx context.Context, tasks ...func(context.Context) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var mu sync.Mutex
+	var firstErr error
+	var wg sync.WaitGroup
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task func(context.Context) error) {
+			defer wg.Done()
+			if err := task(ctx); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				mu.Unlock()
+			}
+		}(task)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// multiDatabase 判断参数是否指向配置了多个数据库的环境
+func multiDatabase(project *config.Project, key string, defaultType config.EnvironmentType) bool {
+	if isSchemaFile(key) {
+		return false
+	}
+	env := project.FirstEnvironmentOfType(defaultType)
+	if key != "" {
+		env = project.FindEnvironment(key)
+	}
+	return env != nil && len(env.Databases) > 0
+}
+
+// loadDatabases 加载参数对应的所有数据库，返回 库名 -> Schema 和显示名称
+// 快照、DDL文件和未配置多库的环境只包含一个库
+func loadDatabases(ctx context.Context, project *config.Project, key string, defaultType config.EnvironmentType, role string) (map[string]*extractor.DatabaseSchema, string, *config.Environment, error) {
+	if isSchemaFile(key) {
+		schema, _, err := loadSchema(ctx, project, key, defaultType, role)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		return map[string]*extractor.DatabaseSchema{schema.Database: schema}, key, nil, nil
+	}
+
+	env, err := resolveEnvironment(project, key, defaultType, role)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if len(env.Databases) > 0 {
+		fmt.Fprintf(os.Stderr, "正在提取%sSchema: %s (%s)\n", roleName(role), env.Name, strings.Join(env.Databases, ", "))
+	} else {
+		fmt.Fprintf(os.Stderr, "正在提取%sSchema: %s (%s)\n", roleName(role), env.Name, env.Database)
+	}
+	schemas, err := extractor.ExtractDatabases(ctx, env, extractor.DefaultExtractOptions())
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("提取%sSchema失败: %w", roleName(role), err)
+	}
+	return schemas, env.Name, env, nil
+}
+
+// runPreflight 连接目标环境执行数据预检
+func runPreflight(ctx context.Context, env *config.Environment, schemaDiff *diff.SchemaDiff) error {
+	ext, err := extractor.NewMySQLExtractor(env)
+	if err != nil {
+		return fmt.Errorf("创建预检连接失败: %w", err)
+	}
+	if err := ext.Connect(ctx); err != nil {
+		return fmt.Errorf("连接目标环境预检失败: %w", err)
+	}
+	defer ext.Close()
+
+	fmt.Fprintf(os.Stderr, "正在对目标环境执行数据预检: %s (%s)\n", env.Name, env.Database)
+	diff.Preflight(ctx, ext, schemaDiff)
+	return nil
+}
+
+// isSchemaFile 判断参数是否指向本地Schema文件（而非环境）
+func isSchemaFile(key string) bool {
+	return strings.HasPrefix(key, snapshotPrefix) || strings.HasPrefix(key, ddlPrefix)
+}
+
+// loadSchema 根据参数加载Schema
+// 以 snapshot: 开头时从快照文件加载，以 ddl: 开头时解析DDL文件，否则连接对应环境提取（同时返回该环境）
+func loadSchema(ctx context.Context, project *config.Project, key string, defaultType config.EnvironmentType, role string) (*extractor.DatabaseSchema, *config.Environment, error) {
+	if strings.HasPrefix(key, snapshotPrefix) {
+		path := strings.TrimPrefix(key, snapshotPrefix)
+		fmt.Fprintf(os.Stderr, "正在加载%s快照: %s\n", roleName(role), path)
+		schema, err := extractor.LoadSnapshot(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("加载%s快照失败: %w", roleName(role), err)
+		}
+		return schema, nil, nil
+	}
+
+	if strings.HasPrefix(key, ddlPrefix) {
+		path := strings.TrimPrefix(key, ddlPrefix)
+		fmt.Fprintf(os.Stderr, "正在解析%sDDL文件: %s\n", roleName(role), path)
+		schema, err := extractor.Extract(ctx, &config.Environment{Name: path, SchemaPath: path}, extractor.DefaultExtractOptions())
+		if err != nil {
+			return nil, nil, fmt.Errorf("解析%sDDL文件失败: %w", roleName(role), err)
+		}
+		return schema, nil, nil
+	}
+
+	env, err := resolveEnvironment(project, key, defaultType, role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fmt.Fprintf(os.Stderr, "正在提取%sSchema: %s (%s)\n", roleName(role), env.Name, env.Database)
+	schema, err := extractor.Extract(ctx, env, extractor.DefaultExtractOptions())
+	if err != nil {
+		return nil, nil, fmt.Errorf("提取%sSchema失败: %w", roleName(role), err)
+	}
+	return schema, env, nil
+}
+
+// roleName 返回角色的中文名称
+func roleName(role string) string {
+	switch role {
+	case "source":
+		return "源环境"
+	case "base":
+		return "基线"
+	}
+	return "目标环境"
+}
+
+// validationOptions 根据项目Docker配置和忽略规则构建验证选项
+func validationOptions(project *config.Project) docker.ValidationOptions {
+	dockerConfig := project.DockerConfig
+	options := docker.DefaultValidationOptions()
+	options.IgnoreRules = project.IgnoreRules
+	options.DefinerPolicy = project.DefinerPolicy
+	options.Seed.RowLimit = dockerConfig.SeedRows
+	options.Seed.MaskColumns = dockerConfig.MaskColumns
+	if mode, err := docker.ParseSeedMode(dockerConfig.SeedData); err == nil {
+		options.Seed.Mode = mode
+	}
+	if dockerConfig.MySQLImage != "" {
+		options.MySQLImage = dockerConfig.MySQLImage
+	}
+	if timeout, err := time.ParseDuration(dockerConfig.Timeout); err == nil && timeout > 0 {
+		options.Timeout = timeout
+	}
+	return options
+}
+
+// runSnapshot snapshot 子命令
+func runSnapshot(ctx context.Context, args []string) int {
+	var flags commonFlags
+	var envKey, output string
+	fs := newFlagSet("snapshot", "提取环境Schema并保存为快照文件，可作为 compare 等命令的 -source/-target（snapshot:<文件>）")
+	fs.StringVar(&flags.configPath, "config", "", "配置文件路径（默认 ~/.schemapatch/config.yaml）")
+	fs.StringVar(&flags.project, "project", "", "项目ID或名称（默认当前活动项目）")
+	fs.StringVar(&envKey, "env", "", "环境ID或名称（默认第一个prod环境）")
+	fs.StringVar(&output, "o", "", "快照输出文件（必填）")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if output == "" {
+		fs.Usage()
+		return ExitUsage
+	}
+
+	project, err := loadProject(&flags)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	env, err := resolveEnvironment(project, envKey, config.EnvTypeProd, "target")
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "正在提取Schema: %s (%s)\n", env.Name, env.Database)
+	schema, err := extractor.Extract(ctx, env, extractor.DefaultExtractOptions())
+	if err != nil {
+		return fail(ExitError, "提取Schema失败: %v", err)
+	}
+
+	if err := extractor.SaveSnapshot(schema, env.Name, output); err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	stats := schema.Statistics()
+	fmt.Fprintf(os.Stdout, "快照已保存到 %s（表 %d，视图 %d，存储过程 %d，函数 %d，触发器 %d）\n",
+		output, stats["tables"], stats["views"], stats["procedures"], stats["functions"], stats["triggers"])
+	return ExitOK
+}
+
+// runApply apply 子命令
+func runApply(ctx context.Context, args []string) int {
+	var flags commonFlags
+	var genFlags generateFlags
+	var scriptPath, confirm, journalFile string
+	var resume bool
+	fs := newFlagSet("apply", "在目标环境上逐条执行升级脚本，执行记录写入目标库的 "+config.JournalTable+" 表或本地文件")
+	flags.register(fs)
+	genFlags.register(fs)
+	fs.StringVar(&scriptPath, "script", "", "执行 generate -format json 保存的脚本（默认重新对比生成）")
+	fs.StringVar(&confirm, "confirm", "", "目标为prod环境时必须填写目标数据库名（多库环境为环境名）以确认执行")
+	fs.BoolVar(&resume, "resume", false, "跳过已成功的语句，从上次失败的语句继续")
+	fs.StringVar(&journalFile, "journal", "", "执行记录文件（默认写入目标库，多库环境必须指定）")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if err := flags.validate(); err != nil {
+		return fail(ExitUsage, "%v", err)
+	}
+	if isSchemaFile(flags.target) {
+		return fail(ExitUsage, "apply 的目标必须是数据库环境")
+	}
+
+	var script *sqlgen.MigrationScript
+	var targetEnv *config.Environment
+	if scriptPath != "" {
+		project, err := loadProject(&flags)
+		if err != nil {
+			return fail(ExitError, "%v", err)
+		}
+		targetEnv, err = resolveEnvironment(project, flags.target, config.EnvTypeProd, "target")
+		if err != nil {
+			return fail(ExitError, "%v", err)
+		}
+		script, err = loadScript(scriptPath)
+		if err != nil {
+			return fail(ExitError, "%v", err)
+		}
+	} else {
+		result, err := compareEnvironments(ctx, &flags)
+		if err != nil {
+			return fail(ExitError, "%v", err)
+		}
+		if !result.schemaDiff.HasDiff() {
+			fmt.Fprintln(os.Stdout, "没有差异，无需执行")
+			return ExitOK
+		}
+		script, err = result.generate(result.generateOptions(genFlags.options()))
+		if err != nil {
+			return fail(ExitError, "生成脚本失败: %v", err)
+		}
+		targetEnv = result.targetEnv
+	}
+
+	if targetEnv == nil || targetEnv.SchemaPath != "" {
+		return fail(ExitUsage, "apply 的目标必须是数据库环境")
+	}
+	if len(script.Statements) == 0 {
+		fmt.Fprintln(os.Stdout, "脚本没有语句，无需执行")
+		return ExitOK
+	}
+
+	exec := executor.NewExecutor(targetEnv)
+	if err := exec.Connect(ctx); err != nil {
+		return fail(ExitError, "连接目标环境失败: %v", err)
+	}
+	defer exec.Close()
+
+	fmt.Fprintf(os.Stderr, "正在执行脚本: %s (%s)，共 %d 条语句\n", targetEnv.Name, targetEnv.Database, len(script.Statements))
+	applyResult, err := exec.Apply(ctx, script, executor.Options{
+		Confirm:     confirm,
+		Resume:      resume,
+		JournalFile: journalFile,
+		OnStatement: func(entry executor.JournalEntry, total int) {
+			status := "✅"
+			if entry.Status != executor.StatusSuccess {
+				status = "❌"
+			}
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s %s %s `%s` (%dms)\n",
+				entry.Seq, total, status, entry.Operation, entry.ObjectType, entry.ObjectName, entry.DurationMs)
+		},
+	})
+	if applyResult == nil {
+		if errors.Is(err, executor.ErrConfirmationRequired) {
+			return fail(ExitUsage, "%v（-confirm %s）", err, executor.ConfirmationName(targetEnv))
+		}
+		return fail(ExitError, "%v", err)
+	}
+
+	if flags.format == "json" {
+		// 执行失败时以执行错误为准
+		if werr := writeJSON(os.Stdout, applyResult); werr != nil && err == nil {
+			return fail(ExitError, "输出执行结果失败: %v", werr)
+		}
+	} else {
+		fmt.Fprintf(os.Stdout, "脚本 %s: 执行 %d 条，跳过 %d 条（已成功），共 %d 条，耗时 %s\n",
+			executor.ShortID(applyResult.ScriptID), applyResult.Executed, applyResult.Skipped, applyResult.Total,
+			applyResult.Duration.Round(time.Millisecond))
+		if applyResult.Failed != nil {
+			fmt.Fprintf(os.Stdout, "\n第 %d 条语句执行失败: %s\n%s\n", applyResult.Failed.Seq, applyResult.Failed.Error, applyResult.Failed.SQL)
+			fmt.Fprintln(os.Stdout, "\n修复后使用相同的脚本和 -resume 从该语句继续")
+		}
+	}
+
+	if err != nil {
+		return fail(ExitApply, "%v", err)
+	}
+	return ExitOK
+}
+
+// loadScript 读取 generate -format json 输出的脚本
+func loadScript(path string) (*sqlgen.MigrationScript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取脚本失败: %w", err)
+	}
+	var script sqlgen.MigrationScript
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("解析脚本失败（需要 generate -format json 的输出）: %w", err)
+	}
+	return &script, nil
+}
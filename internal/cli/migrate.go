@@ -0,0 +1,251 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/executor"
+	"github.com/starvpn/schemapatch/internal/extractor"
+	"github.com/starvpn/schemapatch/internal/migration"
+)
+
+// runMigrate migrate 子命令
+func runMigrate(ctx context.Context, args []string) int {
+	actions := map[string]func(context.Context, []string) int{
+		"new":    runMigrateNew,
+		"status": runMigrateStatus,
+		"up":     runMigrateUp,
+	}
+	if len(args) > 0 {
+		if action, ok := actions[args[0]]; ok {
+			return action(ctx, args[1:])
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "用法: schemapatch migrate <new|status|up> [选项]")
+	fmt.Fprintln(os.Stderr, "  new     对比并生成下一个版本的迁移文件（up/down 和期望Schema快照）")
+	fmt.Fprintln(os.Stderr, "  status  查看环境中各版本的执行状态，并检测迁移之外的Schema漂移")
+	fmt.Fprintln(os.Stderr, "  up      在环境中按顺序执行待执行的版本")
+	return ExitUsage
+}
+
+// migrationFlags migrate 子命令共用的选项
+type migrationFlags struct {
+	flags commonFlags
+	dir   string
+}
+
+// register 注册选项，status/up 只需要项目和目标环境
+func (m *migrationFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&m.flags.configPath, "config", "", "配置文件路径（默认 ~/.schemapatch/config.yaml）")
+	fs.StringVar(&m.flags.project, "project", "", "项目ID或名称（默认当前活动项目）")
+	fs.StringVar(&m.flags.target, "target", "", "目标环境ID或名称（默认第一个prod环境）")
+	fs.StringVar(&m.flags.format, "format", "text", "输出格式: text / json")
+	fs.StringVar(&m.dir, "dir", "", "迁移文件目录（默认使用项目配置的 migrations_dir，未配置时为 ./"+migration.DefaultDir+"）")
+}
+
+// migrationsDir 返回迁移文件目录
+func (m *migrationFlags) migrationsDir(project *config.Project) string {
+	if m.dir != "" {
+		return m.dir
+	}
+	if project.MigrationsDir != "" {
+		return project.MigrationsDir
+	}
+	return migration.DefaultDir
+}
+
+// target 加载项目、迁移文件并连接目标环境
+func (m *migrationFlags) target(ctx context.Context) (*config.Project, *config.Environment, []migration.Migration, *executor.Executor, error) {
+	project, err := loadProject(&m.flags)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	env, err := resolveEnvironment(project, m.flags.target, config.EnvTypeProd, "target")
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if env.SchemaPath != "" {
+		return nil, nil, nil, nil, fmt.Errorf("环境 %s 使用DDL文件，无法记录迁移历史", env.Name)
+	}
+	migrations, err := migration.List(m.migrationsDir(project))
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	exec := executor.NewExecutor(env)
+	if err := exec.Connect(ctx); err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("连接目标环境失败: %w", err)
+	}
+	return project, env, migrations, exec, nil
+}
+
+// runMigrateNew migrate new 子命令
+func runMigrateNew(ctx context.Context, args []string) int {
+	var flags commonFlags
+	var genFlags generateFlags
+	var name, dir string
+	fs := newFlagSet("migrate new", "对比源环境与目标环境，将升级/回滚脚本写入下一个版本的迁移文件")
+	flags.register(fs)
+	genFlags.register(fs)
+	fs.StringVar(&name, "name", "", "迁移名称，如 add_user_email（必填）")
+	fs.StringVar(&dir, "dir", "", "迁移文件目录（默认使用项目配置的 migrations_dir，未配置时为 ./"+migration.DefaultDir+"）")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if name == "" {
+		fs.Usage()
+		return ExitUsage
+	}
+	if err := flags.validate(); err != nil {
+		return fail(ExitUsage, "%v", err)
+	}
+
+	result, err := compareEnvironments(ctx, &flags)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+	if result.multi != nil {
+		return fail(ExitUsage, "版本化迁移不支持多库环境")
+	}
+	if !result.schemaDiff.HasDiff() {
+		fmt.Fprintln(os.Stdout, "没有差异，无需生成迁移")
+		return ExitOK
+	}
+
+	options := result.generateOptions(genFlags.options())
+	options.IncludeRollback = true
+	script, err := result.generate(options)
+	if err != nil {
+		return fail(ExitError, "生成脚本失败: %v", err)
+	}
+
+	m := migrationFlags{flags: flags, dir: dir}
+	created, err := migration.Create(m.migrationsDir(result.project), name, script, result.sourceSchema)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	if flags.format == "json" {
+		if err := writeJSON(os.Stdout, created); err != nil {
+			return fail(ExitError, "输出迁移失败: %v", err)
+		}
+	} else {
+		fmt.Fprintf(os.Stdout, "已生成迁移 %s（%d 条语句）:\n  %s\n  %s\n  %s\n",
+			created.ID(), len(script.Statements), created.UpPath, created.DownPath, created.SchemaPath)
+	}
+	for _, warning := range script.Warnings {
+		fmt.Fprintf(os.Stderr, "警告: %s\n", warning)
+	}
+	return ExitOK
+}
+
+// runMigrateStatus migrate status 子命令
+func runMigrateStatus(ctx context.Context, args []string) int {
+	var m migrationFlags
+	fs := newFlagSet("migrate status", "查看目标环境中各迁移版本的执行状态，校验文件散列并检测Schema漂移")
+	m.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	project, env, migrations, exec, err := m.target(ctx)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+	defer exec.Close()
+
+	entries, err := migration.NewHistory(exec.DB()).Load(ctx)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+	statuses := migration.Status(migrations, entries)
+
+	fmt.Fprintf(os.Stderr, "正在提取Schema: %s (%s)\n", env.Name, env.Database)
+	live, err := extractor.Extract(ctx, env, extractor.DefaultExtractOptions())
+	if err != nil {
+		return fail(ExitError, "提取Schema失败: %v", err)
+	}
+	drift, latest, err := migration.Drift(statuses, live, project.IgnoreRules)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+
+	if m.flags.format == "json" {
+		if err := writeJSON(os.Stdout, map[string]interface{}{
+			"environment": env.Name,
+			"versions":    statuses,
+			"drift":       drift,
+		}); err != nil {
+			return fail(ExitError, "输出迁移状态失败: %v", err)
+		}
+	} else {
+		printMigrationStatus(os.Stdout, env, statuses, drift, latest)
+	}
+
+	if migration.Validate(statuses) != nil || (drift != nil && drift.HasDiff()) {
+		return ExitValidation
+	}
+	return ExitOK
+}
+
+// runMigrateUp migrate up 子命令
+func runMigrateUp(ctx context.Context, args []string) int {
+	var m migrationFlags
+	var confirm, journalFile string
+	var resume bool
+	fs := newFlagSet("migrate up", "在目标环境中按版本顺序执行待执行的迁移，并写入 "+config.HistoryTable+" 表")
+	m.register(fs)
+	fs.StringVar(&confirm, "confirm", "", "目标为prod环境时必须填写目标数据库名（多库环境为环境名）以确认执行")
+	fs.BoolVar(&resume, "resume", false, "从上次失败的语句继续执行失败的版本")
+	fs.StringVar(&journalFile, "journal", "", "逐条语句的执行记录文件（默认写入目标库）")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	_, env, migrations, exec, err := m.target(ctx)
+	if err != nil {
+		return fail(ExitError, "%v", err)
+	}
+	defer exec.Close()
+
+	applied, err := migration.Up(ctx, exec, migrations, executor.Options{
+		Confirm:     confirm,
+		Resume:      resume,
+		JournalFile: journalFile,
+		OnStatement: func(entry executor.JournalEntry, total int) {
+			status := "✅"
+			if entry.Status != executor.StatusSuccess {
+				status = "❌"
+			}
+			fmt.Fprintf(os.Stderr, "    [%d/%d] %s %s (%dms)\n", entry.Seq, total, status, entry.Operation, entry.DurationMs)
+		},
+	}, func(m *migration.Migration) {
+		fmt.Fprintf(os.Stderr, "正在执行迁移 %s\n", m.ID())
+	})
+
+	if m.flags.format == "json" {
+		// 执行失败时以执行错误为准
+		if werr := writeJSON(os.Stdout, applied); werr != nil && err == nil {
+			return fail(ExitError, "输出执行结果失败: %v", werr)
+		}
+	} else if len(applied) == 0 && err == nil {
+		fmt.Fprintf(os.Stdout, "%s 已是最新版本\n", env.Name)
+	} else {
+		for _, entry := range applied {
+			fmt.Fprintf(os.Stdout, "✅ %04d_%s (%dms)\n", entry.Version, entry.Name, entry.DurationMs)
+		}
+	}
+
+	if err != nil {
+		if errors.Is(err, executor.ErrConfirmationRequired) {
+			return fail(ExitUsage, "%v（-confirm %s）", err, executor.ConfirmationName(env))
+		}
+		return fail(ExitApply, "%v", err)
+	}
+	return ExitOK
+}
@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/docker"
+	"github.com/starvpn/schemapatch/internal/migration"
+)
+
+// writeJSON 以缩进JSON格式输出
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// writeJSONFile 以缩进格式将 v 写入文件，关闭文件失败（数据未能写入磁盘）同样返回错误
+func writeJSONFile(path string, v interface{}) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(file, v); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// printDiff 以文本格式输出差异
+func printDiff(w io.Writer, schemaDiff *diff.SchemaDiff) {
+	if !schemaDiff.HasDiff() {
+		fmt.Fprintf(w, "%s 与 %s 没有差异\n", schemaDiff.SourceEnv, schemaDiff.TargetEnv)
+		return
+	}
+
+	fmt.Fprintf(w, "差异: %s -> %s\n", schemaDiff.TargetEnv, schemaDiff.SourceEnv)
+
+	if len(schemaDiff.TableDiffs) > 0 {
+		fmt.Fprintf(w, "\n表 (%d)\n", len(schemaDiff.TableDiffs))
+		for _, td := range schemaDiff.TableDiffs {
+			printItem(w, "  ", td.Severity, td.DiffType, renamedName(td.OldName, td.TableName), td.Description)
+			for _, cd := range td.ColumnDiffs {
+				printItem(w, "      ", cd.Severity, cd.DiffType, "列 "+renamedName(cd.OldName, cd.ColumnName), cd.RiskNote)
+				for _, change := range cd.Changes {
+					fmt.Fprintf(w, "          %s: %s -> %s\n", change.Property, change.OldValue, change.NewValue)
+				}
+				printDataChecks(w, "          ", cd.DataChecks)
+			}
+			for _, id := range td.IndexDiffs {
+				printItem(w, "      ", id.Severity, id.DiffType, "索引 "+id.IndexName, id.Description)
+				printDataChecks(w, "          ", id.DataChecks)
+			}
+			for _, fkd := range td.FKeyDiffs {
+				printItem(w, "      ", fkd.Severity, fkd.DiffType, "外键 "+fkd.FKeyName, fkd.Description)
+				printDataChecks(w, "          ", fkd.DataChecks)
+			}
+			for _, ckd := range td.CheckDiffs {
+				printItem(w, "      ", ckd.Severity, ckd.DiffType, "CHECK "+ckd.CheckName, ckd.Description)
+			}
+			for _, prop := range td.TableProps {
+				fmt.Fprintf(w, "      %s: %s -> %s\n", prop.Property, prop.OldValue, prop.NewValue)
+			}
+			if pd := td.PartitionDiff; pd != nil {
+				printItem(w, "      ", pd.Severity, pd.DiffType, "分区", pd.Description)
+			}
+		}
+	}
+
+	if len(schemaDiff.ViewDiffs) > 0 {
+		fmt.Fprintf(w, "\n视图 (%d)\n", len(schemaDiff.ViewDiffs))
+		for _, vd := range schemaDiff.ViewDiffs {
+			printItem(w, "  ", vd.Severity, vd.DiffType, vd.ViewName, vd.Description)
+		}
+	}
+
+	if len(schemaDiff.ProcDiffs) > 0 {
+		fmt.Fprintf(w, "\n存储过程 (%d)\n", len(schemaDiff.ProcDiffs))
+		for _, pd := range schemaDiff.ProcDiffs {
+			printItem(w, "  ", pd.Severity, pd.DiffType, pd.ProcName, pd.Description)
+		}
+	}
+
+	if len(schemaDiff.FuncDiffs) > 0 {
+		fmt.Fprintf(w, "\n函数 (%d)\n", len(schemaDiff.FuncDiffs))
+		for _, fd := range schemaDiff.FuncDiffs {
+			printItem(w, "  ", fd.Severity, fd.DiffType, fd.FuncName, fd.Description)
+		}
+	}
+
+	if len(schemaDiff.TriggerDiffs) > 0 {
+		fmt.Fprintf(w, "\n触发器 (%d)\n", len(schemaDiff.TriggerDiffs))
+		for _, td := range schemaDiff.TriggerDiffs {
+			printItem(w, "  ", td.Severity, td.DiffType, td.TriggerName, td.Description)
+		}
+	}
+
+	if len(schemaDiff.EventDiffs) > 0 {
+		fmt.Fprintf(w, "\n事件 (%d)\n", len(schemaDiff.EventDiffs))
+		for _, ed := range schemaDiff.EventDiffs {
+			printItem(w, "  ", ed.Severity, ed.DiffType, ed.EventName, ed.Description)
+		}
+	}
+
+	counts := schemaDiff.CountBySeverity()
+	fmt.Fprintf(w, "\n共 %d 项差异 | 🔴%d 🟡%d 🟢%d | 最高级别: %s\n",
+		schemaDiff.Statistics.TotalDiffs,
+		counts[diff.SeverityDanger], counts[diff.SeverityWarning], counts[diff.SeverityInfo],
+		schemaDiff.GetMaxSeverity())
+}
+
+// printDatabases 输出多库对比中各库的对应关系
+func printDatabases(w io.Writer, multi *diff.MultiSchemaDiff) {
+	fmt.Fprintf(w, "数据库 (%d)\n", len(multi.Databases))
+	for _, db := range multi.Databases {
+		name := db.TargetDatabase
+		if db.SourceDatabase != "" && db.SourceDatabase != db.TargetDatabase {
+			name = db.SourceDatabase + " -> " + db.TargetDatabase
+		}
+		switch {
+		case db.DiffType != diff.DiffTypeModified:
+			fmt.Fprintf(w, "  %s [%s] %s\n", diff.GetDiffTypeIcon(db.DiffType), db.DiffType, name)
+		case db.Diff.HasDiff():
+			fmt.Fprintf(w, "  %s [%s] %s - %d 项差异\n", diff.GetDiffTypeIcon(db.DiffType), db.DiffType, name, db.Diff.Statistics.TotalDiffs)
+		default:
+			fmt.Fprintf(w, "  ✅ %s - 没有差异\n", name)
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// printThreeWay 输出三方对比中被保留的目标环境变更和冲突
+func printThreeWay(w io.Writer, threeWay *diff.ThreeWayDiff) {
+	if len(threeWay.TargetChanges) > 0 {
+		fmt.Fprintf(w, "\n目标环境独有变更，已保留不生成语句 (%d)\n", len(threeWay.TargetChanges))
+		for _, change := range threeWay.TargetChanges {
+			fmt.Fprintf(w, "  🔒 %s %s - 目标环境%s\n", change.ObjectType, change.Name, change.TargetChange)
+		}
+	}
+	if len(threeWay.Conflicts) > 0 {
+		fmt.Fprintf(w, "\n冲突，需要人工处理 (%d)\n", len(threeWay.Conflicts))
+		for _, change := range threeWay.Conflicts {
+			fmt.Fprintf(w, "  ⚔️ %s %s - 源环境%s，目标环境%s\n", change.ObjectType, change.Name, change.SourceChange, change.TargetChange)
+		}
+	}
+}
+
+// printConflictWarnings 将三方对比冲突输出为警告
+func printConflictWarnings(threeWay *diff.ThreeWayDiff) {
+	if threeWay == nil {
+		return
+	}
+	for _, change := range threeWay.Conflicts {
+		fmt.Fprintf(os.Stderr, "冲突: %s %s 在源环境%s、目标环境%s，未生成语句\n",
+			change.ObjectType, change.Name, change.SourceChange, change.TargetChange)
+	}
+}
+
+// printBatch 输出批量对比的分组和差异矩阵
+func printBatch(w io.Writer, report *batchReport) {
+	fmt.Fprintf(w, "批量对比: 源 %s，%d 个分组\n", report.Source, len(report.Groups))
+
+	for _, group := range report.Groups {
+		fmt.Fprintf(w, "\n#%d %s\n", group.ID, strings.Join(group.Targets, ", "))
+		if !group.schemaDiff.HasDiff() {
+			fmt.Fprintln(w, "    没有差异")
+			continue
+		}
+		counts := group.schemaDiff.CountBySeverity()
+		fmt.Fprintf(w, "    %d 项差异，%d 条语句 | 🔴%d 🟡%d 🟢%d | 最高级别: %s\n",
+			group.schemaDiff.Statistics.TotalDiffs, group.Statements,
+			counts[diff.SeverityDanger], counts[diff.SeverityWarning], counts[diff.SeverityInfo], group.MaxSeverity)
+		if group.ScriptPath != "" {
+			fmt.Fprintf(w, "    脚本: %s\n", group.ScriptPath)
+		}
+	}
+
+	if len(report.Matrix) > 0 {
+		fmt.Fprintln(w, "\n差异矩阵（● 该分组存在此差异）")
+		var header strings.Builder
+		for _, group := range report.Groups {
+			header.WriteString(fmt.Sprintf("%-4s", fmt.Sprintf("#%d", group.ID)))
+		}
+		fmt.Fprintf(w, "  %s\n", strings.TrimRight(header.String(), " "))
+		for _, row := range report.Matrix {
+			present := make(map[int]bool)
+			for _, id := range row.Groups {
+				present[id] = true
+			}
+			var cells strings.Builder
+			for _, group := range report.Groups {
+				if present[group.ID] {
+					cells.WriteString("●   ")
+				} else {
+					cells.WriteString("·   ")
+				}
+			}
+			fmt.Fprintf(w, "  %s%s [%s] %s %s\n", cells.String(), diff.GetSeverityIcon(row.Item.Severity),
+				row.Item.DiffType, row.Item.ObjectType, row.Item.Name)
+		}
+	}
+
+	if len(report.Failed) > 0 {
+		fmt.Fprintf(w, "\n失败 (%d)\n", len(report.Failed))
+		for _, target := range report.Failed {
+			fmt.Fprintf(w, "  ❌ %s: %s\n", target.Name, target.Error)
+		}
+	}
+}
+
+// printItem 输出单条差异
+func printItem(w io.Writer, indent string, severity diff.DiffSeverity, diffType diff.DiffType, name, description string) {
+	line := fmt.Sprintf("%s%s [%s] %s", indent, diff.GetSeverityIcon(severity), diffType, name)
+	if description != "" {
+		line += " - " + description
+	}
+	fmt.Fprintln(w, line)
+}
+
+// printDataChecks 输出数据预检结果
+func printDataChecks(w io.Writer, indent string, checks []diff.DataCheck) {
+	for _, check := range checks {
+		icon := "✅"
+		if check.Blocking {
+			icon = "🚫"
+		} else if check.Error != "" {
+			icon = "❔"
+		}
+		fmt.Fprintf(w, "%s%s 预检: %s\n", indent, icon, check.Message)
+	}
+}
+
+// renamedName 重命名时显示 "旧名称 -> 新名称"
+func renamedName(oldName, name string) string {
+	if oldName == "" {
+		return name
+	}
+	return oldName + " -> " + name
+}
+
+// printValidation 以文本格式输出验证结果
+func printValidation(w io.Writer, result *docker.ValidationResult) {
+	if result.Success {
+		fmt.Fprintf(w, "✅ 验证成功，耗时 %v\n", result.ExecutionTime)
+	} else {
+		fmt.Fprintf(w, "❌ 验证失败，耗时 %v\n", result.ExecutionTime)
+	}
+
+	for _, err := range result.Errors {
+		fmt.Fprintf(w, "  错误: %s\n", err)
+	}
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(w, "  警告: %s\n", warning)
+	}
+	for _, schemaDiff := range result.SchemaDiffs {
+		fmt.Fprintf(w, "  Schema差异: %s\n", schemaDiff)
+	}
+
+	if len(result.SeededRows) > 0 {
+		total := 0
+		for _, count := range result.SeededRows {
+			total += count
+		}
+		fmt.Fprintf(w, "  样本数据: %d 张表共 %d 行\n", len(result.SeededRows), total)
+	}
+	for _, warning := range result.DataWarnings {
+		fmt.Fprintf(w, "  数据警告: %s\n", warning)
+	}
+
+	if rollback := result.Rollback; rollback != nil {
+		if rollback.Match {
+			fmt.Fprintln(w, "✅ 回滚验证通过，Schema已恢复到目标环境状态")
+		} else {
+			fmt.Fprintln(w, "❌ 回滚验证未通过")
+		}
+		for _, stmt := range rollback.Irreversible {
+			fmt.Fprintf(w, "  不可回滚: %s\n", stmt)
+		}
+		for _, step := range rollback.FailedSteps {
+			fmt.Fprintf(w, "  回滚失败: %s\n", step)
+		}
+		for _, schemaDiff := range rollback.SchemaDiffs {
+			fmt.Fprintf(w, "  回滚后差异: %s\n", schemaDiff)
+		}
+	}
+}
+
+// printMigrationStatus 以文本格式输出迁移版本状态和Schema漂移
+func printMigrationStatus(w io.Writer, env *config.Environment, statuses []migration.VersionStatus, drift *diff.SchemaDiff, latest *migration.Migration) {
+	fmt.Fprintf(w, "环境 %s (%s) 的迁移状态\n\n", env.Name, env.Database)
+	if len(statuses) == 0 {
+		fmt.Fprintln(w, "  没有迁移文件")
+	}
+
+	icons := map[migration.State]string{
+		migration.StateApplied:    "✅",
+		migration.StatePending:    "⏳",
+		migration.StateModified:   "❌",
+		migration.StateMissing:    "❌",
+		migration.StateOutOfOrder: "⚠️",
+	}
+	for _, status := range statuses {
+		line := fmt.Sprintf("  %s %04d_%s [%s]", icons[status.State], status.Version, status.Name, status.State)
+		if status.AppliedAt != nil {
+			line += " " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintln(w, line)
+	}
+
+	fmt.Fprintln(w)
+	switch {
+	case latest == nil:
+		fmt.Fprintln(w, "尚未执行任何迁移，跳过漂移检测")
+	case drift == nil:
+		fmt.Fprintf(w, "迁移 %s 没有期望Schema快照，跳过漂移检测\n", latest.ID())
+	case !drift.HasDiff():
+		fmt.Fprintf(w, "✅ Schema与迁移 %s 的期望状态一致\n", latest.ID())
+	default:
+		fmt.Fprintf(w, "❌ 检测到迁移之外的Schema变更（相对迁移 %s 的期望状态）:\n\n", latest.ID())
+		printDiff(w, drift)
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -27,8 +29,16 @@ type Environment struct {
 	MySQLVersion string          `yaml:"mysql_version" json:"mysql_version"`
 	SSLEnabled   bool            `yaml:"ssl_enabled" json:"ssl_enabled"`
 	SSLConfig    *SSLConfig      `yaml:"ssl_config,omitempty" json:"ssl_config,omitempty"`
+	SchemaPath   string          `yaml:"schema_path,omitempty" json:"schema_path,omitempty"` // DDL文件或目录，设置后从文件解析Schema而不连接数据库
+	Databases    []string        `yaml:"databases,omitempty" json:"databases,omitempty"`     // 多库模式: 数据库名或通配符（如 app_*），使用 schema_path 时为其下的子目录
 }
 
+// SchemaPatch 在目标库中维护的表，对比时总是忽略
+const (
+	JournalTable = "schemapatch_journal" // 逐条语句的执行记录
+	HistoryTable = "schemapatch_history" // 已执行的迁移版本
+)
+
 // SSLConfig SSL配置
 type SSLConfig struct {
 	CAFile   string `yaml:"ca_file" json:"ca_file"`
@@ -38,13 +48,83 @@ type SSLConfig struct {
 
 // Project 项目配置
 type Project struct {
-	ID           string        `yaml:"id" json:"id"`
-	Name         string        `yaml:"name" json:"name"`
-	Environments []Environment `yaml:"environments" json:"environments"`
-	IgnoreRules  IgnoreConfig  `yaml:"ignore_rules" json:"ignore_rules"`
-	DockerConfig DockerConfig  `yaml:"docker" json:"docker"`
-	CreatedAt    time.Time     `yaml:"created_at" json:"created_at"`
-	UpdatedAt    time.Time     `yaml:"updated_at" json:"updated_at"`
+	ID               string            `yaml:"id" json:"id"`
+	Name             string            `yaml:"name" json:"name"`
+	Environments     []Environment     `yaml:"environments" json:"environments"`
+	IgnoreRules      IgnoreConfig      `yaml:"ignore_rules" json:"ignore_rules"`
+	RenameRules      RenameConfig      `yaml:"rename_rules" json:"rename_rules"`
+	DockerConfig     DockerConfig      `yaml:"docker" json:"docker"`
+	MigrationsDir    string            `yaml:"migrations_dir,omitempty" json:"migrations_dir,omitempty"`       // 版本化迁移文件目录
+	DatabaseMappings []DatabaseMapping `yaml:"database_mappings,omitempty" json:"database_mappings,omitempty"` // 多库对比时源库名到目标库名的映射
+	DefinerPolicy    DefinerPolicy     `yaml:"definer_policy,omitempty" json:"definer_policy,omitempty"`       // 视图、存储过程、函数和触发器的 DEFINER 处理策略
+	CreatedAt        time.Time         `yaml:"created_at" json:"created_at"`
+	UpdatedAt        time.Time         `yaml:"updated_at" json:"updated_at"`
+}
+
+// DatabaseMapping 多库对比时的库名映射，支持一个 * 通配符（如 *_dev -> *）
+type DatabaseMapping struct {
+	Source string `yaml:"source" json:"source"` // 源环境中的库名
+	Target string `yaml:"target" json:"target"` // 目标环境中的库名
+}
+
+// DEFINER 处理方式
+const (
+	DefinerKeep  = "keep"  // 保留源环境的 DEFINER，对比时比较
+	DefinerStrip = "strip" // 生成的语句不指定 DEFINER（使用执行脚本的用户），对比时忽略
+	DefinerMap   = "map"   // 按映射替换源环境的 DEFINER，对比时比较替换后的值
+)
+
+// DefinerPolicy DEFINER 处理策略，未配置时保持原有行为：脚本中的 DEFINER 不做处理，对比时忽略
+type DefinerPolicy struct {
+	Mode     string           `yaml:"mode,omitempty" json:"mode,omitempty"`         // keep / strip / map
+	Mappings []DefinerMapping `yaml:"mappings,omitempty" json:"mappings,omitempty"` // map 模式下的映射
+}
+
+// DefinerMapping DEFINER 映射（格式 user@host，如 dev@% -> app@%）
+type DefinerMapping struct {
+	From string `yaml:"from" json:"from"` // 源环境中的 DEFINER
+	To   string `yaml:"to" json:"to"`     // 目标环境中使用的 DEFINER
+}
+
+// Validate 检查处理方式是否有效
+func (p DefinerPolicy) Validate() error {
+	switch p.Mode {
+	case "", DefinerKeep, DefinerStrip, DefinerMap:
+		return nil
+	}
+	return fmt.Errorf("无效的 definer_policy.mode: %s（可选 keep / strip / map）", p.Mode)
+}
+
+// Enabled 是否配置了策略，未配置时不改写语句中的 DEFINER
+func (p DefinerPolicy) Enabled() bool {
+	return p.Mode != ""
+}
+
+// Compares 对比时是否比较 DEFINER
+func (p DefinerPolicy) Compares() bool {
+	return p.Mode == DefinerKeep || p.Mode == DefinerMap
+}
+
+// Apply 按策略转换 DEFINER，返回 user@host 格式，空字符串表示不指定 DEFINER
+// map 模式下没有匹配的映射时保留原值
+func (p DefinerPolicy) Apply(definer string) string {
+	definer = NormalizeDefiner(definer)
+	switch p.Mode {
+	case DefinerStrip:
+		return ""
+	case DefinerMap:
+		for _, mapping := range p.Mappings {
+			if strings.EqualFold(NormalizeDefiner(mapping.From), definer) {
+				return NormalizeDefiner(mapping.To)
+			}
+		}
+	}
+	return definer
+}
+
+// NormalizeDefiner 去掉 DEFINER 中的引号和空白，如 `dev`@`%` -> dev@%
+func NormalizeDefiner(definer string) string {
+	return strings.NewReplacer("`", "", "'", "", "\"", "", " ", "").Replace(definer)
 }
 
 // IgnoreConfig 忽略规则配置
@@ -56,14 +136,34 @@ type IgnoreConfig struct {
 	IgnoreAutoIncrement bool     `yaml:"ignore_auto_increment" json:"ignore_auto_increment"` // 是否忽略自增值变更
 	IgnoreCollation     bool     `yaml:"ignore_collation" json:"ignore_collation"`           // 是否忽略字符集变更
 	IgnoreCharset       bool     `yaml:"ignore_charset" json:"ignore_charset"`               // 是否忽略编码变更
+	IgnoreColumnOrder   bool     `yaml:"ignore_column_order" json:"ignore_column_order"`     // 是否忽略列顺序变更
+	IgnorePartitions    bool     `yaml:"ignore_partitions" json:"ignore_partitions"`         // 是否忽略分区定义变更
+}
+
+// RenameConfig 重命名识别配置
+type RenameConfig struct {
+	Tables           []RenameMapping `yaml:"tables" json:"tables"`                       // 已确认的表重命名
+	Columns          []RenameMapping `yaml:"columns" json:"columns"`                     // 已确认的列重命名 (table 为新表名)
+	DisableHeuristic bool            `yaml:"disable_heuristic" json:"disable_heuristic"` // 是否关闭启发式识别
+	MinScore         float64         `yaml:"min_score" json:"min_score"`                 // 启发式识别的最低相似度 (0-1，默认0.7)
+}
+
+// RenameMapping 重命名映射
+type RenameMapping struct {
+	Table string `yaml:"table,omitempty" json:"table,omitempty"` // 列重命名所在的表
+	From  string `yaml:"from" json:"from"`                       // 目标环境中的旧名称
+	To    string `yaml:"to" json:"to"`                           // 源环境中的新名称
 }
 
 // DockerConfig Docker验证环境配置
 type DockerConfig struct {
-	MySQLImage string `yaml:"mysql_image" json:"mysql_image"` // 如 mysql:8.0.35
-	Timeout    string `yaml:"timeout" json:"timeout"`         // 启动超时
-	Cleanup    bool   `yaml:"cleanup" json:"cleanup"`         // 验证后是否清理容器
-	Port       int    `yaml:"port" json:"port"`               // 映射端口，默认随机
+	MySQLImage  string   `yaml:"mysql_image" json:"mysql_image"`                       // 如 mysql:8.0.35
+	Timeout     string   `yaml:"timeout" json:"timeout"`                               // 启动超时
+	Cleanup     bool     `yaml:"cleanup" json:"cleanup"`                               // 验证后是否清理容器
+	Port        int      `yaml:"port" json:"port"`                                     // 映射端口，默认随机
+	SeedData    string   `yaml:"seed_data,omitempty" json:"seed_data,omitempty"`       // 验证前填充样本数据: sample(从目标环境抽样) / synthetic(按列类型合成)
+	SeedRows    int      `yaml:"seed_rows,omitempty" json:"seed_rows,omitempty"`       // 每张表填充的行数，默认100
+	MaskColumns []string `yaml:"mask_columns,omitempty" json:"mask_columns,omitempty"` // 抽样时脱敏的列 (格式: table.column，支持通配符)
 }
 
 // AppConfig 应用全局配置
@@ -147,6 +247,46 @@ func (p *Project) GetEnvironment(envID string) *Environment {
 	return nil
 }
 
+// FindEnvironment 根据ID或名称查找环境
+func (p *Project) FindEnvironment(key string) *Environment {
+	if env := p.GetEnvironment(key); env != nil {
+		return env
+	}
+	for i := range p.Environments {
+		if p.Environments[i].Name == key {
+			return &p.Environments[i]
+		}
+	}
+	return nil
+}
+
+// FirstEnvironmentOfType 获取指定类型的第一个环境
+func (p *Project) FirstEnvironmentOfType(envType EnvironmentType) *Environment {
+	for i := range p.Environments {
+		if p.Environments[i].Type == envType {
+			return &p.Environments[i]
+		}
+	}
+	return nil
+}
+
+// MapDatabase 按库名映射返回源库在目标环境中对应的库名，没有匹配的映射时返回原库名
+func (p *Project) MapDatabase(source string) string {
+	for _, mapping := range p.DatabaseMappings {
+		if mapping.Source == source {
+			return mapping.Target
+		}
+		prefix, suffix, ok := strings.Cut(mapping.Source, "*")
+		if !ok || len(source) < len(prefix)+len(suffix) ||
+			!strings.HasPrefix(source, prefix) || !strings.HasSuffix(source, suffix) {
+			continue
+		}
+		wildcard := source[len(prefix) : len(source)-len(suffix)]
+		return strings.Replace(mapping.Target, "*", wildcard, 1)
+	}
+	return source
+}
+
 // AddEnvironment 添加环境
 func (p *Project) AddEnvironment(env Environment) {
 	if env.ID == "" {
@@ -22,13 +22,16 @@ func NewStore() (*Store, error) {
 		return nil, err
 	}
 
+	return NewStoreAt(filepath.Join(configDir, "config.yaml"))
+}
+
+// NewStoreAt 使用指定路径创建配置存储（命令行模式可通过 -config 指定）
+func NewStoreAt(configPath string) (*Store, error) {
 	// 确保配置目录存在
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
 		return nil, err
 	}
 
-	configPath := filepath.Join(configDir, "config.yaml")
-
 	store := &Store{
 		configPath: configPath,
 	}
@@ -58,6 +61,15 @@ func getConfigDir() (string, error) {
 	return filepath.Join(homeDir, ".schemapatch"), nil
 }
 
+// CacheDir 获取Schema缓存目录
+func CacheDir() (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "cache"), nil
+}
+
 // Load 加载配置
 func (s *Store) Load() error {
 	s.mu.Lock()
@@ -118,6 +130,23 @@ func (s *Store) GetProject(projectID string) *Project {
 	return nil
 }
 
+// FindProject 根据ID或名称查找项目
+func (s *Store) FindProject(key string) *Project {
+	if project := s.GetProject(key); project != nil {
+		return project
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for i := range s.config.Projects {
+		if s.config.Projects[i].Name == key {
+			return &s.config.Projects[i]
+		}
+	}
+	return nil
+}
+
 // AddProject 添加项目
 func (s *Store) AddProject(project Project) error {
 	s.mu.Lock()
@@ -2,6 +2,7 @@ package diff
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/starvpn/schemapatch/internal/extractor"
@@ -9,9 +10,12 @@ import (
 
 // TableCompareOptions 表比较选项
 type TableCompareOptions struct {
-	IgnoreComments  bool
-	IgnoreCharset   bool
-	IgnoreCollation bool
+	IgnoreComments    bool
+	IgnoreCharset     bool
+	IgnoreCollation   bool
+	IgnoreColumnOrder bool
+	IgnorePartitions  bool
+	Renames           *RenameSet // 已识别的表、列重命名
 }
 
 // compareTables 比较表
@@ -61,17 +65,36 @@ func compareTablesWithOptions(source, target *extractor.TableSchema, opts TableC
 
 	// 比较列
 	colOpts := ColumnCompareOptions{
-		IgnoreComments:  opts.IgnoreComments,
-		IgnoreCharset:   opts.IgnoreCharset,
-		IgnoreCollation: opts.IgnoreCollation,
+		IgnoreComments:    opts.IgnoreComments,
+		IgnoreCharset:     opts.IgnoreCharset,
+		IgnoreCollation:   opts.IgnoreCollation,
+		IgnoreColumnOrder: opts.IgnoreColumnOrder,
+		Renames:           opts.Renames.columnRenames(source.Name),
 	}
 	diff.ColumnDiffs = compareColumnsWithOptions(source.Columns, target.Columns, colOpts)
 
-	// 比较索引
-	diff.IndexDiffs = compareIndexes(source.Indexes, target.Indexes)
+	// 比较索引（重命名的列按新名称比较）
+	diff.IndexDiffs = compareIndexesWithRenames(source.Name, source.Indexes, target.Indexes, opts.Renames)
 
 	// 比较外键
-	diff.FKeyDiffs = compareForeignKeys(source.ForeignKeys, target.ForeignKeys)
+	diff.FKeyDiffs = compareForeignKeysWithRenames(source.Name, source.ForeignKeys, target.ForeignKeys, opts.Renames)
+
+	// 比较CHECK约束
+	diff.CheckDiffs = compareChecksWithRenames(source.Name, source.Checks, target.Checks, opts.Renames)
+
+	// 比较分区
+	if !opts.IgnorePartitions {
+		diff.PartitionDiff = comparePartitions(source.Partition, target.Partition)
+	}
+
+	summarizeTableDiff(diff)
+
+	return diff
+}
+
+// summarizeTableDiff 根据子项差异计算表差异的严重程度和描述
+func summarizeTableDiff(diff *TableDiff) {
+	diff.Severity = SeverityInfo
 
 	// 计算最高严重程度
 	for _, cd := range diff.ColumnDiffs {
@@ -89,6 +112,14 @@ func compareTablesWithOptions(source, target *extractor.TableSchema, opts TableC
 			diff.Severity = fkd.Severity
 		}
 	}
+	for _, ckd := range diff.CheckDiffs {
+		if ckd.Severity > diff.Severity {
+			diff.Severity = ckd.Severity
+		}
+	}
+	if diff.PartitionDiff != nil && diff.PartitionDiff.Severity > diff.Severity {
+		diff.Severity = diff.PartitionDiff.Severity
+	}
 
 	// 生成描述
 	var changes []string
@@ -101,12 +132,16 @@ func compareTablesWithOptions(source, target *extractor.TableSchema, opts TableC
 	if len(diff.FKeyDiffs) > 0 {
 		changes = append(changes, fmt.Sprintf("%d外键变更", len(diff.FKeyDiffs)))
 	}
+	if len(diff.CheckDiffs) > 0 {
+		changes = append(changes, fmt.Sprintf("%dCHECK约束变更", len(diff.CheckDiffs)))
+	}
 	if len(diff.TableProps) > 0 {
 		changes = append(changes, fmt.Sprintf("%d属性变更", len(diff.TableProps)))
 	}
+	if diff.PartitionDiff != nil {
+		changes = append(changes, "分区变更")
+	}
 	diff.Description = strings.Join(changes, ", ")
-
-	return diff
 }
 
 // compareColumns 比较列
@@ -130,20 +165,69 @@ func compareColumnsWithOptions(sourceCols, targetCols []*extractor.ColumnSchema,
 		sourceMap[col.Name] = col
 	}
 
+	// 已识别的重命名：旧列名 -> 新列名
+	renamedFrom := make(map[string]string)
+	for name, match := range opts.Renames {
+		if sourceMap[name] != nil && targetMap[match.OldName] != nil {
+			renamedFrom[match.OldName] = name
+		}
+	}
+
+	// 列顺序：不在最长公共子序列中的已有列视为移动
+	var moved map[string]bool
+	if !opts.IgnoreColumnOrder {
+		moved = movedColumns(sourceCols, targetCols, renamedFrom)
+	}
+
+	// 末尾连续的新增列直接追加，无需指定位置
+	appendFrom := len(sourceCols)
+	for appendFrom > 0 {
+		name := sourceCols[appendFrom-1].Name
+		if _, renamed := opts.Renames[name]; targetMap[name] != nil || renamed {
+			break
+		}
+		appendFrom--
+	}
+
 	// 检查新增和修改的列
-	for _, srcCol := range sourceCols {
+	for i, srcCol := range sourceCols {
+		if match, ok := opts.Renames[srcCol.Name]; ok && renamedFrom[match.OldName] == srcCol.Name {
+			colDiff := renamedColumnDiff(srcCol, targetMap[match.OldName], match, opts)
+			if moved[srcCol.Name] {
+				addPositionChange(&colDiff, sourceCols, i, targetCols, renamedFrom)
+			}
+			diffs = append(diffs, colDiff)
+			continue
+		}
+
 		tgtCol, exists := targetMap[srcCol.Name]
 		if !exists {
 			// 新增列
-			diffs = append(diffs, ColumnDiff{
+			colDiff := ColumnDiff{
 				ColumnName: srcCol.Name,
 				DiffType:   DiffTypeAdded,
 				Severity:   SeverityInfo,
 				NewColumn:  srcCol,
-			})
+			}
+			if !opts.IgnoreColumnOrder && i < appendFrom {
+				placeColumn(&colDiff, sourceCols, i)
+			}
+			diffs = append(diffs, colDiff)
 		} else {
 			// 比较列是否有变化
 			colDiff := compareColumnWithOptions(srcCol, tgtCol, opts)
+			if moved[srcCol.Name] {
+				if colDiff == nil {
+					colDiff = &ColumnDiff{
+						ColumnName: srcCol.Name,
+						DiffType:   DiffTypeModified,
+						Severity:   SeverityInfo,
+						OldColumn:  tgtCol,
+						NewColumn:  srcCol,
+					}
+				}
+				addPositionChange(colDiff, sourceCols, i, targetCols, renamedFrom)
+			}
 			if colDiff != nil {
 				diffs = append(diffs, *colDiff)
 			}
@@ -152,6 +236,9 @@ func compareColumnsWithOptions(sourceCols, targetCols []*extractor.ColumnSchema,
 
 	// 检查删除的列
 	for _, tgtCol := range targetCols {
+		if _, renamed := renamedFrom[tgtCol.Name]; renamed {
+			continue
+		}
 		if _, exists := sourceMap[tgtCol.Name]; !exists {
 			diffs = append(diffs, ColumnDiff{
 				ColumnName: tgtCol.Name,
@@ -166,11 +253,135 @@ func compareColumnsWithOptions(sourceCols, targetCols []*extractor.ColumnSchema,
 	return diffs
 }
 
+// movedColumns 找出顺序发生变化的列
+// 源、目标共有的列中，不在两者最长公共子序列中的列需要移动位置
+func movedColumns(sourceCols, targetCols []*extractor.ColumnSchema, renamedFrom map[string]string) map[string]bool {
+	sourceSet := make(map[string]bool)
+	for _, col := range sourceCols {
+		sourceSet[col.Name] = true
+	}
+
+	// 目标列按新名称表示，只保留源中也存在的列
+	var tgtSeq []string
+	for _, col := range targetCols {
+		name := col.Name
+		if newName, ok := renamedFrom[name]; ok {
+			name = newName
+		}
+		if sourceSet[name] {
+			tgtSeq = append(tgtSeq, name)
+		}
+	}
+	common := make(map[string]bool)
+	for _, name := range tgtSeq {
+		common[name] = true
+	}
+	var srcSeq []string
+	for _, col := range sourceCols {
+		if common[col.Name] {
+			srcSeq = append(srcSeq, col.Name)
+		}
+	}
+
+	// 最长公共子序列
+	n, m := len(srcSeq), len(tgtSeq)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if srcSeq[i] == tgtSeq[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	moved := make(map[string]bool)
+	for _, name := range srcSeq {
+		moved[name] = true
+	}
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case srcSeq[i] == tgtSeq[j]:
+			delete(moved, srcSeq[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return moved
+}
+
+// placeColumn 按源表中的前一列设置列的放置位置
+func placeColumn(cd *ColumnDiff, sourceCols []*extractor.ColumnSchema, index int) {
+	if index == 0 {
+		cd.First = true
+		return
+	}
+	cd.AfterColumn = sourceCols[index-1].Name
+}
+
+// addPositionChange 为移动的列添加位置变更
+func addPositionChange(cd *ColumnDiff, sourceCols []*extractor.ColumnSchema, index int, targetCols []*extractor.ColumnSchema, renamedFrom map[string]string) {
+	placeColumn(cd, sourceCols, index)
+	newPos := positionClause(cd.First, cd.AfterColumn)
+
+	oldPos := ""
+	oldName := cd.ColumnName
+	if cd.OldName != "" {
+		oldName = cd.OldName
+	}
+	for i, col := range targetCols {
+		if col.Name != oldName {
+			continue
+		}
+		if i == 0 {
+			oldPos = positionClause(true, "")
+		} else {
+			prev := targetCols[i-1].Name
+			if newName, ok := renamedFrom[prev]; ok {
+				prev = newName
+			}
+			oldPos = positionClause(false, prev)
+		}
+		break
+	}
+
+	cd.Changes = append(cd.Changes, PropertyDiff{
+		Property: "位置",
+		OldValue: oldPos,
+		NewValue: newPos,
+	})
+	if cd.RiskNote == "" {
+		cd.RiskNote = "调整列顺序需要重建表"
+	} else {
+		cd.RiskNote += "; 调整列顺序需要重建表"
+	}
+}
+
+// positionClause 列位置的描述，与 MySQL 语法一致
+func positionClause(first bool, after string) string {
+	if first {
+		return "FIRST"
+	}
+	return fmt.Sprintf("AFTER `%s`", after)
+}
+
 // ColumnCompareOptions 列比较选项
 type ColumnCompareOptions struct {
-	IgnoreComments  bool
-	IgnoreCharset   bool
-	IgnoreCollation bool
+	IgnoreComments    bool
+	IgnoreCharset     bool
+	IgnoreCollation   bool
+	IgnoreColumnOrder bool
+	Renames           map[string]RenameMatch // 新列名 -> 重命名匹配
 }
 
 // compareColumn 比较单个列
@@ -386,6 +597,11 @@ func extractBaseType(colType string) string {
 
 // compareIndexes 比较索引
 func compareIndexes(sourceIdxs, targetIdxs map[string]*extractor.IndexSchema) []IndexDiff {
+	return compareIndexesWithRenames("", sourceIdxs, targetIdxs, nil)
+}
+
+// compareIndexesWithRenames 比较索引，目标索引中重命名的列按新名称比较
+func compareIndexesWithRenames(tableName string, sourceIdxs, targetIdxs map[string]*extractor.IndexSchema, renames *RenameSet) []IndexDiff {
 	var diffs []IndexDiff
 
 	// 检查新增和修改的索引
@@ -401,7 +617,7 @@ func compareIndexes(sourceIdxs, targetIdxs map[string]*extractor.IndexSchema) []
 			})
 		} else {
 			// 比较索引是否有变化
-			if !indexEquals(srcIdx, tgtIdx) {
+			if !indexEquals(srcIdx, renames.translateIndex(tableName, tgtIdx)) {
 				diffs = append(diffs, IndexDiff{
 					IndexName:   name,
 					DiffType:    DiffTypeModified,
@@ -455,6 +671,11 @@ func indexEquals(a, b *extractor.IndexSchema) bool {
 
 // compareForeignKeys 比较外键
 func compareForeignKeys(sourceFKs, targetFKs map[string]*extractor.ForeignKey) []ForeignKeyDiff {
+	return compareForeignKeysWithRenames("", sourceFKs, targetFKs, nil)
+}
+
+// compareForeignKeysWithRenames 比较外键，目标外键中重命名的表和列按新名称比较
+func compareForeignKeysWithRenames(tableName string, sourceFKs, targetFKs map[string]*extractor.ForeignKey, renames *RenameSet) []ForeignKeyDiff {
 	var diffs []ForeignKeyDiff
 
 	// 检查新增和修改的外键
@@ -470,7 +691,7 @@ func compareForeignKeys(sourceFKs, targetFKs map[string]*extractor.ForeignKey) [
 			})
 		} else {
 			// 比较外键是否有变化
-			if !foreignKeyEquals(srcFK, tgtFK) {
+			if !foreignKeyEquals(srcFK, renames.translateForeignKey(tableName, tgtFK)) {
 				diffs = append(diffs, ForeignKeyDiff{
 					FKeyName:    name,
 					DiffType:    DiffTypeModified,
@@ -514,3 +735,89 @@ func foreignKeyEquals(a, b *extractor.ForeignKey) bool {
 	}
 	return true
 }
+
+// compareChecksWithRenames 比较CHECK约束，目标约束中重命名的列按新名称比较
+func compareChecksWithRenames(tableName string, sourceChecks, targetChecks map[string]*extractor.CheckConstraint, renames *RenameSet) []CheckDiff {
+	var diffs []CheckDiff
+
+	// 检查新增和修改的约束
+	for name, srcCheck := range sourceChecks {
+		tgtCheck, exists := targetChecks[name]
+		if !exists {
+			diffs = append(diffs, CheckDiff{
+				CheckName:   name,
+				DiffType:    DiffTypeAdded,
+				Severity:    checkAddSeverity(srcCheck),
+				NewCheck:    srcCheck,
+				Description: "新增CHECK约束 " + srcCheck.Expression,
+			})
+			continue
+		}
+
+		translated := renames.translateCheck(tableName, tgtCheck)
+		switch {
+		case normalizeCheckExpr(srcCheck.Expression) != normalizeCheckExpr(translated.Expression):
+			diffs = append(diffs, CheckDiff{
+				CheckName:   name,
+				DiffType:    DiffTypeModified,
+				Severity:    checkAddSeverity(srcCheck),
+				OldCheck:    tgtCheck,
+				NewCheck:    srcCheck,
+				ExprChanged: true,
+				Description: fmt.Sprintf("CHECK约束 %s -> %s", tgtCheck.Expression, srcCheck.Expression),
+			})
+		case srcCheck.Enforced != tgtCheck.Enforced:
+			diffs = append(diffs, CheckDiff{
+				CheckName:   name,
+				DiffType:    DiffTypeModified,
+				Severity:    checkAddSeverity(srcCheck),
+				OldCheck:    tgtCheck,
+				NewCheck:    srcCheck,
+				Description: fmt.Sprintf("CHECK约束 %s -> %s", enforcedText(tgtCheck.Enforced), enforcedText(srcCheck.Enforced)),
+			})
+		}
+	}
+
+	// 检查删除的约束
+	for name, tgtCheck := range targetChecks {
+		if _, exists := sourceChecks[name]; !exists {
+			diffs = append(diffs, CheckDiff{
+				CheckName:   name,
+				DiffType:    DiffTypeRemoved,
+				Severity:    SeverityInfo,
+				OldCheck:    tgtCheck,
+				Description: "删除CHECK约束 " + tgtCheck.Expression,
+			})
+		}
+	}
+
+	return diffs
+}
+
+// checkAddSeverity 启用的CHECK约束会校验现有数据，不满足时变更失败
+func checkAddSeverity(check *extractor.CheckConstraint) DiffSeverity {
+	if check.Enforced {
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
+
+// enforcedText 返回约束的启用状态
+func enforcedText(enforced bool) string {
+	if enforced {
+		return "ENFORCED"
+	}
+	return "NOT ENFORCED"
+}
+
+// charsetIntroducerPattern 匹配字符串常量前的字符集前缀
+var charsetIntroducerPattern = regexp.MustCompile(`_[A-Za-z0-9]+'`)
+
+// normalizeCheckExpr 规范化CHECK表达式
+// INFORMATION_SCHEMA 中的表达式带反引号和字符集前缀（如 _utf8mb4'a'），DDL 文件中的写法可能不同
+func normalizeCheckExpr(expr string) string {
+	expr = charsetIntroducerPattern.ReplaceAllString(expr, "'")
+	expr = strings.ReplaceAll(expr, "`", "")
+	expr = strings.Join(strings.Fields(expr), "")
+	return strings.ToLower(expr)
+}
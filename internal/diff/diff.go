@@ -1,6 +1,7 @@
 package diff
 
 import (
+	"fmt"
 	"path/filepath"
 	"strings"
 	"time"
@@ -12,6 +13,8 @@ import (
 // DiffEngine 差异分析引擎
 type DiffEngine struct {
 	ignoreRules config.IgnoreConfig
+	renameRules config.RenameConfig
+	definer     config.DefinerPolicy
 }
 
 // NewDiffEngine 创建差异分析引擎
@@ -19,6 +22,16 @@ func NewDiffEngine(ignoreRules config.IgnoreConfig) *DiffEngine {
 	return &DiffEngine{ignoreRules: ignoreRules}
 }
 
+// SetRenameRules 设置重命名识别规则
+func (e *DiffEngine) SetRenameRules(rules config.RenameConfig) {
+	e.renameRules = rules
+}
+
+// SetDefinerPolicy 设置 DEFINER 处理策略，keep 和 map 模式下比较视图、存储过程、函数和触发器的 DEFINER
+func (e *DiffEngine) SetDefinerPolicy(policy config.DefinerPolicy) {
+	e.definer = policy
+}
+
 // Compare 比较两个Schema
 // source: 开发环境Schema (新的)
 // target: 生产环境Schema (旧的)
@@ -33,17 +46,26 @@ func (e *DiffEngine) Compare(source, target *extractor.DatabaseSchema) *SchemaDi
 	// 比较表
 	diff.TableDiffs = e.compareTables(source.Tables, target.Tables)
 
+	// 视图、存储过程等定义比较时忽略两侧当前库名的限定，任一侧服务器不区分表名大小写时标识符也不区分
+	normalize := extractor.NormalizeOptions{
+		Databases:       []string{source.Database, target.Database},
+		FoldIdentifiers: source.LowerCaseTableNames != 0 || target.LowerCaseTableNames != 0,
+	}
+
 	// 比较视图
-	diff.ViewDiffs = e.compareViews(source.Views, target.Views)
+	diff.ViewDiffs = e.compareViews(source.Views, target.Views, normalize)
 
 	// 比较存储过程
-	diff.ProcDiffs = e.compareProcedures(source.Procedures, target.Procedures)
+	diff.ProcDiffs = e.compareProcedures(source.Procedures, target.Procedures, normalize)
 
 	// 比较函数
-	diff.FuncDiffs = e.compareFunctions(source.Functions, target.Functions)
+	diff.FuncDiffs = e.compareFunctions(source.Functions, target.Functions, normalize)
 
 	// 比较触发器
-	diff.TriggerDiffs = e.compareTriggers(source.Triggers, target.Triggers)
+	diff.TriggerDiffs = e.compareTriggers(source.Triggers, target.Triggers, normalize)
+
+	// 比较事件
+	diff.EventDiffs = e.compareEvents(source.Events, target.Events, normalize)
 
 	// 计算统计信息
 	diff.Statistics = e.calculateStatistics(diff)
@@ -55,11 +77,17 @@ func (e *DiffEngine) Compare(source, target *extractor.DatabaseSchema) *SchemaDi
 func (e *DiffEngine) compareTables(sourceTables, targetTables map[string]*extractor.TableSchema) []TableDiff {
 	var diffs []TableDiff
 
+	// 识别重命名，避免生成 DROP + ADD
+	renames := e.detectRenames(sourceTables, targetTables)
+
 	// 构建比较选项
 	opts := TableCompareOptions{
-		IgnoreComments:  e.ignoreRules.IgnoreComments,
-		IgnoreCharset:   e.ignoreRules.IgnoreCharset,
-		IgnoreCollation: e.ignoreRules.IgnoreCollation,
+		IgnoreComments:    e.ignoreRules.IgnoreComments,
+		IgnoreCharset:     e.ignoreRules.IgnoreCharset,
+		IgnoreCollation:   e.ignoreRules.IgnoreCollation,
+		IgnoreColumnOrder: e.ignoreRules.IgnoreColumnOrder,
+		IgnorePartitions:  e.ignoreRules.IgnorePartitions,
+		Renames:           renames,
 	}
 
 	// 检查新增和修改的表
@@ -69,7 +97,8 @@ func (e *DiffEngine) compareTables(sourceTables, targetTables map[string]*extrac
 			continue
 		}
 
-		tgtTable, exists := targetTables[name]
+		oldName := renames.oldTableName(name)
+		tgtTable, exists := targetTables[oldName]
 		if !exists {
 			// 新增表
 			diffs = append(diffs, TableDiff{
@@ -85,12 +114,20 @@ func (e *DiffEngine) compareTables(sourceTables, targetTables map[string]*extrac
 			
 			// 过滤忽略的列
 			tableDiff.ColumnDiffs = e.filterIgnoredColumns(name, tableDiff.ColumnDiffs)
+
+			// 重命名的表始终输出
+			if oldName != name {
+				diffs = append(diffs, *renamedTableDiff(tableDiff, renames.Tables[name]))
+				continue
+			}
 			
 			// 如果有差异，添加到结果
 			if len(tableDiff.ColumnDiffs) > 0 || 
 			   len(tableDiff.IndexDiffs) > 0 || 
 			   len(tableDiff.FKeyDiffs) > 0 ||
-			   len(tableDiff.TableProps) > 0 {
+			   len(tableDiff.CheckDiffs) > 0 ||
+			   len(tableDiff.TableProps) > 0 ||
+			   tableDiff.PartitionDiff != nil {
 				diffs = append(diffs, *tableDiff)
 			}
 		}
@@ -102,7 +139,7 @@ func (e *DiffEngine) compareTables(sourceTables, targetTables map[string]*extrac
 			continue
 		}
 
-		if _, exists := sourceTables[name]; !exists {
+		if _, exists := sourceTables[name]; !exists && renames.newTableName(name) == name {
 			diffs = append(diffs, TableDiff{
 				TableName:   name,
 				DiffType:    DiffTypeRemoved,
@@ -117,7 +154,7 @@ func (e *DiffEngine) compareTables(sourceTables, targetTables map[string]*extrac
 }
 
 // compareViews 比较视图
-func (e *DiffEngine) compareViews(sourceViews, targetViews map[string]*extractor.ViewSchema) []ViewDiff {
+func (e *DiffEngine) compareViews(sourceViews, targetViews map[string]*extractor.ViewSchema, normalize extractor.NormalizeOptions) []ViewDiff {
 	var diffs []ViewDiff
 
 	// 检查新增和修改的视图
@@ -133,14 +170,20 @@ func (e *DiffEngine) compareViews(sourceViews, targetViews map[string]*extractor
 			})
 		} else {
 			// 比较视图定义
-			if srcView.Definition != tgtView.Definition {
+			description := ""
+			if !sameDefinition(srcView.Definition, tgtView.Definition, normalize) {
+				description = "视图定义已变更"
+			} else if oldDefiner, newDefiner, changed := e.definerChanged(srcView.Definer, tgtView.Definer); changed {
+				description = fmt.Sprintf("视图定义者已变更: %s -> %s", oldDefiner, newDefiner)
+			}
+			if description != "" {
 				diffs = append(diffs, ViewDiff{
 					ViewName:    name,
 					DiffType:    DiffTypeModified,
 					Severity:    SeverityWarning,
 					OldView:     tgtView,
 					NewView:     srcView,
-					Description: "视图定义已变更",
+					Description: description,
 				})
 			}
 		}
@@ -163,7 +206,7 @@ func (e *DiffEngine) compareViews(sourceViews, targetViews map[string]*extractor
 }
 
 // compareProcedures 比较存储过程
-func (e *DiffEngine) compareProcedures(sourceProcs, targetProcs map[string]*extractor.ProcedureSchema) []ProcedureDiff {
+func (e *DiffEngine) compareProcedures(sourceProcs, targetProcs map[string]*extractor.ProcedureSchema, normalize extractor.NormalizeOptions) []ProcedureDiff {
 	var diffs []ProcedureDiff
 
 	for name, srcProc := range sourceProcs {
@@ -177,14 +220,15 @@ func (e *DiffEngine) compareProcedures(sourceProcs, targetProcs map[string]*extr
 				Description: "新增存储过程",
 			})
 		} else {
-			if srcProc.Definition != tgtProc.Definition {
+			if props := e.compareProcedureProperties(srcProc, tgtProc, normalize); len(props) > 0 {
 				diffs = append(diffs, ProcedureDiff{
-					ProcName:    name,
-					DiffType:    DiffTypeModified,
-					Severity:    SeverityWarning,
-					OldProc:     tgtProc,
-					NewProc:     srcProc,
-					Description: "存储过程定义已变更",
+					ProcName:      name,
+					DiffType:      DiffTypeModified,
+					Severity:      routinePropertySeverity(props),
+					OldProc:       tgtProc,
+					NewProc:       srcProc,
+					PropertyDiffs: props,
+					Description:   fmt.Sprintf("存储过程%s已变更", propertyNames(props)),
 				})
 			}
 		}
@@ -206,7 +250,7 @@ func (e *DiffEngine) compareProcedures(sourceProcs, targetProcs map[string]*extr
 }
 
 // compareFunctions 比较函数
-func (e *DiffEngine) compareFunctions(sourceFuncs, targetFuncs map[string]*extractor.FunctionSchema) []FunctionDiff {
+func (e *DiffEngine) compareFunctions(sourceFuncs, targetFuncs map[string]*extractor.FunctionSchema, normalize extractor.NormalizeOptions) []FunctionDiff {
 	var diffs []FunctionDiff
 
 	for name, srcFunc := range sourceFuncs {
@@ -220,14 +264,15 @@ func (e *DiffEngine) compareFunctions(sourceFuncs, targetFuncs map[string]*extra
 				Description: "新增函数",
 			})
 		} else {
-			if srcFunc.Definition != tgtFunc.Definition || srcFunc.Returns != tgtFunc.Returns {
+			if props := e.compareFunctionProperties(srcFunc, tgtFunc, normalize); len(props) > 0 {
 				diffs = append(diffs, FunctionDiff{
-					FuncName:    name,
-					DiffType:    DiffTypeModified,
-					Severity:    SeverityWarning,
-					OldFunc:     tgtFunc,
-					NewFunc:     srcFunc,
-					Description: "函数定义已变更",
+					FuncName:      name,
+					DiffType:      DiffTypeModified,
+					Severity:      routinePropertySeverity(props),
+					OldFunc:       tgtFunc,
+					NewFunc:       srcFunc,
+					PropertyDiffs: props,
+					Description:   fmt.Sprintf("函数%s已变更", propertyNames(props)),
 				})
 			}
 		}
@@ -249,7 +294,7 @@ func (e *DiffEngine) compareFunctions(sourceFuncs, targetFuncs map[string]*extra
 }
 
 // compareTriggers 比较触发器
-func (e *DiffEngine) compareTriggers(sourceTriggers, targetTriggers map[string]*extractor.TriggerSchema) []TriggerDiff {
+func (e *DiffEngine) compareTriggers(sourceTriggers, targetTriggers map[string]*extractor.TriggerSchema, normalize extractor.NormalizeOptions) []TriggerDiff {
 	var diffs []TriggerDiff
 
 	for name, srcTrigger := range sourceTriggers {
@@ -263,16 +308,22 @@ func (e *DiffEngine) compareTriggers(sourceTriggers, targetTriggers map[string]*
 				Description: "新增触发器",
 			})
 		} else {
-			if srcTrigger.Statement != tgtTrigger.Statement ||
+			description := ""
+			if !sameDefinition(srcTrigger.Statement, tgtTrigger.Statement, normalize) ||
 				srcTrigger.Event != tgtTrigger.Event ||
 				srcTrigger.Timing != tgtTrigger.Timing {
+				description = "触发器定义已变更"
+			} else if oldDefiner, newDefiner, changed := e.definerChanged(srcTrigger.Definer, tgtTrigger.Definer); changed {
+				description = fmt.Sprintf("触发器定义者已变更: %s -> %s", oldDefiner, newDefiner)
+			}
+			if description != "" {
 				diffs = append(diffs, TriggerDiff{
 					TriggerName: name,
 					DiffType:    DiffTypeModified,
 					Severity:    SeverityWarning,
 					OldTrigger:  tgtTrigger,
 					NewTrigger:  srcTrigger,
-					Description: "触发器定义已变更",
+					Description: description,
 				})
 			}
 		}
@@ -293,8 +344,122 @@ func (e *DiffEngine) compareTriggers(sourceTriggers, targetTriggers map[string]*
 	return diffs
 }
 
+// compareEvents 比较事件
+func (e *DiffEngine) compareEvents(sourceEvents, targetEvents map[string]*extractor.EventSchema, normalize extractor.NormalizeOptions) []EventDiff {
+	var diffs []EventDiff
+
+	for name, srcEvent := range sourceEvents {
+		tgtEvent, exists := targetEvents[name]
+		if !exists {
+			diffs = append(diffs, EventDiff{
+				EventName:   name,
+				DiffType:    DiffTypeAdded,
+				Severity:    SeverityInfo,
+				NewEvent:    srcEvent,
+				Description: "新增事件",
+			})
+			continue
+		}
+
+		props := e.compareEventProperties(srcEvent, tgtEvent, normalize)
+		if len(props) == 0 {
+			continue
+		}
+
+		// 只有状态、注释或定义者变化时不影响事件逻辑
+		severity := SeverityInfo
+		var changed []string
+		for _, prop := range props {
+			changed = append(changed, prop.Property)
+			if prop.Property == "调度" || prop.Property == "事件体" || prop.Property == "sql_mode" {
+				severity = SeverityWarning
+			}
+		}
+
+		diffs = append(diffs, EventDiff{
+			EventName:     name,
+			DiffType:      DiffTypeModified,
+			Severity:      severity,
+			OldEvent:      tgtEvent,
+			NewEvent:      srcEvent,
+			PropertyDiffs: props,
+			Description:   fmt.Sprintf("事件%s已变更", strings.Join(changed, "、")),
+		})
+	}
+
+	for name, tgtEvent := range targetEvents {
+		if _, exists := sourceEvents[name]; !exists {
+			diffs = append(diffs, EventDiff{
+				EventName:   name,
+				DiffType:    DiffTypeRemoved,
+				Severity:    SeverityWarning,
+				OldEvent:    tgtEvent,
+				Description: "删除事件",
+			})
+		}
+	}
+
+	return diffs
+}
+
+// compareEventProperties 比较事件属性
+// 未指定 STARTS 时 MySQL 以创建时间填充，各环境必然不同，因此调度比较不包含 STARTS
+func (e *DiffEngine) compareEventProperties(source, target *extractor.EventSchema, normalize extractor.NormalizeOptions) []PropertyDiff {
+	var props []PropertyDiff
+
+	if source.EventType != target.EventType ||
+		source.ExecuteAt != target.ExecuteAt ||
+		source.IntervalValue != target.IntervalValue ||
+		source.IntervalField != target.IntervalField ||
+		source.Ends != target.Ends {
+		props = append(props, PropertyDiff{Property: "调度", OldValue: target.Schedule(), NewValue: source.Schedule()})
+	}
+	if source.Status != target.Status {
+		props = append(props, PropertyDiff{Property: "状态", OldValue: target.Status, NewValue: source.Status})
+	}
+	if source.OnCompletion != target.OnCompletion {
+		props = append(props, PropertyDiff{Property: "完成后", OldValue: target.OnCompletion, NewValue: source.OnCompletion})
+	}
+	if !sameDefinition(source.Body, target.Body, normalize) {
+		props = append(props, PropertyDiff{Property: "事件体", OldValue: target.Body, NewValue: source.Body})
+	}
+	if !e.ignoreRules.IgnoreComments && source.Comment != target.Comment {
+		props = append(props, PropertyDiff{Property: "注释", OldValue: target.Comment, NewValue: source.Comment})
+	}
+	if source.SQLMode != "" && target.SQLMode != "" && !strings.EqualFold(source.SQLMode, target.SQLMode) {
+		props = append(props, PropertyDiff{Property: "sql_mode", OldValue: target.SQLMode, NewValue: source.SQLMode})
+	}
+	if oldDefiner, newDefiner, changed := e.definerChanged(source.Definer, target.Definer); changed {
+		props = append(props, PropertyDiff{Property: "定义者", OldValue: oldDefiner, NewValue: newDefiner})
+	}
+
+	return props
+}
+
+// sameDefinition 判断视图、存储过程、触发器等定义是否等价
+// 忽略空白、注释、DEFINER、两侧当前库名的限定、标识符引号和关键字大小写的差异，差异中仍展示原始定义
+func sameDefinition(source, target string, normalize extractor.NormalizeOptions) bool {
+	if source == target {
+		return true
+	}
+	return extractor.NormalizeSQL(source, normalize) == extractor.NormalizeSQL(target, normalize)
+}
+
+// definerChanged 按策略比较 DEFINER，源环境的 DEFINER 先按映射转换，返回目标环境和期望的 DEFINER
+// strip 模式或未配置策略时不比较；任一侧为空（DDL文件中未指定或为 CURRENT_USER）时无法判断，也不比较
+func (e *DiffEngine) definerChanged(source, target string) (string, string, bool) {
+	if !e.definer.Compares() || source == "" || target == "" {
+		return "", "", false
+	}
+	oldDefiner, newDefiner := config.NormalizeDefiner(target), e.definer.Apply(source)
+	return oldDefiner, newDefiner, !strings.EqualFold(oldDefiner, newDefiner)
+}
+
 // shouldIgnoreTable 检查是否应该忽略表
 func (e *DiffEngine) shouldIgnoreTable(tableName string) bool {
+	if tableName == config.JournalTable || tableName == config.HistoryTable {
+		return true
+	}
 	for _, pattern := range e.ignoreRules.Tables {
 		if matched, _ := filepath.Match(pattern, tableName); matched {
 			return true
@@ -365,6 +530,13 @@ func (e *DiffEngine) calculateStatistics(diff *SchemaDiff) DiffStatistics {
 			stats.TablesRemoved++
 		case DiffTypeModified:
 			stats.TablesChanged++
+		case DiffTypeRenamed:
+			stats.TablesRenamed++
+		}
+		for _, cd := range td.ColumnDiffs {
+			if cd.DiffType == DiffTypeRenamed {
+				stats.ColumnsRenamed++
+			}
 		}
 		switch td.Severity {
 		case SeverityDanger:
@@ -420,8 +592,20 @@ func (e *DiffEngine) calculateStatistics(diff *SchemaDiff) DiffStatistics {
 		}
 	}
 
+	for _, ed := range diff.EventDiffs {
+		switch ed.DiffType {
+		case DiffTypeAdded:
+			stats.EventsAdded++
+		case DiffTypeRemoved:
+			stats.EventsRemoved++
+		case DiffTypeModified:
+			stats.EventsChanged++
+		}
+	}
+
 	stats.TotalDiffs = len(diff.TableDiffs) + len(diff.ViewDiffs) +
-		len(diff.ProcDiffs) + len(diff.FuncDiffs) + len(diff.TriggerDiffs)
+		len(diff.ProcDiffs) + len(diff.FuncDiffs) + len(diff.TriggerDiffs) +
+		len(diff.EventDiffs)
 
 	return stats
 }
@@ -0,0 +1,79 @@
+package diff
+
+import "fmt"
+
+// DiffItem 展开后的单项差异，用于跨多个目标汇总
+type DiffItem struct {
+	Key        string       `json:"key"` // 对象类型:名称:差异类型，同一变更在不同目标中相同
+	ObjectType string       `json:"object_type"`
+	Name       string       `json:"name"`
+	DiffType   DiffType     `json:"diff_type"`
+	Severity   DiffSeverity `json:"severity"`
+}
+
+// Items 将差异展开为逐项列表，表的子项以 表名.对象名 表示
+func (d *SchemaDiff) Items() []DiffItem {
+	var items []DiffItem
+	add := func(objectType, name string, diffType DiffType, severity DiffSeverity) {
+		items = append(items, DiffItem{
+			Key:        objectType + ":" + name + ":" + diffType.String(),
+			ObjectType: objectType,
+			Name:       name,
+			DiffType:   diffType,
+			Severity:   severity,
+		})
+	}
+
+	for _, td := range d.TableDiffs {
+		name := td.TableName
+		if td.OldName != "" {
+			name = td.OldName + " -> " + td.TableName
+		}
+		if td.DiffType != DiffTypeModified {
+			add("表", name, td.DiffType, td.Severity)
+		}
+		if td.DiffType == DiffTypeAdded || td.DiffType == DiffTypeRemoved {
+			continue
+		}
+		for _, cd := range td.ColumnDiffs {
+			column := cd.ColumnName
+			if cd.OldName != "" {
+				column = cd.OldName + " -> " + cd.ColumnName
+			}
+			add("列", td.TableName+"."+column, cd.DiffType, cd.Severity)
+		}
+		for _, id := range td.IndexDiffs {
+			add("索引", td.TableName+"."+id.IndexName, id.DiffType, id.Severity)
+		}
+		for _, fkd := range td.FKeyDiffs {
+			add("外键", td.TableName+"."+fkd.FKeyName, fkd.DiffType, fkd.Severity)
+		}
+		for _, ckd := range td.CheckDiffs {
+			add("CHECK约束", td.TableName+"."+ckd.CheckName, ckd.DiffType, ckd.Severity)
+		}
+		for _, prop := range td.TableProps {
+			add("表属性", fmt.Sprintf("%s.%s (%s -> %s)", td.TableName, prop.Property, prop.OldValue, prop.NewValue),
+				DiffTypeModified, SeverityInfo)
+		}
+		if pd := td.PartitionDiff; pd != nil {
+			add("分区", td.TableName, pd.DiffType, pd.Severity)
+		}
+	}
+
+	for _, vd := range d.ViewDiffs {
+		add("视图", vd.ViewName, vd.DiffType, vd.Severity)
+	}
+	for _, pd := range d.ProcDiffs {
+		add("存储过程", pd.ProcName, pd.DiffType, pd.Severity)
+	}
+	for _, fd := range d.FuncDiffs {
+		add("函数", fd.FuncName, fd.DiffType, fd.Severity)
+	}
+	for _, td := range d.TriggerDiffs {
+		add("触发器", td.TriggerName, td.DiffType, td.Severity)
+	}
+	for _, ed := range d.EventDiffs {
+		add("事件", ed.EventName, ed.DiffType, ed.Severity)
+	}
+	return items
+}
@@ -0,0 +1,144 @@
+package diff
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// DatabaseDiff 多库对比中单个数据库的差异
+// DiffType 为新增时目标环境缺少该库，为删除时源环境中没有对应的库
+type DatabaseDiff struct {
+	SourceDatabase string      `json:"source_database,omitempty"`
+	TargetDatabase string      `json:"target_database"`
+	DiffType       DiffType    `json:"diff_type"`
+	Diff           *SchemaDiff `json:"diff"`
+}
+
+// MultiSchemaDiff 多库对比结果
+type MultiSchemaDiff struct {
+	SourceEnv   string         `json:"source_env"`
+	TargetEnv   string         `json:"target_env"`
+	Databases   []DatabaseDiff `json:"databases"`
+	GeneratedAt time.Time      `json:"generated_at"`
+}
+
+// CompareDatabases 按库名映射逐库对比，mapDatabase 返回源库在目标环境中的库名
+func (e *DiffEngine) CompareDatabases(source, target map[string]*extractor.DatabaseSchema, mapDatabase func(string) string) *MultiSchemaDiff {
+	result := &MultiSchemaDiff{GeneratedAt: time.Now()}
+
+	mapping := make(map[string]string)
+	var sourceNames []string
+	for name := range source {
+		mapping[name] = mapDatabase(name)
+		sourceNames = append(sourceNames, name)
+	}
+	sort.Strings(sourceNames)
+
+	matched := make(map[string]bool)
+	for _, name := range sourceNames {
+		targetName := mapping[name]
+		targetSchema, ok := target[targetName]
+		diffType := DiffTypeModified
+		if !ok {
+			diffType = DiffTypeAdded
+			targetSchema = extractor.NewDatabaseSchema(targetName)
+		}
+		matched[targetName] = true
+
+		schemaDiff := e.Compare(qualifyViews(source[name], mapping), targetSchema)
+		result.Databases = append(result.Databases, DatabaseDiff{
+			SourceDatabase: name,
+			TargetDatabase: targetName,
+			DiffType:       diffType,
+			Diff:           schemaDiff,
+		})
+	}
+
+	var removed []string
+	for name := range target {
+		if !matched[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		result.Databases = append(result.Databases, DatabaseDiff{
+			TargetDatabase: name,
+			DiffType:       DiffTypeRemoved,
+			Diff:           e.Compare(extractor.NewDatabaseSchema(name), target[name]),
+		})
+	}
+	return result
+}
+
+// qualifyViews 视图定义中带有库名限定，按映射改写为目标环境的库名
+func qualifyViews(schema *extractor.DatabaseSchema, mapping map[string]string) *extractor.DatabaseSchema {
+	var replacements []string
+	for from, to := range mapping {
+		if from != to {
+			replacements = append(replacements, "`"+from+"`.", "`"+to+"`.")
+		}
+	}
+	if len(replacements) == 0 || len(schema.Views) == 0 {
+		return schema
+	}
+
+	replacer := strings.NewReplacer(replacements...)
+	clone := schema.Clone()
+	for name, view := range schema.Views {
+		rewritten := *view
+		rewritten.Definition = replacer.Replace(view.Definition)
+		clone.Views[name] = &rewritten
+	}
+	return clone
+}
+
+// HasDiff 是否存在差异
+func (d *MultiSchemaDiff) HasDiff() bool {
+	for _, db := range d.Databases {
+		if db.DiffType != DiffTypeModified || db.Diff.HasDiff() {
+			return true
+		}
+	}
+	return false
+}
+
+// Combined 合并为单个差异用于展示和统计，对象名以 库名.对象名 表示
+func (d *MultiSchemaDiff) Combined() *SchemaDiff {
+	combined := &SchemaDiff{SourceEnv: d.SourceEnv, TargetEnv: d.TargetEnv, GeneratedAt: d.GeneratedAt}
+	for _, db := range d.Databases {
+		prefix := db.TargetDatabase + "."
+		for _, td := range db.Diff.TableDiffs {
+			td.TableName = prefix + td.TableName
+			if td.OldName != "" {
+				td.OldName = prefix + td.OldName
+			}
+			combined.TableDiffs = append(combined.TableDiffs, td)
+		}
+		for _, vd := range db.Diff.ViewDiffs {
+			vd.ViewName = prefix + vd.ViewName
+			combined.ViewDiffs = append(combined.ViewDiffs, vd)
+		}
+		for _, pd := range db.Diff.ProcDiffs {
+			pd.ProcName = prefix + pd.ProcName
+			combined.ProcDiffs = append(combined.ProcDiffs, pd)
+		}
+		for _, fd := range db.Diff.FuncDiffs {
+			fd.FuncName = prefix + fd.FuncName
+			combined.FuncDiffs = append(combined.FuncDiffs, fd)
+		}
+		for _, td := range db.Diff.TriggerDiffs {
+			td.TriggerName = prefix + td.TriggerName
+			combined.TriggerDiffs = append(combined.TriggerDiffs, td)
+		}
+		for _, ed := range db.Diff.EventDiffs {
+			ed.EventName = prefix + ed.EventName
+			combined.EventDiffs = append(combined.EventDiffs, ed)
+		}
+	}
+	combined.Statistics = (&DiffEngine{}).calculateStatistics(combined)
+	return combined
+}
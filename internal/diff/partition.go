@@ -0,0 +1,153 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// comparePartitions 比较表分区定义，没有差异时返回 nil
+// 分区注释和存储选项不参与对比
+func comparePartitions(source, target *extractor.PartitionInfo) *PartitionDiff {
+	switch {
+	case source == nil && target == nil:
+		return nil
+	case target == nil:
+		return &PartitionDiff{
+			DiffType:     DiffTypeAdded,
+			Severity:     SeverityWarning,
+			NewPartition: source,
+			Repartition:  true,
+			Description:  fmt.Sprintf("改为 %s 分区表，需要重建表", source.Method),
+		}
+	case source == nil:
+		return &PartitionDiff{
+			DiffType:     DiffTypeRemoved,
+			Severity:     SeverityWarning,
+			OldPartition: target,
+			Description:  "取消分区，需要重建表",
+		}
+	}
+
+	diff := &PartitionDiff{
+		DiffType:     DiffTypeModified,
+		Severity:     SeverityInfo,
+		OldPartition: target,
+		NewPartition: source,
+	}
+
+	if !samePartitionScheme(source, target) {
+		diff.Repartition = true
+		diff.Severity = SeverityWarning
+		diff.Description = fmt.Sprintf("分区方式 %s -> %s，需要重建表", partitionScheme(target), partitionScheme(source))
+		return diff
+	}
+
+	// HASH / KEY 分区只关心分区数量
+	if !source.IsRange() && !source.IsList() {
+		if len(source.Partitions) == len(target.Partitions) {
+			return nil
+		}
+		diff.Severity = SeverityWarning
+		diff.Description = fmt.Sprintf("分区数 %d -> %d，数据将重新分布", len(target.Partitions), len(source.Partitions))
+		return diff
+	}
+
+	// RANGE / LIST 分区按名称比较
+	targetDefs := make(map[string]extractor.PartitionDef)
+	for _, def := range target.Partitions {
+		targetDefs[def.Name] = def
+	}
+	sourceDefs := make(map[string]extractor.PartitionDef)
+	for _, def := range source.Partitions {
+		sourceDefs[def.Name] = def
+	}
+
+	var targetOrder, sourceOrder []string
+	for _, def := range target.Partitions {
+		if _, ok := sourceDefs[def.Name]; !ok {
+			diff.Removed = append(diff.Removed, def.Name)
+		} else {
+			targetOrder = append(targetOrder, def.Name)
+		}
+	}
+	for _, def := range source.Partitions {
+		old, ok := targetDefs[def.Name]
+		if !ok {
+			diff.Added = append(diff.Added, def.Name)
+			continue
+		}
+		sourceOrder = append(sourceOrder, def.Name)
+		if normalizePartitionText(old.Description) != normalizePartitionText(def.Description) {
+			diff.Repartition = true
+		}
+	}
+	if strings.Join(targetOrder, ",") != strings.Join(sourceOrder, ",") {
+		diff.Repartition = true
+	}
+
+	if !diff.Repartition && len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		return nil
+	}
+
+	var changes []string
+	if diff.Repartition {
+		// 已有分区的边界或顺序变化，无法增量调整
+		diff.Added, diff.Removed = nil, nil
+		diff.Severity = SeverityWarning
+		changes = append(changes, "分区边界变更，需要重建表")
+	} else {
+		if len(diff.Added) > 0 {
+			changes = append(changes, "新增分区 "+strings.Join(diff.Added, ", "))
+			if source.IsRange() && splitsRangePartition(source, targetDefs) {
+				diff.Severity = SeverityWarning
+			}
+		}
+		if len(diff.Removed) > 0 {
+			changes = append(changes, fmt.Sprintf("⚠️ 删除分区 %s - 数据将丢失", strings.Join(diff.Removed, ", ")))
+			diff.Severity = SeverityDanger
+		}
+	}
+	diff.Description = strings.Join(changes, ", ")
+	return diff
+}
+
+// samePartitionScheme 分区方式、表达式和子分区是否一致
+func samePartitionScheme(a, b *extractor.PartitionInfo) bool {
+	return strings.EqualFold(a.Method, b.Method) &&
+		normalizePartitionText(a.Expression) == normalizePartitionText(b.Expression) &&
+		strings.EqualFold(a.SubMethod, b.SubMethod) &&
+		normalizePartitionText(a.SubExpression) == normalizePartitionText(b.SubExpression) &&
+		a.SubPartitionCount() == b.SubPartitionCount()
+}
+
+// partitionScheme 返回分区方式的简短描述
+func partitionScheme(p *extractor.PartitionInfo) string {
+	scheme := fmt.Sprintf("%s(%s)", p.Method, p.Expression)
+	if p.SubMethod != "" {
+		scheme += fmt.Sprintf(" SUBPARTITION %s(%s) x%d", p.SubMethod, p.SubExpression, p.SubPartitionCount())
+	}
+	return scheme
+}
+
+// splitsRangePartition 新增的 RANGE 分区是否插入到已有分区之前（需要拆分已有分区）
+func splitsRangePartition(source *extractor.PartitionInfo, targetDefs map[string]extractor.PartitionDef) bool {
+	pending := false
+	for _, def := range source.Partitions {
+		if _, ok := targetDefs[def.Name]; !ok {
+			pending = true
+		} else if pending {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizePartitionText 规范化分区表达式和边界值
+// INFORMATION_SCHEMA 中的表达式带反引号且函数名为小写，DDL 文件中的写法可能不同
+func normalizePartitionText(s string) string {
+	s = strings.ReplaceAll(s, "`", "")
+	s = strings.Join(strings.Fields(s), "")
+	return strings.ToLower(s)
+}
@@ -0,0 +1,383 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// DataProber 对目标库执行只读统计查询
+type DataProber interface {
+	// QueryInts 执行只返回一行整数的查询，按列顺序返回结果，NULL 视为 0
+	QueryInts(ctx context.Context, query string) ([]int64, error)
+}
+
+// Preflight 针对目标库中的现有数据检查高风险变更，结果附加到列、索引和外键差异上
+// 只执行 COUNT/MAX 等只读查询；存在不满足新定义的数据时将对应差异提升为危险
+func Preflight(ctx context.Context, prober DataProber, schemaDiff *SchemaDiff) {
+	p := &preflight{ctx: ctx, prober: prober, tables: make(map[string]*preflightTable)}
+
+	// 预检在目标库（升级前）执行，表名和列名需要换回旧名称
+	for i := range schemaDiff.TableDiffs {
+		td := &schemaDiff.TableDiffs[i]
+		if td.DiffType != DiffTypeModified && td.DiffType != DiffTypeRenamed {
+			continue
+		}
+		table := &preflightTable{name: td.TableName, columns: make(map[string]string), added: make(map[string]bool)}
+		if td.OldName != "" {
+			table.name = td.OldName
+		}
+		for _, cd := range td.ColumnDiffs {
+			switch cd.DiffType {
+			case DiffTypeAdded:
+				table.added[cd.ColumnName] = true
+			case DiffTypeRenamed:
+				table.columns[cd.ColumnName] = cd.OldName
+			}
+		}
+		p.tables[td.TableName] = table
+	}
+	for _, td := range schemaDiff.TableDiffs {
+		if td.DiffType == DiffTypeAdded {
+			p.newTables = append(p.newTables, td.TableName)
+		}
+	}
+
+	for i := range schemaDiff.TableDiffs {
+		td := &schemaDiff.TableDiffs[i]
+		table := p.tables[td.TableName]
+		if table == nil {
+			continue
+		}
+
+		for j := range td.ColumnDiffs {
+			cd := &td.ColumnDiffs[j]
+			cd.DataChecks = p.columnChecks(td.TableName, table, cd)
+			if hasBlockingCheck(cd.DataChecks) {
+				cd.Severity = SeverityDanger
+			}
+		}
+		for j := range td.IndexDiffs {
+			id := &td.IndexDiffs[j]
+			id.DataChecks = p.indexChecks(td.TableName, table, id)
+			if hasBlockingCheck(id.DataChecks) {
+				id.Severity = SeverityDanger
+			}
+		}
+		for j := range td.FKeyDiffs {
+			fkd := &td.FKeyDiffs[j]
+			fkd.DataChecks = p.foreignKeyChecks(td.TableName, table, fkd)
+			if hasBlockingCheck(fkd.DataChecks) {
+				fkd.Severity = SeverityDanger
+			}
+		}
+
+		if td.Severity < SeverityDanger && tableHasBlockingCheck(td) {
+			td.Severity = SeverityDanger
+		}
+	}
+
+	schemaDiff.Statistics = (&DiffEngine{}).calculateStatistics(schemaDiff)
+}
+
+// preflight 预检上下文
+type preflight struct {
+	ctx       context.Context
+	prober    DataProber
+	tables    map[string]*preflightTable // 新表名 -> 目标库中的表
+	newTables []string                   // 目标库中尚不存在的表
+}
+
+// preflightTable 目标库中的表
+type preflightTable struct {
+	name    string
+	columns map[string]string // 新列名 -> 旧列名（仅重命名的列）
+	added   map[string]bool   // 目标库中尚不存在的列
+}
+
+// column 返回目标库中的列名，列尚不存在时返回 false
+func (t *preflightTable) column(name string) (string, bool) {
+	if t.added[name] {
+		return "", false
+	}
+	if old, ok := t.columns[name]; ok {
+		return old, true
+	}
+	return name, true
+}
+
+// run 执行一项检查，blocking 根据查询结果判断是否会导致变更失败
+func (p *preflight) run(check, query string, limit int64, blocking func(int64) bool, message func(int64) string) DataCheck {
+	result, values, ok := p.query(check, query, limit, 1)
+	if !ok {
+		return result
+	}
+	result.Count = values[0]
+	result.Blocking = blocking(values[0])
+	result.Message = message(values[0])
+	return result
+}
+
+// query 执行检查的查询，要求结果至少有 columns 列；失败时返回记录了错误的检查结果
+func (p *preflight) query(check, query string, limit int64, columns int) (DataCheck, []int64, bool) {
+	result := DataCheck{Check: check, Query: query, Limit: limit}
+	values, err := p.prober.QueryInts(p.ctx, query)
+	if err == nil && len(values) < columns {
+		err = fmt.Errorf("查询返回 %d 列，需要 %d 列", len(values), columns)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		result.Message = "预检查询失败: " + err.Error()
+		return result, nil, false
+	}
+	return result, values, true
+}
+
+// columnChecks 检查 NOT NULL 转换和类型收缩
+func (p *preflight) columnChecks(tableName string, table *preflightTable, cd *ColumnDiff) []DataCheck {
+	if cd.OldColumn == nil || cd.NewColumn == nil {
+		return nil
+	}
+	column := "`" + cd.OldColumn.Name + "`"
+	from := "`" + table.name + "`"
+	subject := fmt.Sprintf("列 `%s`.`%s`", tableName, cd.ColumnName)
+	positive := func(count int64) bool { return count > 0 }
+
+	var checks []DataCheck
+	for _, change := range cd.Changes {
+		switch change.Property {
+		case "可空":
+			if cd.NewColumn.IsNullable || !cd.OldColumn.IsNullable {
+				continue
+			}
+			checks = append(checks, p.run("null_rows",
+				fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s IS NULL", from, column), 0, positive,
+				func(count int64) string {
+					if count == 0 {
+						return subject + " 没有NULL值，可以改为 NOT NULL"
+					}
+					return fmt.Sprintf("%s 有 %d 行为NULL，改为 NOT NULL 将失败", subject, count)
+				}))
+
+		case "类型":
+			oldType, newType := cd.OldColumn.ColumnType, cd.NewColumn.ColumnType
+			if !isTypeShrink(oldType, newType) && !isTypeChange(oldType, newType) {
+				continue
+			}
+			checks = append(checks, p.typeChecks(subject, from, column, oldType, newType)...)
+		}
+	}
+	return checks
+}
+
+// typeChecks 检查现有数据是否超出新类型的长度或取值范围
+func (p *preflight) typeChecks(subject, from, column, oldType, newType string) []DataCheck {
+	newBase := extractBaseType(newType)
+	positive := func(count int64) bool { return count > 0 }
+
+	switch newBase {
+	case "char", "varchar", "binary", "varbinary":
+		limit := int64(extractTypeLength(newType))
+		oldLen := int64(extractTypeLength(oldType))
+		if limit <= 0 || (oldLen > 0 && oldLen <= limit && extractBaseType(oldType) == newBase) {
+			return nil
+		}
+		lengthFunc := "CHAR_LENGTH"
+		if strings.HasSuffix(newBase, "binary") {
+			lengthFunc = "LENGTH"
+		}
+		// 一次扫描同时统计超长的行数和当前最大长度
+		query := fmt.Sprintf("SELECT SUM(%s(%s) > %d), MAX(%s(%s)) FROM %s", lengthFunc, column, limit, lengthFunc, column, from)
+		result, values, ok := p.query("over_length", query, limit, 2)
+		if ok {
+			result.Count, result.Max = values[0], values[1]
+			result.Blocking = result.Count > 0
+			if result.Count == 0 {
+				result.Message = fmt.Sprintf("%s 没有超过 %d 的数据，当前最大长度为 %d", subject, limit, result.Max)
+			} else {
+				result.Message = fmt.Sprintf("%s 有 %d 行长度超过 %d（当前最大长度为 %d），修改类型将失败或截断数据", subject, result.Count, limit, result.Max)
+			}
+		}
+		return []DataCheck{result}
+
+	case "tinyint", "smallint", "mediumint", "int", "integer", "bigint":
+		min, max := integerRange(newType)
+		if oldMin, oldMax := integerRange(oldType); oldMin != nil && oldMin.Cmp(min) >= 0 && oldMax.Cmp(max) <= 0 {
+			return nil
+		}
+		return []DataCheck{p.run("out_of_range",
+			fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s < %s OR %s > %s", from, column, min, column, max), 0, positive,
+			func(count int64) string {
+				if count == 0 {
+					return fmt.Sprintf("%s 的数据都在 %s 范围内", subject, newType)
+				}
+				return fmt.Sprintf("%s 有 %d 行超出 %s 的取值范围 [%s, %s]", subject, count, newType, min, max)
+			})}
+
+	case "decimal", "numeric":
+		precision, scale := decimalSize(newType)
+		if precision <= 0 {
+			return nil
+		}
+		bound := "1" + strings.Repeat("0", precision-scale)
+		return []DataCheck{p.run("out_of_range",
+			fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE ABS(%s) >= %s", from, column, bound), 0, positive,
+			func(count int64) string {
+				if count == 0 {
+					return fmt.Sprintf("%s 的数据都在 %s 范围内", subject, newType)
+				}
+				return fmt.Sprintf("%s 有 %d 行整数部分超过 %d 位，超出 %s 的取值范围", subject, count, precision-scale, newType)
+			})}
+	}
+
+	return nil
+}
+
+// indexChecks 检查新增唯一索引或主键的重复值
+func (p *preflight) indexChecks(tableName string, table *preflightTable, id *IndexDiff) []DataCheck {
+	if id.DiffType != DiffTypeAdded && id.DiffType != DiffTypeModified {
+		return nil
+	}
+	if id.NewIndex == nil || (!id.NewIndex.IsUnique && !id.NewIndex.IsPrimary) {
+		return nil
+	}
+
+	var keys, conditions []string
+	for _, col := range id.NewIndex.Columns {
+		name, ok := table.column(col.Name)
+		if !ok {
+			// 索引包含新增列，现有数据中该列全部为默认值，无法预先判断
+			return nil
+		}
+		key := "`" + name + "`"
+		conditions = append(conditions, key+" IS NOT NULL")
+		if col.SubPart != nil {
+			key = fmt.Sprintf("LEFT(%s, %d)", key, *col.SubPart)
+		}
+		keys = append(keys, key)
+	}
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM (SELECT 1 FROM `%s` WHERE %s GROUP BY %s HAVING COUNT(*) > 1) AS dup",
+		table.name, strings.Join(conditions, " AND "), strings.Join(keys, ", "))
+	subject := fmt.Sprintf("索引 `%s`.`%s`", tableName, id.IndexName)
+	return []DataCheck{p.run("duplicate_keys", query, 0, func(count int64) bool { return count > 0 },
+		func(count int64) string {
+			if count == 0 {
+				return subject + " 的列没有重复值"
+			}
+			return fmt.Sprintf("%s 的列存在 %d 组重复值，创建唯一索引将失败", subject, count)
+		})}
+}
+
+// foreignKeyChecks 检查新增外键的孤儿行
+func (p *preflight) foreignKeyChecks(tableName string, table *preflightTable, fkd *ForeignKeyDiff) []DataCheck {
+	if fkd.DiffType != DiffTypeAdded && fkd.DiffType != DiffTypeModified {
+		return nil
+	}
+	fk := fkd.NewFKey
+	if fk == nil || len(fk.Columns) != len(fk.RefColumns) {
+		return nil
+	}
+	for _, name := range p.newTables {
+		if name == fk.RefTable {
+			// 被引用表将在本次升级中创建，目标库中尚无数据
+			return nil
+		}
+	}
+
+	refTable := &preflightTable{name: fk.RefTable}
+	if t := p.tables[fk.RefTable]; t != nil {
+		refTable = t
+	}
+
+	var joins, conditions []string
+	var firstRef string
+	for i := range fk.Columns {
+		child, ok := table.column(fk.Columns[i])
+		if !ok {
+			return nil
+		}
+		parent, ok := refTable.column(fk.RefColumns[i])
+		if !ok {
+			return nil
+		}
+		joins = append(joins, fmt.Sprintf("c.`%s` = p.`%s`", child, parent))
+		conditions = append(conditions, fmt.Sprintf("c.`%s` IS NOT NULL", child))
+		if firstRef == "" {
+			firstRef = parent
+		}
+	}
+	conditions = append(conditions, fmt.Sprintf("p.`%s` IS NULL", firstRef))
+
+	query := fmt.Sprintf("SELECT COUNT(*) FROM `%s` c LEFT JOIN `%s` p ON %s WHERE %s",
+		table.name, refTable.name, strings.Join(joins, " AND "), strings.Join(conditions, " AND "))
+	subject := fmt.Sprintf("外键 `%s`.`%s`", tableName, fkd.FKeyName)
+	return []DataCheck{p.run("orphan_rows", query, 0, func(count int64) bool { return count > 0 },
+		func(count int64) string {
+			if count == 0 {
+				return subject + " 没有孤儿行"
+			}
+			return fmt.Sprintf("%s 有 %d 行在 `%s` 中找不到对应记录，添加外键将失败", subject, count, fk.RefTable)
+		})}
+}
+
+// integerRange 返回整数类型的取值范围，非整数类型返回 nil
+func integerRange(colType string) (*big.Int, *big.Int) {
+	bits := map[string]uint{"tinyint": 8, "smallint": 16, "mediumint": 24, "int": 32, "integer": 32, "bigint": 64}
+	size, ok := bits[extractBaseType(colType)]
+	if !ok {
+		return nil, nil
+	}
+
+	one := big.NewInt(1)
+	if strings.Contains(strings.ToLower(colType), "unsigned") {
+		max := new(big.Int).Sub(new(big.Int).Lsh(one, size), one)
+		return big.NewInt(0), max
+	}
+	max := new(big.Int).Sub(new(big.Int).Lsh(one, size-1), one)
+	min := new(big.Int).Neg(new(big.Int).Lsh(one, size-1))
+	return min, max
+}
+
+// decimalSize 解析 decimal(p,s) 的精度和小数位
+func decimalSize(colType string) (int, int) {
+	start := strings.Index(colType, "(")
+	end := strings.Index(colType, ")")
+	if start < 0 || end < start {
+		return 0, 0
+	}
+	var precision, scale int
+	fmt.Sscanf(strings.ReplaceAll(colType[start+1:end], " ", ""), "%d,%d", &precision, &scale)
+	return precision, scale
+}
+
+// hasBlockingCheck 是否存在会导致变更失败的预检结果
+func hasBlockingCheck(checks []DataCheck) bool {
+	for _, check := range checks {
+		if check.Blocking {
+			return true
+		}
+	}
+	return false
+}
+
+// tableHasBlockingCheck 表中任一列、索引或外键是否存在阻断性预检结果
+func tableHasBlockingCheck(td *TableDiff) bool {
+	for _, cd := range td.ColumnDiffs {
+		if hasBlockingCheck(cd.DataChecks) {
+			return true
+		}
+	}
+	for _, id := range td.IndexDiffs {
+		if hasBlockingCheck(id.DataChecks) {
+			return true
+		}
+	}
+	for _, fkd := range td.FKeyDiffs {
+		if hasBlockingCheck(fkd.DataChecks) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,445 @@
+package diff
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// defaultRenameScore 启发式识别重命名的默认最低相似度
+const defaultRenameScore = 0.7
+
+// RenameMatch 一次重命名匹配
+type RenameMatch struct {
+	OldName   string  `json:"old_name"`
+	Confirmed bool    `json:"confirmed"` // 是否来自项目配置中已确认的映射
+	Score     float64 `json:"score"`     // 启发式相似度 (0-1)
+}
+
+// RenameSet 已识别的表和列重命名，键均为源环境中的新名称
+type RenameSet struct {
+	Tables  map[string]RenameMatch            // 新表名 -> 匹配
+	Columns map[string]map[string]RenameMatch // 新表名 -> 新列名 -> 匹配
+}
+
+// newRenameSet 创建空的重命名集合
+func newRenameSet() *RenameSet {
+	return &RenameSet{
+		Tables:  make(map[string]RenameMatch),
+		Columns: make(map[string]map[string]RenameMatch),
+	}
+}
+
+// oldTableName 返回源表在目标环境中的名称
+func (s *RenameSet) oldTableName(name string) string {
+	if s != nil {
+		if match, ok := s.Tables[name]; ok {
+			return match.OldName
+		}
+	}
+	return name
+}
+
+// newTableName 返回目标表在源环境中的名称
+func (s *RenameSet) newTableName(oldName string) string {
+	if s != nil {
+		for name, match := range s.Tables {
+			if match.OldName == oldName {
+				return name
+			}
+		}
+	}
+	return oldName
+}
+
+// columnRenames 返回表（新表名）中的列重命名
+func (s *RenameSet) columnRenames(table string) map[string]RenameMatch {
+	if s == nil {
+		return nil
+	}
+	return s.Columns[table]
+}
+
+// newColumnName 返回目标列在源环境中的名称
+func (s *RenameSet) newColumnName(table, oldName string) string {
+	for name, match := range s.columnRenames(table) {
+		if match.OldName == oldName {
+			return name
+		}
+	}
+	return oldName
+}
+
+// translateIndex 将目标索引中的列名换算为新名称，用于判断索引是否真正变化
+func (s *RenameSet) translateIndex(table string, idx *extractor.IndexSchema) *extractor.IndexSchema {
+	if len(s.columnRenames(table)) == 0 {
+		return idx
+	}
+	translated := *idx
+	translated.Columns = make([]extractor.IndexColumn, len(idx.Columns))
+	for i, col := range idx.Columns {
+		col.Name = s.newColumnName(table, col.Name)
+		translated.Columns[i] = col
+	}
+	return &translated
+}
+
+// translateForeignKey 将目标外键中的表名、列名换算为新名称
+func (s *RenameSet) translateForeignKey(table string, fk *extractor.ForeignKey) *extractor.ForeignKey {
+	if s == nil {
+		return fk
+	}
+	translated := *fk
+	translated.RefTable = s.newTableName(fk.RefTable)
+	translated.Columns = make([]string, len(fk.Columns))
+	for i, col := range fk.Columns {
+		translated.Columns[i] = s.newColumnName(table, col)
+	}
+	translated.RefColumns = make([]string, len(fk.RefColumns))
+	for i, col := range fk.RefColumns {
+		translated.RefColumns[i] = s.newColumnName(translated.RefTable, col)
+	}
+	return &translated
+}
+
+// checkTokenPattern 匹配CHECK表达式中的字符串常量和标识符
+var checkTokenPattern = regexp.MustCompile("'(?:[^'\\\\]|\\\\.)*'|`[^`]+`|[A-Za-z_][A-Za-z0-9_$]*")
+
+// translateCheck 将目标CHECK约束表达式中的列名换算为新名称
+func (s *RenameSet) translateCheck(table string, check *extractor.CheckConstraint) *extractor.CheckConstraint {
+	if len(s.columnRenames(table)) == 0 {
+		return check
+	}
+	translated := *check
+	translated.Expression = checkTokenPattern.ReplaceAllStringFunc(check.Expression, func(token string) string {
+		if strings.HasPrefix(token, "'") {
+			return token
+		}
+		name := strings.Trim(token, "`")
+		if newName := s.newColumnName(table, name); newName != name {
+			return "`" + newName + "`"
+		}
+		return token
+	})
+	return &translated
+}
+
+// detectRenames 识别表和列的重命名
+// 先应用项目配置中确认的映射，再对剩余的新增/删除对象做启发式匹配
+func (e *DiffEngine) detectRenames(sourceTables, targetTables map[string]*extractor.TableSchema) *RenameSet {
+	set := newRenameSet()
+	heuristic := !e.renameRules.DisableHeuristic
+	minScore := e.renameRules.MinScore
+	if minScore <= 0 {
+		minScore = defaultRenameScore
+	}
+
+	var added, removed []string
+	for name := range sourceTables {
+		if _, exists := targetTables[name]; !exists && !e.shouldIgnoreTable(name) {
+			added = append(added, name)
+		}
+	}
+	for name := range targetTables {
+		if _, exists := sourceTables[name]; !exists && !e.shouldIgnoreTable(name) {
+			removed = append(removed, name)
+		}
+	}
+
+	matches := applyConfirmedRenames(e.renameRules.Tables, &added, &removed)
+	if heuristic {
+		for newName, match := range matchRenames(added, removed, minScore, func(newName, oldName string) float64 {
+			return tableSimilarity(sourceTables[newName], targetTables[oldName])
+		}) {
+			matches[newName] = match
+		}
+	}
+	set.Tables = matches
+
+	for name, srcTable := range sourceTables {
+		tgtTable, exists := targetTables[set.oldTableName(name)]
+		if !exists {
+			continue
+		}
+		if columns := e.detectColumnRenames(srcTable, tgtTable, heuristic, minScore); len(columns) > 0 {
+			set.Columns[name] = columns
+		}
+	}
+
+	return set
+}
+
+// detectColumnRenames 识别同一张表中的列重命名
+func (e *DiffEngine) detectColumnRenames(source, target *extractor.TableSchema, heuristic bool, minScore float64) map[string]RenameMatch {
+	var added, removed []string
+	for _, col := range source.Columns {
+		if target.GetColumn(col.Name) == nil {
+			added = append(added, col.Name)
+		}
+	}
+	for _, col := range target.Columns {
+		if source.GetColumn(col.Name) == nil {
+			removed = append(removed, col.Name)
+		}
+	}
+	if len(added) == 0 || len(removed) == 0 {
+		return nil
+	}
+
+	var rules []config.RenameMapping
+	for _, rule := range e.renameRules.Columns {
+		if rule.Table == source.Name || rule.Table == target.Name {
+			rules = append(rules, rule)
+		}
+	}
+
+	matches := applyConfirmedRenames(rules, &added, &removed)
+	if heuristic {
+		for newName, match := range matchRenames(added, removed, minScore, func(newName, oldName string) float64 {
+			return columnSimilarity(source, target, source.GetColumn(newName), target.GetColumn(oldName))
+		}) {
+			matches[newName] = match
+		}
+	}
+	return matches
+}
+
+// applyConfirmedRenames 应用已确认的映射，并从候选列表中移除已匹配的名称
+func applyConfirmedRenames(rules []config.RenameMapping, added, removed *[]string) map[string]RenameMatch {
+	matches := make(map[string]RenameMatch)
+	for _, rule := range rules {
+		newIdx := indexOf(*added, rule.To)
+		oldIdx := indexOf(*removed, rule.From)
+		if newIdx < 0 || oldIdx < 0 {
+			continue
+		}
+		matches[rule.To] = RenameMatch{OldName: rule.From, Confirmed: true, Score: 1}
+		*added = append((*added)[:newIdx], (*added)[newIdx+1:]...)
+		*removed = append((*removed)[:oldIdx], (*removed)[oldIdx+1:]...)
+	}
+	return matches
+}
+
+// matchRenames 按相似度从高到低一一配对新增与删除的对象
+func matchRenames(added, removed []string, minScore float64, score func(newName, oldName string) float64) map[string]RenameMatch {
+	type candidate struct {
+		newName, oldName string
+		score            float64
+	}
+
+	var candidates []candidate
+	for _, newName := range added {
+		for _, oldName := range removed {
+			if s := score(newName, oldName); s >= minScore {
+				candidates = append(candidates, candidate{newName, oldName, s})
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		if candidates[i].newName != candidates[j].newName {
+			return candidates[i].newName < candidates[j].newName
+		}
+		return candidates[i].oldName < candidates[j].oldName
+	})
+
+	matches := make(map[string]RenameMatch)
+	usedOld := make(map[string]bool)
+	for _, c := range candidates {
+		if _, used := matches[c.newName]; used || usedOld[c.oldName] {
+			continue
+		}
+		matches[c.newName] = RenameMatch{OldName: c.oldName, Score: c.score}
+		usedOld[c.oldName] = true
+	}
+	return matches
+}
+
+// columnSimilarity 计算两列为同一列改名的可能性
+// 类型必须一致；其余依据完整类型、属性、注释、位置和名称相似度加权
+func columnSimilarity(sourceTable, targetTable *extractor.TableSchema, source, target *extractor.ColumnSchema) float64 {
+	if source == nil || target == nil || source.DataType != target.DataType {
+		return 0
+	}
+
+	score := 0.15
+	if source.ColumnType == target.ColumnType {
+		score = 0.35
+	}
+
+	if source.IsNullable == target.IsNullable &&
+		source.IsAutoIncr == target.IsAutoIncr &&
+		defaultString(source.DefaultValue) == defaultString(target.DefaultValue) {
+		score += 0.1
+	}
+
+	if source.Comment != "" && source.Comment == target.Comment {
+		score += 0.2
+	}
+
+	if source.Position == target.Position || previousColumn(sourceTable, source) == previousColumn(targetTable, target) {
+		score += 0.15
+	}
+
+	score += 0.2 * nameSimilarity(source.Name, target.Name)
+	return score
+}
+
+// tableSimilarity 计算两张表为同一张表改名的可能性，主要依据列结构的重合程度
+// 同名同类型、或同位置同类型的列视为相同列
+func tableSimilarity(source, target *extractor.TableSchema) float64 {
+	if source == nil || target == nil || len(source.Columns) == 0 || len(target.Columns) == 0 {
+		return 0
+	}
+
+	common := 0
+	for i, col := range source.Columns {
+		if tgtCol := target.GetColumn(col.Name); tgtCol != nil && tgtCol.ColumnType == col.ColumnType {
+			common++
+		} else if i < len(target.Columns) && target.Columns[i].ColumnType == col.ColumnType {
+			common++
+		}
+	}
+
+	overlap := float64(common) / float64(len(source.Columns)+len(target.Columns)-common)
+	if overlap < 0.5 {
+		return 0
+	}
+
+	score := 0.7 * overlap
+	if source.Comment != "" && source.Comment == target.Comment {
+		score += 0.1
+	}
+	score += 0.2 * nameSimilarity(source.Name, target.Name)
+	return score
+}
+
+// previousColumn 返回列前面的列名
+func previousColumn(table *extractor.TableSchema, col *extractor.ColumnSchema) string {
+	for i, c := range table.Columns {
+		if c == col {
+			if i == 0 {
+				return ""
+			}
+			return table.Columns[i-1].Name
+		}
+	}
+	return "\x00"
+}
+
+// nameSimilarity 基于编辑距离的名称相似度 (0-1)
+func nameSimilarity(a, b string) float64 {
+	ra := []rune(strings.ToLower(a))
+	rb := []rune(strings.ToLower(b))
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	if maxLen == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(maxLen)
+}
+
+// renamedColumnDiff 构建列重命名差异，同时包含定义上的变化
+func renamedColumnDiff(source, target *extractor.ColumnSchema, match RenameMatch, opts ColumnCompareOptions) ColumnDiff {
+	cd := ColumnDiff{
+		ColumnName: source.Name,
+		OldName:    target.Name,
+		DiffType:   DiffTypeRenamed,
+		Severity:   SeverityInfo,
+		OldColumn:  target,
+		NewColumn:  source,
+	}
+
+	var notes []string
+	if !match.Confirmed {
+		cd.Severity = SeverityWarning
+		notes = append(notes, fmt.Sprintf("启发式识别为重命名（相似度 %.0f%%），请确认后写入 rename_rules", match.Score*100))
+	}
+
+	if changed := compareColumnWithOptions(source, target, opts); changed != nil {
+		cd.Changes = changed.Changes
+		if changed.Severity > cd.Severity {
+			cd.Severity = changed.Severity
+		}
+		if changed.RiskNote != "" {
+			notes = append(notes, changed.RiskNote)
+		}
+	}
+
+	cd.RiskNote = strings.Join(notes, "; ")
+	return cd
+}
+
+// defaultString 返回默认值的字符串形式
+func defaultString(value *string) string {
+	if value == nil {
+		return "\x00"
+	}
+	return *value
+}
+
+func indexOf(items []string, item string) int {
+	for i, v := range items {
+		if v == item {
+			return i
+		}
+	}
+	return -1
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+// renamedTableDiff 将表比较结果标记为重命名
+func renamedTableDiff(diff *TableDiff, match RenameMatch) *TableDiff {
+	diff.DiffType = DiffTypeRenamed
+	diff.OldName = match.OldName
+
+	description := fmt.Sprintf("重命名自 %s", match.OldName)
+	if !match.Confirmed {
+		description += fmt.Sprintf(" (启发式识别，相似度 %.0f%%，请确认后写入 rename_rules)", match.Score*100)
+		if diff.Severity < SeverityWarning {
+			diff.Severity = SeverityWarning
+		}
+	}
+	if diff.Description != "" {
+		description += ", " + diff.Description
+	}
+	diff.Description = description
+
+	return diff
+}
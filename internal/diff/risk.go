@@ -29,11 +29,12 @@ func (r RiskLevel) String() string {
 
 // RiskAssessment 风险评估结果
 type RiskAssessment struct {
-	Level       RiskLevel `json:"level"`
-	Score       int       `json:"score"`        // 0-100
-	Description string    `json:"description"`
-	Warnings    []string  `json:"warnings"`
-	Suggestions []string  `json:"suggestions"`
+	Level       RiskLevel   `json:"level"`
+	Score       int         `json:"score"` // 0-100
+	Description string      `json:"description"`
+	Warnings    []string    `json:"warnings"`
+	Suggestions []string    `json:"suggestions"`
+	DataChecks  []DataCheck `json:"data_checks,omitempty"` // 针对目标库数据的预检结果
 }
 
 // RiskAssessor 风险评估器
@@ -73,6 +74,15 @@ func (r *RiskAssessor) Assess(diff *SchemaDiff) *RiskAssessment {
 		r.assessTriggerDiff(&td, assessment)
 	}
 
+	// 评估事件变更风险
+	for _, ed := range diff.EventDiffs {
+		r.assessEventDiff(&ed, assessment)
+	}
+
+	if hasBlockingCheck(assessment.DataChecks) {
+		assessment.Suggestions = append(assessment.Suggestions, "请先修正目标库中不符合新定义的数据，再执行升级脚本")
+	}
+
 	// 计算最终风险级别
 	if assessment.Score >= 70 {
 		assessment.Level = RiskHigh
@@ -99,6 +109,14 @@ func (r *RiskAssessor) assessTableDiff(td *TableDiff, assessment *RiskAssessment
 		assessment.Suggestions = append(assessment.Suggestions,
 			fmt.Sprintf("建议在删除表 `%s` 前先备份数据", td.TableName))
 
+	case DiffTypeRenamed:
+		// 重命名不丢数据，但引用旧表名的代码会失效
+		assessment.Score += 10
+		assessment.Warnings = append(assessment.Warnings,
+			fmt.Sprintf("⚠️ 表 `%s` 重命名为 `%s`，引用旧表名的应用代码、视图、存储过程和触发器需要同步修改",
+				td.OldName, td.TableName))
+		fallthrough
+
 	case DiffTypeModified:
 		// 评估列变更风险
 		for _, cd := range td.ColumnDiffs {
@@ -114,11 +132,56 @@ func (r *RiskAssessor) assessTableDiff(td *TableDiff, assessment *RiskAssessment
 		for _, fkd := range td.FKeyDiffs {
 			r.assessForeignKeyDiff(td.TableName, &fkd, assessment)
 		}
+
+		// 评估CHECK约束变更风险
+		for _, ckd := range td.CheckDiffs {
+			r.assessCheckDiff(td.TableName, &ckd, assessment)
+		}
+
+		// 评估分区变更风险
+		if td.PartitionDiff != nil {
+			r.assessPartitionDiff(td.TableName, td.PartitionDiff, assessment)
+		}
+	}
+}
+
+// assessCheckDiff 评估CHECK约束差异风险
+func (r *RiskAssessor) assessCheckDiff(tableName string, ckd *CheckDiff, assessment *RiskAssessment) {
+	if ckd.NewCheck == nil || !ckd.NewCheck.Enforced {
+		return
+	}
+	assessment.Score += 5
+	assessment.Warnings = append(assessment.Warnings,
+		fmt.Sprintf("⚠️ CHECK约束 `%s`.`%s` 会校验现有数据，存在不满足 %s 的行时变更将失败",
+			tableName, ckd.CheckName, ckd.NewCheck.Expression))
+	assessment.Suggestions = append(assessment.Suggestions,
+		fmt.Sprintf("添加CHECK约束前，请先查询 `%s` 中是否存在 NOT (%s) 的数据", tableName, ckd.NewCheck.Expression))
+}
+
+// assessPartitionDiff 评估分区差异风险
+func (r *RiskAssessor) assessPartitionDiff(tableName string, pd *PartitionDiff, assessment *RiskAssessment) {
+	if len(pd.Removed) > 0 {
+		// 删除分区会直接丢弃分区内的数据
+		assessment.Score += 20
+		assessment.Warnings = append(assessment.Warnings,
+			fmt.Sprintf("⚠️ 删除表 `%s` 的分区 %s 将导致其中的数据丢失", tableName, strings.Join(pd.Removed, ", ")))
+		assessment.Suggestions = append(assessment.Suggestions,
+			fmt.Sprintf("建议在删除表 `%s` 的分区前先归档数据", tableName))
+	}
+
+	if pd.Repartition || pd.DiffType == DiffTypeRemoved {
+		assessment.Score += 10
+		assessment.Warnings = append(assessment.Warnings,
+			fmt.Sprintf("⚠️ 表 `%s` 重新分区需要复制全部数据，执行期间阻塞写入", tableName))
+		assessment.Suggestions = append(assessment.Suggestions,
+			fmt.Sprintf("重新分区表 `%s` 可能耗时较长，建议在低峰期执行", tableName))
 	}
 }
 
 // assessColumnDiff 评估列差异风险
 func (r *RiskAssessor) assessColumnDiff(tableName string, cd *ColumnDiff, assessment *RiskAssessment) {
+	r.assessDataChecks(cd.DataChecks, assessment)
+
 	switch cd.DiffType {
 	case DiffTypeRemoved:
 		// 删除列是高风险操作
@@ -126,6 +189,13 @@ func (r *RiskAssessor) assessColumnDiff(tableName string, cd *ColumnDiff, assess
 		assessment.Warnings = append(assessment.Warnings,
 			fmt.Sprintf("⚠️ 删除列 `%s`.`%s` 将导致该列数据丢失", tableName, cd.ColumnName))
 
+	case DiffTypeRenamed:
+		assessment.Score += 5
+		assessment.Warnings = append(assessment.Warnings,
+			fmt.Sprintf("⚠️ 列 `%s`.`%s` 重命名为 `%s`，引用旧列名的应用代码、视图和存储过程需要同步修改",
+				tableName, cd.OldName, cd.ColumnName))
+		fallthrough
+
 	case DiffTypeModified:
 		// 检查危险的修改
 		for _, change := range cd.Changes {
@@ -160,6 +230,8 @@ func (r *RiskAssessor) assessColumnDiff(tableName string, cd *ColumnDiff, assess
 
 // assessIndexDiff 评估索引差异风险
 func (r *RiskAssessor) assessIndexDiff(tableName string, id *IndexDiff, assessment *RiskAssessment) {
+	r.assessDataChecks(id.DataChecks, assessment)
+
 	switch id.DiffType {
 	case DiffTypeRemoved:
 		if id.OldIndex != nil && id.OldIndex.IsPrimary {
@@ -182,6 +254,8 @@ func (r *RiskAssessor) assessIndexDiff(tableName string, id *IndexDiff, assessme
 
 // assessForeignKeyDiff 评估外键差异风险
 func (r *RiskAssessor) assessForeignKeyDiff(tableName string, fkd *ForeignKeyDiff, assessment *RiskAssessment) {
+	r.assessDataChecks(fkd.DataChecks, assessment)
+
 	switch fkd.DiffType {
 	case DiffTypeAdded:
 		if fkd.NewFKey != nil {
@@ -196,6 +270,20 @@ func (r *RiskAssessor) assessForeignKeyDiff(tableName string, fkd *ForeignKeyDif
 	}
 }
 
+// assessDataChecks 根据预检结果给出带具体行数的警告
+func (r *RiskAssessor) assessDataChecks(checks []DataCheck, assessment *RiskAssessment) {
+	for _, check := range checks {
+		assessment.DataChecks = append(assessment.DataChecks, check)
+		switch {
+		case check.Blocking:
+			assessment.Score += 30
+			assessment.Warnings = append(assessment.Warnings, "🚫 "+check.Message)
+		case check.Error != "":
+			assessment.Suggestions = append(assessment.Suggestions, check.Message)
+		}
+	}
+}
+
 // assessViewDiff 评估视图差异风险
 func (r *RiskAssessor) assessViewDiff(vd *ViewDiff, assessment *RiskAssessment) {
 	switch vd.DiffType {
@@ -236,6 +324,28 @@ func (r *RiskAssessor) assessTriggerDiff(td *TriggerDiff, assessment *RiskAssess
 	}
 }
 
+// assessEventDiff 评估事件差异风险
+func (r *RiskAssessor) assessEventDiff(ed *EventDiff, assessment *RiskAssessment) {
+	switch ed.DiffType {
+	case DiffTypeAdded:
+		if ed.NewEvent != nil && ed.NewEvent.Status == "ENABLED" {
+			assessment.Score += 5
+			assessment.Warnings = append(assessment.Warnings,
+				fmt.Sprintf("⚠️ 新增事件 `%s` 创建后将按调度自动执行", ed.EventName))
+		}
+	case DiffTypeRemoved:
+		assessment.Score += 10
+		assessment.Warnings = append(assessment.Warnings,
+			fmt.Sprintf("⚠️ 删除事件 `%s`，相关定时任务将停止", ed.EventName))
+	case DiffTypeModified:
+		if ed.Severity >= SeverityWarning {
+			assessment.Score += 8
+			assessment.Warnings = append(assessment.Warnings,
+				fmt.Sprintf("⚠️ 修改事件 `%s` 的调度或事件体", ed.EventName))
+		}
+	}
+}
+
 // generateDescription 生成风险描述
 func (r *RiskAssessor) generateDescription(assessment *RiskAssessment, diff *SchemaDiff) string {
 	var parts []string
@@ -303,6 +413,8 @@ func GetDiffTypeIcon(diffType DiffType) string {
 		return "➖"
 	case DiffTypeModified:
 		return "✏️"
+	case DiffTypeRenamed:
+		return "🔀"
 	default:
 		return "❓"
 	}
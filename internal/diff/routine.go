@@ -0,0 +1,128 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// routineProperties 存储过程和函数共有的可比较属性
+type routineProperties struct {
+	definition string
+	params     []extractor.ProcedureParam
+	security   string
+	sqlMode    string
+	comment    string
+	definer    string
+}
+
+// AlterableRoutineProperties 可以用 ALTER PROCEDURE / ALTER FUNCTION 修改的属性，其余变更需要重建
+var AlterableRoutineProperties = map[string]bool{
+	"安全性": true,
+	"注释":  true,
+}
+
+// compareProcedureProperties 比较存储过程属性
+func (e *DiffEngine) compareProcedureProperties(source, target *extractor.ProcedureSchema, normalize extractor.NormalizeOptions) []PropertyDiff {
+	return e.compareRoutineProperties(
+		routineProperties{source.Definition, source.Params, source.Security, source.SQLMode, source.Comment, source.Definer},
+		routineProperties{target.Definition, target.Params, target.Security, target.SQLMode, target.Comment, target.Definer},
+		normalize,
+	)
+}
+
+// compareFunctionProperties 比较函数属性，除公共属性外还比较返回类型和确定性
+func (e *DiffEngine) compareFunctionProperties(source, target *extractor.FunctionSchema, normalize extractor.NormalizeOptions) []PropertyDiff {
+	props := e.compareRoutineProperties(
+		routineProperties{source.Definition, source.Params, source.Security, source.SQLMode, source.Comment, source.Definer},
+		routineProperties{target.Definition, target.Params, target.Security, target.SQLMode, target.Comment, target.Definer},
+		normalize,
+	)
+
+	if !strings.EqualFold(source.Returns, target.Returns) {
+		props = append(props, PropertyDiff{Property: "返回类型", OldValue: target.Returns, NewValue: source.Returns})
+	}
+	if source.IsDetermin != target.IsDetermin {
+		props = append(props, PropertyDiff{Property: "确定性", OldValue: determinismText(target.IsDetermin), NewValue: determinismText(source.IsDetermin)})
+	}
+	return props
+}
+
+// compareRoutineProperties 比较参数、主体、SQL SECURITY、sql_mode、注释和 DEFINER
+// DDL文件中没有 sql_mode，任一侧为空时不比较；DEFINER 按策略比较
+func (e *DiffEngine) compareRoutineProperties(source, target routineProperties, normalize extractor.NormalizeOptions) []PropertyDiff {
+	var props []PropertyDiff
+
+	if oldParams, newParams := formatParams(target.params), formatParams(source.params); !strings.EqualFold(oldParams, newParams) {
+		props = append(props, PropertyDiff{Property: "参数", OldValue: oldParams, NewValue: newParams})
+	}
+
+	oldBody, newBody := extractor.RoutineBody(target.definition), extractor.RoutineBody(source.definition)
+	if !sameDefinition(newBody, oldBody, normalize) {
+		props = append(props, PropertyDiff{Property: "主体", OldValue: oldBody, NewValue: newBody})
+	}
+
+	if oldSecurity, newSecurity := routineSecurity(target.security), routineSecurity(source.security); oldSecurity != newSecurity {
+		props = append(props, PropertyDiff{Property: "安全性", OldValue: oldSecurity, NewValue: newSecurity})
+	}
+	if source.sqlMode != "" && target.sqlMode != "" && !strings.EqualFold(source.sqlMode, target.sqlMode) {
+		props = append(props, PropertyDiff{Property: "sql_mode", OldValue: target.sqlMode, NewValue: source.sqlMode})
+	}
+	if !e.ignoreRules.IgnoreComments && source.comment != target.comment {
+		props = append(props, PropertyDiff{Property: "注释", OldValue: target.comment, NewValue: source.comment})
+	}
+	if oldDefiner, newDefiner, changed := e.definerChanged(source.definer, target.definer); changed {
+		props = append(props, PropertyDiff{Property: "定义者", OldValue: oldDefiner, NewValue: newDefiner})
+	}
+
+	return props
+}
+
+// routinePropertySeverity 只有注释变化时不影响逻辑
+func routinePropertySeverity(props []PropertyDiff) DiffSeverity {
+	for _, prop := range props {
+		if prop.Property != "注释" {
+			return SeverityWarning
+		}
+	}
+	return SeverityInfo
+}
+
+// propertyNames 返回变更的属性名，用于描述
+func propertyNames(props []PropertyDiff) string {
+	var names []string
+	for _, prop := range props {
+		names = append(names, prop.Property)
+	}
+	return strings.Join(names, "、")
+}
+
+// formatParams 格式化参数列表，如 IN a int, OUT b varchar(10)
+func formatParams(params []extractor.ProcedureParam) string {
+	var parts []string
+	for _, param := range params {
+		part := fmt.Sprintf("%s %s", param.Name, param.DataType)
+		if param.Mode != "" {
+			part = param.Mode + " " + part
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// routineSecurity 规范化 SQL SECURITY，未指定时为 DEFINER
+func routineSecurity(security string) string {
+	if security == "" {
+		return "DEFINER"
+	}
+	return strings.ToUpper(security)
+}
+
+// determinismText 返回确定性对应的特性
+func determinismText(deterministic bool) string {
+	if deterministic {
+		return "DETERMINISTIC"
+	}
+	return "NOT DETERMINISTIC"
+}
@@ -0,0 +1,292 @@
+package diff
+
+import (
+	"fmt"
+
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// ThreeWayDiff 三方对比结果
+type ThreeWayDiff struct {
+	Diff          *SchemaDiff      `json:"diff"`           // 只包含开发侧的变更，可直接生成升级脚本
+	TargetChanges []ThreeWayChange `json:"target_changes"` // 只在目标环境发生的变更（如生产热修复），予以保留
+	Conflicts     []ThreeWayChange `json:"conflicts"`      // 双方都改动过且结果不同的对象，需要人工处理
+}
+
+// ThreeWayChange 相对基线的变更
+type ThreeWayChange struct {
+	ObjectType   string `json:"object_type"`
+	Name         string `json:"name"`
+	SourceChange string `json:"source_change,omitempty"` // 源环境相对基线的变更
+	TargetChange string `json:"target_change,omitempty"` // 目标环境相对基线的变更
+}
+
+// HasConflicts 是否存在冲突
+func (d *ThreeWayDiff) HasConflicts() bool {
+	return len(d.Conflicts) > 0
+}
+
+// CompareThreeWay 以基线快照为共同祖先进行三方对比
+// 源与目标之间的每项差异按相对基线的变更来源分类：只有源环境改动的保留，
+// 只有目标环境改动的（热修复）不生成语句，双方都改动的记为冲突
+func (e *DiffEngine) CompareThreeWay(base, source, target *extractor.DatabaseSchema) *ThreeWayDiff {
+	full := e.Compare(source, target)
+	c := &threeWayClassifier{
+		source: collectChanges(e.Compare(source, base)),
+		target: collectChanges(e.Compare(target, base)),
+		result: &ThreeWayDiff{
+			Diff: &SchemaDiff{
+				SourceEnv:   full.SourceEnv,
+				TargetEnv:   full.TargetEnv,
+				GeneratedAt: full.GeneratedAt,
+			},
+		},
+	}
+	diff := c.result.Diff
+
+	for i := range full.TableDiffs {
+		if td := c.table(&full.TableDiffs[i]); td != nil {
+			diff.TableDiffs = append(diff.TableDiffs, *td)
+		}
+	}
+	for _, vd := range full.ViewDiffs {
+		if c.keep("视图", vd.ViewName, "view:"+vd.ViewName, "view:"+vd.ViewName, "", "") {
+			diff.ViewDiffs = append(diff.ViewDiffs, vd)
+		}
+	}
+	for _, pd := range full.ProcDiffs {
+		if c.keep("存储过程", pd.ProcName, "procedure:"+pd.ProcName, "procedure:"+pd.ProcName, "", "") {
+			diff.ProcDiffs = append(diff.ProcDiffs, pd)
+		}
+	}
+	for _, fd := range full.FuncDiffs {
+		if c.keep("函数", fd.FuncName, "function:"+fd.FuncName, "function:"+fd.FuncName, "", "") {
+			diff.FuncDiffs = append(diff.FuncDiffs, fd)
+		}
+	}
+	for _, td := range full.TriggerDiffs {
+		if c.keep("触发器", td.TriggerName, "trigger:"+td.TriggerName, "trigger:"+td.TriggerName, "", "") {
+			diff.TriggerDiffs = append(diff.TriggerDiffs, td)
+		}
+	}
+	for _, ed := range full.EventDiffs {
+		if c.keep("事件", ed.EventName, "event:"+ed.EventName, "event:"+ed.EventName, "", "") {
+			diff.EventDiffs = append(diff.EventDiffs, ed)
+		}
+	}
+
+	diff.Statistics = e.calculateStatistics(diff)
+	return c.result
+}
+
+// changeSet 一侧相对基线的变更，键为 类型:对象名，值为变更类型
+type changeSet struct {
+	keys   map[string]string
+	tables map[string]string // 整表新增或删除
+}
+
+// lookup 查找对象的变更，所在的表整体新增或删除时也视为变更
+func (s *changeSet) lookup(key, table string) (string, bool) {
+	if change, ok := s.keys[key]; ok {
+		return change, true
+	}
+	if table != "" {
+		if change, ok := s.tables[table]; ok {
+			return change, true
+		}
+	}
+	return "", false
+}
+
+// collectChanges 收集差异中的所有对象
+func collectChanges(d *SchemaDiff) *changeSet {
+	s := &changeSet{keys: make(map[string]string), tables: make(map[string]string)}
+	for _, td := range d.TableDiffs {
+		names := []string{td.TableName}
+		if td.OldName != "" {
+			names = append(names, td.OldName)
+		}
+		for _, name := range names {
+			s.keys["table:"+name] = td.DiffType.String()
+			switch td.DiffType {
+			case DiffTypeAdded, DiffTypeRemoved:
+				s.tables[name] = td.DiffType.String()
+			case DiffTypeRenamed:
+				s.keys["rename:"+name] = td.DiffType.String()
+			}
+		}
+
+		for _, cd := range td.ColumnDiffs {
+			s.keys["column:"+td.TableName+"."+cd.ColumnName] = cd.DiffType.String()
+			if cd.OldName != "" {
+				s.keys["column:"+td.TableName+"."+cd.OldName] = cd.DiffType.String()
+			}
+		}
+		for _, id := range td.IndexDiffs {
+			s.keys["index:"+td.TableName+"."+id.IndexName] = id.DiffType.String()
+		}
+		for _, fkd := range td.FKeyDiffs {
+			s.keys["fk:"+td.TableName+"."+fkd.FKeyName] = fkd.DiffType.String()
+		}
+		for _, ckd := range td.CheckDiffs {
+			s.keys["check:"+td.TableName+"."+ckd.CheckName] = ckd.DiffType.String()
+		}
+		for _, prop := range td.TableProps {
+			s.keys["prop:"+td.TableName+"."+prop.Property] = fmt.Sprintf("%s -> %s", prop.OldValue, prop.NewValue)
+		}
+		if td.PartitionDiff != nil {
+			s.keys["partition:"+td.TableName] = td.PartitionDiff.DiffType.String()
+		}
+	}
+
+	for _, vd := range d.ViewDiffs {
+		s.keys["view:"+vd.ViewName] = vd.DiffType.String()
+	}
+	for _, pd := range d.ProcDiffs {
+		s.keys["procedure:"+pd.ProcName] = pd.DiffType.String()
+	}
+	for _, fd := range d.FuncDiffs {
+		s.keys["function:"+fd.FuncName] = fd.DiffType.String()
+	}
+	for _, td := range d.TriggerDiffs {
+		s.keys["trigger:"+td.TriggerName] = td.DiffType.String()
+	}
+	for _, ed := range d.EventDiffs {
+		s.keys["event:"+ed.EventName] = ed.DiffType.String()
+	}
+	return s
+}
+
+// threeWayClassifier 按变更来源过滤差异
+type threeWayClassifier struct {
+	source *changeSet
+	target *changeSet
+	result *ThreeWayDiff
+}
+
+// keep 判断差异是否应保留；只有目标环境改动的记入 TargetChanges，双方都改动的记入 Conflicts
+// sourceKey/sourceTable 使用源环境中的名称，targetKey/targetTable 使用目标环境中的名称
+func (c *threeWayClassifier) keep(objectType, name, sourceKey, targetKey, sourceTable, targetTable string) bool {
+	sourceChange, sourceChanged := c.source.lookup(sourceKey, sourceTable)
+	targetChange, targetChanged := c.target.lookup(targetKey, targetTable)
+
+	change := ThreeWayChange{ObjectType: objectType, Name: name, SourceChange: sourceChange, TargetChange: targetChange}
+	switch {
+	case sourceChanged && targetChanged:
+		c.result.Conflicts = append(c.result.Conflicts, change)
+		return false
+	case targetChanged:
+		c.result.TargetChanges = append(c.result.TargetChanges, change)
+		return false
+	}
+	// 只有源环境改动，或基线已过期无法判断时按两方对比处理
+	return true
+}
+
+// table 过滤表差异，返回 nil 表示整个表差异都不需要生成
+func (c *threeWayClassifier) table(td *TableDiff) *TableDiff {
+	sourceTable := td.TableName
+	targetTable := td.TableName
+	if td.OldName != "" {
+		targetTable = td.OldName
+	}
+
+	switch td.DiffType {
+	case DiffTypeAdded, DiffTypeRemoved:
+		if c.keep("表", td.TableName, "table:"+sourceTable, "table:"+targetTable, "", "") {
+			return td
+		}
+		return nil
+
+	case DiffTypeRenamed:
+		_, sourceRenamed := c.source.lookup("rename:"+sourceTable, "")
+		if !c.keep("表", renamed(td.OldName, td.TableName), "rename:"+sourceTable, "rename:"+targetTable, "", "") {
+			if sourceRenamed {
+				// 双方都重命名过，整表交由人工处理
+				return nil
+			}
+			// 只有目标环境重命名，保留目标环境的表名，其余变更按该表名生成
+			td.DiffType = DiffTypeModified
+			td.TableName = td.OldName
+			td.OldName = ""
+		}
+	}
+
+	filtered := *td
+	filtered.ColumnDiffs = nil
+	filtered.IndexDiffs = nil
+	filtered.FKeyDiffs = nil
+	filtered.CheckDiffs = nil
+	filtered.TableProps = nil
+	filtered.PartitionDiff = nil
+
+	for _, cd := range td.ColumnDiffs {
+		targetColumn := cd.ColumnName
+		if cd.OldName != "" {
+			targetColumn = cd.OldName
+		}
+		if c.keep("列", sourceTable+"."+cd.ColumnName,
+			"column:"+sourceTable+"."+cd.ColumnName, "column:"+targetTable+"."+targetColumn, sourceTable, targetTable) {
+			filtered.ColumnDiffs = append(filtered.ColumnDiffs, cd)
+		}
+	}
+	for _, id := range td.IndexDiffs {
+		if c.keep("索引", sourceTable+"."+id.IndexName,
+			"index:"+sourceTable+"."+id.IndexName, "index:"+targetTable+"."+id.IndexName, sourceTable, targetTable) {
+			filtered.IndexDiffs = append(filtered.IndexDiffs, id)
+		}
+	}
+	for _, fkd := range td.FKeyDiffs {
+		if c.keep("外键", sourceTable+"."+fkd.FKeyName,
+			"fk:"+sourceTable+"."+fkd.FKeyName, "fk:"+targetTable+"."+fkd.FKeyName, sourceTable, targetTable) {
+			filtered.FKeyDiffs = append(filtered.FKeyDiffs, fkd)
+		}
+	}
+	for _, ckd := range td.CheckDiffs {
+		if c.keep("CHECK约束", sourceTable+"."+ckd.CheckName,
+			"check:"+sourceTable+"."+ckd.CheckName, "check:"+targetTable+"."+ckd.CheckName, sourceTable, targetTable) {
+			filtered.CheckDiffs = append(filtered.CheckDiffs, ckd)
+		}
+	}
+	for _, prop := range td.TableProps {
+		if c.keep("表属性", sourceTable+"."+prop.Property,
+			"prop:"+sourceTable+"."+prop.Property, "prop:"+targetTable+"."+prop.Property, sourceTable, targetTable) {
+			filtered.TableProps = append(filtered.TableProps, prop)
+		}
+	}
+	if td.PartitionDiff != nil && c.keep("分区", sourceTable,
+		"partition:"+sourceTable, "partition:"+targetTable, sourceTable, targetTable) {
+		filtered.PartitionDiff = td.PartitionDiff
+	}
+
+	if filtered.DiffType == DiffTypeRenamed {
+		// 重命名本身就是需要生成的变更
+		if len(filtered.ColumnDiffs) != len(td.ColumnDiffs) || len(filtered.IndexDiffs) != len(td.IndexDiffs) ||
+			len(filtered.FKeyDiffs) != len(td.FKeyDiffs) || len(filtered.CheckDiffs) != len(td.CheckDiffs) ||
+			len(filtered.TableProps) != len(td.TableProps) || (filtered.PartitionDiff == nil) != (td.PartitionDiff == nil) {
+			summarizeTableDiff(&filtered)
+			filtered.Description = renamedDescription(filtered.OldName, filtered.Description)
+		}
+		return &filtered
+	}
+
+	if len(filtered.ColumnDiffs) == 0 && len(filtered.IndexDiffs) == 0 && len(filtered.FKeyDiffs) == 0 &&
+		len(filtered.CheckDiffs) == 0 && len(filtered.TableProps) == 0 && filtered.PartitionDiff == nil {
+		return nil
+	}
+	summarizeTableDiff(&filtered)
+	return &filtered
+}
+
+// renamed 返回 "旧名称 -> 新名称"
+func renamed(oldName, name string) string {
+	return oldName + " -> " + name
+}
+
+// renamedDescription 重新生成重命名表的描述
+func renamedDescription(oldName, description string) string {
+	if description == "" {
+		return "重命名自 " + oldName
+	}
+	return fmt.Sprintf("重命名自 %s, %s", oldName, description)
+}
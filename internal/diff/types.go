@@ -13,6 +13,7 @@ const (
 	DiffTypeAdded    DiffType = iota // 新增
 	DiffTypeRemoved                  // 删除
 	DiffTypeModified                 // 修改
+	DiffTypeRenamed                  // 重命名
 )
 
 func (t DiffType) String() string {
@@ -23,6 +24,8 @@ func (t DiffType) String() string {
 		return "删除"
 	case DiffTypeModified:
 		return "修改"
+	case DiffTypeRenamed:
+		return "重命名"
 	default:
 		return "未知"
 	}
@@ -59,6 +62,7 @@ type SchemaDiff struct {
 	ProcDiffs    []ProcedureDiff  `json:"proc_diffs"`
 	FuncDiffs    []FunctionDiff   `json:"func_diffs"`
 	TriggerDiffs []TriggerDiff    `json:"trigger_diffs"`
+	EventDiffs   []EventDiff      `json:"event_diffs"`
 	Statistics   DiffStatistics   `json:"statistics"`
 	GeneratedAt  time.Time        `json:"generated_at"`
 }
@@ -69,6 +73,8 @@ type DiffStatistics struct {
 	TablesAdded   int `json:"tables_added"`
 	TablesRemoved int `json:"tables_removed"`
 	TablesChanged int `json:"tables_changed"`
+	TablesRenamed int `json:"tables_renamed"`
+	ColumnsRenamed int `json:"columns_renamed"`
 	ViewsAdded    int `json:"views_added"`
 	ViewsRemoved  int `json:"views_removed"`
 	ViewsChanged  int `json:"views_changed"`
@@ -81,6 +87,9 @@ type DiffStatistics struct {
 	TriggersAdded   int `json:"triggers_added"`
 	TriggersRemoved int `json:"triggers_removed"`
 	TriggersChanged int `json:"triggers_changed"`
+	EventsAdded   int `json:"events_added"`
+	EventsRemoved int `json:"events_removed"`
+	EventsChanged int `json:"events_changed"`
 	DangerCount   int `json:"danger_count"`
 	WarningCount  int `json:"warning_count"`
 	InfoCount     int `json:"info_count"`
@@ -89,6 +98,7 @@ type DiffStatistics struct {
 // TableDiff 表差异
 type TableDiff struct {
 	TableName   string                  `json:"table_name"`
+	OldName     string                  `json:"old_name,omitempty"` // 重命名前的表名
 	DiffType    DiffType                `json:"diff_type"`
 	Severity    DiffSeverity            `json:"severity"`
 	OldTable    *extractor.TableSchema  `json:"old_table,omitempty"`
@@ -96,19 +106,25 @@ type TableDiff struct {
 	ColumnDiffs []ColumnDiff            `json:"column_diffs,omitempty"`
 	IndexDiffs  []IndexDiff             `json:"index_diffs,omitempty"`
 	FKeyDiffs   []ForeignKeyDiff        `json:"fkey_diffs,omitempty"`
+	CheckDiffs  []CheckDiff             `json:"check_diffs,omitempty"`
 	TableProps  []PropertyDiff          `json:"table_props,omitempty"` // 表属性变更(引擎、字符集等)
+	PartitionDiff *PartitionDiff        `json:"partition_diff,omitempty"` // 分区定义变更
 	Description string                  `json:"description"`
 }
 
 // ColumnDiff 列差异
 type ColumnDiff struct {
 	ColumnName    string                   `json:"column_name"`
+	OldName       string                   `json:"old_name,omitempty"` // 重命名前的列名
+	AfterColumn   string                   `json:"after_column,omitempty"` // 列应放在该列之后
+	First         bool                     `json:"first,omitempty"`        // 列应放在第一列
 	DiffType      DiffType                 `json:"diff_type"`
 	Severity      DiffSeverity             `json:"severity"`
 	OldColumn     *extractor.ColumnSchema  `json:"old_column,omitempty"`
 	NewColumn     *extractor.ColumnSchema  `json:"new_column,omitempty"`
 	Changes       []PropertyDiff           `json:"changes,omitempty"`
 	RiskNote      string                   `json:"risk_note"`
+	DataChecks    []DataCheck              `json:"data_checks,omitempty"` // 针对目标库数据的预检结果
 }
 
 // IndexDiff 索引差异
@@ -120,6 +136,7 @@ type IndexDiff struct {
 	NewIndex    *extractor.IndexSchema  `json:"new_index,omitempty"`
 	Changes     []PropertyDiff          `json:"changes,omitempty"`
 	Description string                  `json:"description"`
+	DataChecks  []DataCheck             `json:"data_checks,omitempty"` // 针对目标库数据的预检结果
 }
 
 // ForeignKeyDiff 外键差异
@@ -130,6 +147,31 @@ type ForeignKeyDiff struct {
 	OldFKey     *extractor.ForeignKey `json:"old_fkey,omitempty"`
 	NewFKey     *extractor.ForeignKey `json:"new_fkey,omitempty"`
 	Description string                `json:"description"`
+	DataChecks  []DataCheck           `json:"data_checks,omitempty"` // 针对目标库数据的预检结果
+}
+
+// CheckDiff CHECK约束差异
+type CheckDiff struct {
+	CheckName   string                     `json:"check_name"`
+	DiffType    DiffType                   `json:"diff_type"`
+	Severity    DiffSeverity               `json:"severity"`
+	OldCheck    *extractor.CheckConstraint `json:"old_check,omitempty"`
+	NewCheck    *extractor.CheckConstraint `json:"new_check,omitempty"`
+	ExprChanged bool                       `json:"expr_changed"` // 表达式变化，需要删除后重建
+	Description string                     `json:"description"`
+}
+
+// PartitionDiff 分区差异
+// DiffTypeAdded 表示表变为分区表，DiffTypeRemoved 表示取消分区
+type PartitionDiff struct {
+	DiffType     DiffType                 `json:"diff_type"`
+	Severity     DiffSeverity             `json:"severity"`
+	OldPartition *extractor.PartitionInfo `json:"old_partition,omitempty"`
+	NewPartition *extractor.PartitionInfo `json:"new_partition,omitempty"`
+	Repartition  bool                     `json:"repartition"`       // 需要整体 PARTITION BY 重写
+	Added        []string                 `json:"added,omitempty"`   // 新增的分区
+	Removed      []string                 `json:"removed,omitempty"` // 删除的分区
+	Description  string                   `json:"description"`
 }
 
 // ViewDiff 视图差异
@@ -144,22 +186,24 @@ type ViewDiff struct {
 
 // ProcedureDiff 存储过程差异
 type ProcedureDiff struct {
-	ProcName    string                      `json:"proc_name"`
-	DiffType    DiffType                    `json:"diff_type"`
-	Severity    DiffSeverity                `json:"severity"`
-	OldProc     *extractor.ProcedureSchema  `json:"old_proc,omitempty"`
-	NewProc     *extractor.ProcedureSchema  `json:"new_proc,omitempty"`
-	Description string                      `json:"description"`
+	ProcName      string                     `json:"proc_name"`
+	DiffType      DiffType                   `json:"diff_type"`
+	Severity      DiffSeverity               `json:"severity"`
+	OldProc       *extractor.ProcedureSchema `json:"old_proc,omitempty"`
+	NewProc       *extractor.ProcedureSchema `json:"new_proc,omitempty"`
+	PropertyDiffs []PropertyDiff             `json:"property_diffs,omitempty"` // 参数、主体、安全性、sql_mode等变更
+	Description   string                     `json:"description"`
 }
 
 // FunctionDiff 函数差异
 type FunctionDiff struct {
-	FuncName    string                     `json:"func_name"`
-	DiffType    DiffType                   `json:"diff_type"`
-	Severity    DiffSeverity               `json:"severity"`
-	OldFunc     *extractor.FunctionSchema  `json:"old_func,omitempty"`
-	NewFunc     *extractor.FunctionSchema  `json:"new_func,omitempty"`
-	Description string                     `json:"description"`
+	FuncName      string                    `json:"func_name"`
+	DiffType      DiffType                  `json:"diff_type"`
+	Severity      DiffSeverity              `json:"severity"`
+	OldFunc       *extractor.FunctionSchema `json:"old_func,omitempty"`
+	NewFunc       *extractor.FunctionSchema `json:"new_func,omitempty"`
+	PropertyDiffs []PropertyDiff            `json:"property_diffs,omitempty"` // 参数、返回类型、主体、确定性等变更
+	Description   string                    `json:"description"`
 }
 
 // TriggerDiff 触发器差异
@@ -172,6 +216,29 @@ type TriggerDiff struct {
 	Description string                    `json:"description"`
 }
 
+// EventDiff 事件差异
+type EventDiff struct {
+	EventName     string                 `json:"event_name"`
+	DiffType      DiffType               `json:"diff_type"`
+	Severity      DiffSeverity           `json:"severity"`
+	OldEvent      *extractor.EventSchema `json:"old_event,omitempty"`
+	NewEvent      *extractor.EventSchema `json:"new_event,omitempty"`
+	PropertyDiffs []PropertyDiff         `json:"property_diffs,omitempty"` // 调度、状态、事件体等变更
+	Description   string                 `json:"description"`
+}
+
+// DataCheck 对目标库数据的预检结果
+type DataCheck struct {
+	Check    string `json:"check"`           // null_rows, over_length, out_of_range, duplicate_keys, orphan_rows
+	Query    string `json:"query"`           // 执行的只读查询
+	Count    int64  `json:"count"`           // 不满足新定义的行数或重复组数
+	Max      int64  `json:"max,omitempty"`   // 现有数据的最大长度（over_length）
+	Limit    int64  `json:"limit,omitempty"` // 新定义允许的上限，如新的字符长度
+	Blocking bool   `json:"blocking"`        // 现有数据不满足新定义，执行将失败或截断数据
+	Message  string `json:"message"`
+	Error    string `json:"error,omitempty"` // 查询失败时的错误
+}
+
 // PropertyDiff 属性差异
 type PropertyDiff struct {
 	Property string `json:"property"`
@@ -185,7 +252,8 @@ func (d *SchemaDiff) HasDiff() bool {
 		len(d.ViewDiffs) > 0 ||
 		len(d.ProcDiffs) > 0 ||
 		len(d.FuncDiffs) > 0 ||
-		len(d.TriggerDiffs) > 0
+		len(d.TriggerDiffs) > 0 ||
+		len(d.EventDiffs) > 0
 }
 
 // GetMaxSeverity 获取最高严重程度
@@ -227,6 +295,12 @@ func (d *SchemaDiff) GetMaxSeverity() DiffSeverity {
 		}
 	}
 
+	for _, ed := range d.EventDiffs {
+		if ed.Severity > max {
+			max = ed.Severity
+		}
+	}
+
 	return max
 }
 
@@ -245,6 +319,9 @@ func (d *SchemaDiff) CountBySeverity() map[DiffSeverity]int {
 		for _, fkd := range td.FKeyDiffs {
 			counts[fkd.Severity]++
 		}
+		for _, ckd := range td.CheckDiffs {
+			counts[ckd.Severity]++
+		}
 	}
 
 	for _, vd := range d.ViewDiffs {
@@ -263,5 +340,9 @@ func (d *SchemaDiff) CountBySeverity() map[DiffSeverity]int {
 		counts[td.Severity]++
 	}
 
+	for _, ed := range d.EventDiffs {
+		counts[ed.Severity]++
+	}
+
 	return counts
 }
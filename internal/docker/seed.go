@@ -0,0 +1,323 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// SeedMode 样本数据填充方式
+type SeedMode string
+
+const (
+	SeedNone      SeedMode = ""          // 不填充，只导入空表
+	SeedSample    SeedMode = "sample"    // 从目标环境抽样
+	SeedSynthetic SeedMode = "synthetic" // 按列类型合成
+)
+
+// DefaultSeedRows 每张表默认填充的行数
+const DefaultSeedRows = 100
+
+// SeedOptions 执行升级脚本前填充样本数据的选项
+type SeedOptions struct {
+	Mode        SeedMode
+	RowLimit    int                 // 每张表最多填充的行数，默认 DefaultSeedRows
+	MaskColumns []string            // 抽样时需要脱敏的列 (格式: table.column，支持通配符)
+	Source      *config.Environment // 抽样来源，一般为目标环境
+}
+
+// ParseSeedMode 解析样本数据填充方式
+func ParseSeedMode(value string) (SeedMode, error) {
+	switch SeedMode(strings.ToLower(value)) {
+	case SeedNone, "none":
+		return SeedNone, nil
+	case SeedSample:
+		return SeedSample, nil
+	case SeedSynthetic:
+		return SeedSynthetic, nil
+	}
+	return SeedNone, fmt.Errorf("不支持的样本数据方式: %s（可选 sample、synthetic）", value)
+}
+
+// seedData 向容器中的表写入样本数据，返回每张表写入的行数和警告
+func (v *Validator) seedData(ctx context.Context, container *Container, schema *extractor.DatabaseSchema, options SeedOptions) (map[string]int, []string) {
+	limit := options.RowLimit
+	if limit <= 0 {
+		limit = DefaultSeedRows
+	}
+
+	var warnings []string
+	var sampler *extractor.MySQLExtractor
+	if options.Mode == SeedSample {
+		if options.Source == nil {
+			warnings = append(warnings, "目标环境不是数据库连接，改为按列类型合成样本数据")
+		} else {
+			ext, err := extractor.NewMySQLExtractor(options.Source)
+			if err == nil {
+				err = ext.Connect(ctx)
+			}
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("连接目标环境抽样失败，改为按列类型合成样本数据: %v", err))
+			} else {
+				defer ext.Close()
+				sampler = ext
+			}
+		}
+	}
+
+	names := make([]string, 0, len(schema.Tables))
+	for name := range schema.Tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	seeded := make(map[string]int)
+	for _, name := range names {
+		table := schema.Tables[name]
+		columns := seedColumns(table)
+		if len(columns) == 0 {
+			continue
+		}
+
+		var rows [][]*string
+		if sampler != nil {
+			colNames := make([]string, len(columns))
+			for i, col := range columns {
+				colNames[i] = col.Name
+			}
+			sampled, err := sampler.SampleRows(ctx, name, colNames, limit)
+			if err != nil {
+				warnings = append(warnings, err.Error())
+				continue
+			}
+			rows = maskRows(name, columns, sampled, options.MaskColumns)
+		} else {
+			rows = synthesizeRows(table, columns, limit)
+		}
+		if len(rows) == 0 {
+			continue
+		}
+
+		// 样本数据按原样写入，不受严格模式和外键顺序影响
+		sql := "SET SESSION sql_mode = 'NO_AUTO_VALUE_ON_ZERO';\nSET FOREIGN_KEY_CHECKS = 0;\n" +
+			buildInsertSQL(name, columns, rows)
+		result, err := v.manager.ExecuteSQL(ctx, container, sql)
+		if err == nil && !result.Success {
+			err = fmt.Errorf("%s", result.Error)
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("表 %s 填充样本数据失败: %v", name, err))
+			continue
+		}
+		seeded[name] = len(rows)
+	}
+
+	return seeded, warnings
+}
+
+// seedColumns 返回可写入的列（生成列由数据库计算）
+func seedColumns(table *extractor.TableSchema) []*extractor.ColumnSchema {
+	var columns []*extractor.ColumnSchema
+	for _, col := range table.Columns {
+		if !col.IsGenerated {
+			columns = append(columns, col)
+		}
+	}
+	return columns
+}
+
+// buildInsertSQL 构建批量插入语句
+func buildInsertSQL(tableName string, columns []*extractor.ColumnSchema, rows [][]*string) string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = "`" + col.Name + "`"
+	}
+
+	values := make([]string, len(rows))
+	for i, row := range rows {
+		literals := make([]string, len(columns))
+		for j, col := range columns {
+			literals[j] = seedLiteral(col, row[j])
+		}
+		values[i] = "(" + strings.Join(literals, ", ") + ")"
+	}
+
+	return fmt.Sprintf("INSERT INTO `%s` (%s) VALUES\n%s;\n", tableName, strings.Join(names, ", "), strings.Join(values, ",\n"))
+}
+
+// seedLiteral 将值转换为SQL字面量，二进制类型使用十六进制
+func seedLiteral(col *extractor.ColumnSchema, value *string) string {
+	if value == nil {
+		return "NULL"
+	}
+	if isBinaryType(col.DataType) {
+		if *value == "" {
+			return "''"
+		}
+		return "X'" + hex.EncodeToString([]byte(*value)) + "'"
+	}
+
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`)
+	return "'" + replacer.Replace(*value) + "'"
+}
+
+// isBinaryType 是否为需要按字节写入的类型
+func isBinaryType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit",
+		"geometry", "point", "linestring", "polygon", "multipoint", "multilinestring", "multipolygon", "geometrycollection":
+		return true
+	}
+	return false
+}
+
+// isTextType 是否为可脱敏的文本类型
+func isTextType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext":
+		return true
+	}
+	return false
+}
+
+// maskRows 对匹配脱敏规则的文本列做不可逆替换，保留长度以便验证长度收缩
+func maskRows(tableName string, columns []*extractor.ColumnSchema, rows [][]*string, patterns []string) [][]*string {
+	var masked []int
+	for i, col := range columns {
+		if isTextType(col.DataType) && matchColumn(patterns, tableName, col.Name) {
+			masked = append(masked, i)
+		}
+	}
+	if len(masked) == 0 {
+		return rows
+	}
+
+	for _, row := range rows {
+		for _, i := range masked {
+			if row[i] != nil {
+				value := maskValue(*row[i])
+				row[i] = &value
+			}
+		}
+	}
+	return rows
+}
+
+// matchColumn 检查 table.column 是否匹配任一规则
+func matchColumn(patterns []string, tableName, columnName string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, tableName+"."+columnName); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// maskValue 用散列值替换原值，字符数与原值相同，相同输入得到相同输出以保留唯一性
+func maskValue(value string) string {
+	length := len([]rune(value))
+	if length == 0 {
+		return value
+	}
+
+	sum := sha256.Sum256([]byte(value))
+	digest := hex.EncodeToString(sum[:])
+	masked := strings.Repeat(digest, length/len(digest)+1)
+	return masked[:length]
+}
+
+// synthesizeRows 按列类型生成样本数据
+// 数值列和自增列使用行号，外键引用的父表通常也是同样的行号；可空列每隔一行写入 NULL
+func synthesizeRows(table *extractor.TableSchema, columns []*extractor.ColumnSchema, limit int) [][]*string {
+	indexed := make(map[string]bool)
+	for _, idx := range table.Indexes {
+		for _, col := range idx.Columns {
+			indexed[col.Name] = true
+		}
+	}
+
+	rows := make([][]*string, limit)
+	for i := range rows {
+		n := i + 1
+		row := make([]*string, len(columns))
+		for j, col := range columns {
+			if col.IsNullable && !indexed[col.Name] && n%2 == 0 {
+				continue
+			}
+			value, ok := syntheticValue(col, n)
+			if !ok {
+				continue
+			}
+			row[j] = &value
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// syntheticValue 生成第 n 行的列值，无法生成时返回 false（写入 NULL）
+func syntheticValue(col *extractor.ColumnSchema, n int) (string, bool) {
+	base := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, n)
+
+	switch strings.ToLower(col.DataType) {
+	case "tinyint":
+		return strconv.Itoa(n % 128), true
+	case "smallint", "mediumint", "int", "integer", "bigint", "decimal", "numeric", "float", "double", "real":
+		return strconv.Itoa(n), true
+	case "bit":
+		return string([]byte{byte(n % 2)}), true
+	case "year":
+		return strconv.Itoa(2000 + n%100), true
+	case "date":
+		return base.Format("2006-01-02"), true
+	case "datetime", "timestamp":
+		return base.Format("2006-01-02 15:04:05"), true
+	case "time":
+		return fmt.Sprintf("%02d:00:00", n%24), true
+	case "json":
+		return fmt.Sprintf(`{"id": %d}`, n), true
+	case "enum", "set":
+		return firstEnumValue(col.ColumnType)
+	case "geometry", "point":
+		// SRID 0 的 POINT(0 0)，内部格式为 4 字节 SRID + WKB
+		return string(append([]byte{0, 0, 0, 0, 1, 1, 0, 0, 0}, make([]byte, 16)...)), true
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "binary", "varbinary",
+		"tinyblob", "blob", "mediumblob", "longblob":
+		value := strconv.Itoa(n)
+		if col.CharMaxLen != nil && int64(len(value)) > *col.CharMaxLen {
+			value = value[len(value)-int(*col.CharMaxLen):]
+		}
+		return value, true
+	}
+	return "", false
+}
+
+// firstEnumValue 返回 enum/set 定义中的第一个值
+func firstEnumValue(columnType string) (string, bool) {
+	start := strings.Index(columnType, "'")
+	if start < 0 {
+		return "", false
+	}
+	var value strings.Builder
+	for i := start + 1; i < len(columnType); i++ {
+		if columnType[i] == '\'' {
+			if i+1 < len(columnType) && columnType[i+1] == '\'' {
+				value.WriteByte('\'')
+				i++
+				continue
+			}
+			return value.String(), true
+		}
+		value.WriteByte(columnType[i])
+	}
+	return "", false
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/diff"
 	"github.com/starvpn/schemapatch/internal/extractor"
 	"github.com/starvpn/schemapatch/internal/sqlgen"
 	"go.uber.org/zap"
@@ -19,6 +20,10 @@ type ValidationOptions struct {
 	Cleanup        bool          // 验证后是否清理
 	QuickMode      bool          // 快速模式（仅语法检查）
 	CompareSchema  bool          // 验证后对比Schema
+	IgnoreRules    config.IgnoreConfig // 对比Schema时使用的忽略规则
+	VerifyRollback bool          // 升级后执行回滚语句，并与目标Schema对比
+	Seed           SeedOptions   // 执行脚本前填充样本数据
+	DefinerPolicy  config.DefinerPolicy // 导入目标Schema时视图、存储过程、函数和触发器的 DEFINER 处理策略
 }
 
 // DefaultValidationOptions 默认验证选项
@@ -40,10 +45,23 @@ type ValidationResult struct {
 	Warnings      []string             `json:"warnings"`
 	SchemaMatch   bool                 `json:"schema_match"`
 	SchemaDiffs   []string             `json:"schema_diffs,omitempty"`
+	RemainingDiff *diff.SchemaDiff     `json:"remaining_diff,omitempty"` // 升级后与开发环境仍存在的差异
+	Rollback      *RollbackResult      `json:"rollback,omitempty"`       // 回滚验证结果（启用 VerifyRollback 时）
+	SeededRows    map[string]int       `json:"seeded_rows,omitempty"`    // 每张表填充的样本行数
+	DataWarnings  []string             `json:"data_warnings,omitempty"`  // 执行时截断或转换数据的语句
 	ExecutionTime time.Duration        `json:"execution_time"`
 	ContainerLog  string               `json:"container_log"`
 }
 
+// RollbackResult 回滚验证结果
+type RollbackResult struct {
+	Match         bool             `json:"match"`                    // 回滚后是否与目标Schema一致
+	Irreversible  []string         `json:"irreversible,omitempty"`   // 没有回滚语句的升级语句
+	FailedSteps   []string         `json:"failed_steps,omitempty"`   // 执行失败的回滚语句
+	SchemaDiffs   []string         `json:"schema_diffs,omitempty"`   // 回滚后与目标Schema的差异说明
+	RemainingDiff *diff.SchemaDiff `json:"remaining_diff,omitempty"` // 回滚后与目标Schema仍存在的差异
+}
+
 // ExecutionLogEntry 执行日志条目
 type ExecutionLogEntry struct {
 	Timestamp time.Time     `json:"timestamp"`
@@ -87,6 +105,12 @@ func (v *Validator) Validate(ctx context.Context, sourceSchema, targetSchema *ex
 
 	// 计算总步骤数
 	totalSteps := 4 + len(script.Statements) // 检查Docker + 创建容器 + 等待就绪 + 执行语句 + 清理
+	if options.VerifyRollback {
+		totalSteps += countRollbackSteps(script.Statements) + 1
+	}
+	if options.Seed.Mode != SeedNone {
+		totalSteps++
+	}
 	currentStep := 0
 
 	// 步骤1: 检查Docker
@@ -151,17 +175,33 @@ func (v *Validator) Validate(ctx context.Context, sourceSchema, targetSchema *ex
 		callback(currentStep, totalSteps, "导入目标Schema...", nil)
 	}
 
-	if err := v.importSchema(ctx, container, targetSchema); err != nil {
+	if err := v.importSchema(ctx, container, targetSchema, options.DefinerPolicy); err != nil {
 		v.logStep(result, currentStep, totalSteps, "导入Schema失败", "", false, err)
 		result.Errors = append(result.Errors, "导入Schema失败: "+err.Error())
 		return result, err
 	}
 
+	// 填充样本数据，使 NOT NULL、类型收缩、唯一索引和外键等变更在有数据时验证
+	if options.Seed.Mode != SeedNone {
+		currentStep++
+		v.logStep(result, currentStep, totalSteps, "填充样本数据...", "", true, nil)
+		if callback != nil {
+			callback(currentStep, totalSteps, "填充样本数据...", nil)
+		}
+
+		seeded, warnings := v.seedData(ctx, container, targetSchema, options.Seed)
+		result.SeededRows = seeded
+		result.Warnings = append(result.Warnings, warnings...)
+	}
+	hasData := len(result.SeededRows) > 0
+
 	// 步骤5-N: 执行升级语句
 	executeStart := time.Now()
 	successCount := 0
 	failCount := 0
 
+	// 每条语句在单独的 mysql 会话中执行，会话设置（如切换 sql_mode）需要带到之后的语句前面
+	var session []string
 	for i, stmt := range script.Statements {
 		currentStep++
 		stepMsg := fmt.Sprintf("执行 [%d/%d]: %s.%s", i+1, len(script.Statements), stmt.Operation, stmt.ObjectName)
@@ -170,26 +210,23 @@ func (v *Validator) Validate(ctx context.Context, sourceSchema, targetSchema *ex
 			callback(currentStep, totalSteps, stepMsg, nil)
 		}
 
-		var execResult *ExecutionResult
-		var execErr error
-
-		// 对于触发器、存储过程、函数，使用分隔符执行（它们可能包含多个分号）
-		if stmt.ObjectType == "TRIGGER" || stmt.ObjectType == "PROCEDURE" || stmt.ObjectType == "FUNCTION" {
-			// 去掉末尾的分号（如果有），然后用 $$ 作为分隔符
-			sql := strings.TrimSuffix(strings.TrimSpace(stmt.SQL), ";")
-			execResult, execErr = v.manager.ExecuteSQLWithDelimiter(ctx, container, sql+"\n$$", "$$")
-		} else {
-			execResult, execErr = v.manager.ExecuteSQL(ctx, container, stmt.SQL)
+		if stmt.ObjectType == "SESSION" {
+			session = append(session, stmt.SQL)
+			successCount++
+			v.logStep(result, currentStep, totalSteps, stepMsg+" ✓", stmt.SQL, true, nil)
+			continue
+		}
+		sql := stmt.SQL
+		if len(session) > 0 {
+			sql = strings.Join(session, "\n") + "\n" + sql
 		}
 
-		if execErr != nil || !execResult.Success {
+		errMsg, warnings := v.executeStatement(ctx, container, stmt.ObjectType, sql, hasData)
+		for _, warning := range warnings {
+			result.DataWarnings = append(result.DataWarnings, fmt.Sprintf("语句 %d %s.%s: %s", i+1, stmt.Operation, stmt.ObjectName, warning))
+		}
+		if errMsg != "" {
 			failCount++
-			errMsg := ""
-			if execErr != nil {
-				errMsg = execErr.Error()
-			} else {
-				errMsg = execResult.Error
-			}
 			v.logStep(result, currentStep, totalSteps, stepMsg, stmt.SQL, false, fmt.Errorf(errMsg))
 			result.Errors = append(result.Errors, fmt.Sprintf("语句 %d 执行失败: %s", i+1, errMsg))
 
@@ -214,12 +251,26 @@ func (v *Validator) Validate(ctx context.Context, sourceSchema, targetSchema *ex
 		}
 
 		// 比较容器中升级后的Schema与开发环境（sourceSchema）是否一致
-		match, diffs := v.compareSchemaInContainer(ctx, container, sourceSchema)
-		result.SchemaMatch = match
-		result.SchemaDiffs = diffs
+		remaining, err := v.compareSchemaInContainer(ctx, container, sourceSchema, options.IgnoreRules)
+		if err != nil {
+			result.SchemaDiffs = []string{err.Error()}
+			result.Warnings = append(result.Warnings, "无法验证升级后的Schema: "+err.Error())
+		} else {
+			result.RemainingDiff = remaining
+			result.SchemaMatch = !remaining.HasDiff()
+			result.SchemaDiffs = describeSchemaDiff(remaining)
+			if !result.SchemaMatch {
+				result.Warnings = append(result.Warnings,
+					fmt.Sprintf("升级后Schema与开发环境仍有 %d 项差异", remaining.Statistics.TotalDiffs))
+			}
+		}
+	}
 
-		if !match {
-			result.Warnings = append(result.Warnings, "升级后Schema与开发环境仍有差异")
+	// 回滚验证（执行回滚语句后应回到目标环境的原始状态）
+	if options.VerifyRollback && failCount == 0 {
+		result.Rollback = v.verifyRollback(ctx, container, targetSchema, script, options, result, &currentStep, totalSteps, callback)
+		if !result.Rollback.Match {
+			result.Warnings = append(result.Warnings, "回滚后Schema未能恢复到目标环境的原始状态")
 		}
 	}
 
@@ -233,8 +284,113 @@ func (v *Validator) Validate(ctx context.Context, sourceSchema, targetSchema *ex
 	return result, nil
 }
 
-// importSchema 导入Schema到容器
-func (v *Validator) importSchema(ctx context.Context, container *Container, schema *extractor.DatabaseSchema) error {
+// executeStatement 在容器中执行单条语句，返回错误信息（成功时为空）
+// collectWarnings 为 true 时在同一会话中读取 SHOW WARNINGS，返回数据截断、转换等警告
+func (v *Validator) executeStatement(ctx context.Context, container *Container, objectType, sql string, collectWarnings bool) (string, []string) {
+	var execResult *ExecutionResult
+	var execErr error
+
+	// 对于触发器、存储过程、函数、事件，使用分隔符执行（它们可能包含多个分号）
+	delimited := objectType == "TRIGGER" || objectType == "PROCEDURE" || objectType == "FUNCTION" || objectType == "EVENT"
+	if delimited {
+		// 去掉末尾的分号（如果有），然后用 $$ 作为分隔符
+		sql = strings.TrimSuffix(strings.TrimSpace(sql), ";")
+		execResult, execErr = v.manager.ExecuteSQLWithDelimiter(ctx, container, sql+"\n$$", "$$")
+	} else if collectWarnings {
+		execResult, execErr = v.manager.ExecuteSQL(ctx, container, sql+"\nSHOW WARNINGS;")
+	} else {
+		execResult, execErr = v.manager.ExecuteSQL(ctx, container, sql)
+	}
+
+	if execErr != nil {
+		return execErr.Error(), nil
+	}
+	if !execResult.Success {
+		return execResult.Error, nil
+	}
+	if collectWarnings && !delimited {
+		return "", parseWarnings(execResult.Output)
+	}
+	return "", nil
+}
+
+// parseWarnings 解析 mysql 客户端批处理模式下 SHOW WARNINGS 的输出（Level\tCode\tMessage）
+func parseWarnings(output string) []string {
+	var warnings []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) != 3 || (fields[0] != "Warning" && fields[0] != "Error") {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("[%s] %s", fields[1], fields[2]))
+	}
+	return warnings
+}
+
+// countRollbackSteps 统计带有回滚语句的升级语句数量
+func countRollbackSteps(statements []sqlgen.SQLStatement) int {
+	count := 0
+	for _, stmt := range statements {
+		if stmt.RollbackSQL != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// verifyRollback 逆序执行回滚语句，并将结果与目标Schema对比
+func (v *Validator) verifyRollback(ctx context.Context, container *Container, targetSchema *extractor.DatabaseSchema, script *sqlgen.MigrationScript, options ValidationOptions, result *ValidationResult, currentStep *int, totalSteps int, callback ProgressCallback) *RollbackResult {
+	rollback := &RollbackResult{}
+
+	for i := len(script.Statements) - 1; i >= 0; i-- {
+		stmt := script.Statements[i]
+		label := fmt.Sprintf("%s.%s", stmt.Operation, stmt.ObjectName)
+		if stmt.ObjectType == "SESSION" {
+			// 会话设置不需要回滚
+			continue
+		}
+		if stmt.RollbackSQL == "" {
+			rollback.Irreversible = append(rollback.Irreversible,
+				fmt.Sprintf("语句 %d %s: %s", i+1, label, stmt.Comment))
+			continue
+		}
+
+		*currentStep++
+		stepMsg := fmt.Sprintf("回滚 [%d/%d]: %s", i+1, len(script.Statements), label)
+		if callback != nil {
+			callback(*currentStep, totalSteps, stepMsg, nil)
+		}
+
+		if errMsg, _ := v.executeStatement(ctx, container, stmt.ObjectType, stmt.RollbackSQL, false); errMsg != "" {
+			v.logStep(result, *currentStep, totalSteps, stepMsg, stmt.RollbackSQL, false, fmt.Errorf("%s", errMsg))
+			rollback.FailedSteps = append(rollback.FailedSteps, fmt.Sprintf("语句 %d %s 回滚失败: %s", i+1, label, errMsg))
+			if callback != nil {
+				callback(*currentStep, totalSteps, stepMsg, fmt.Errorf("%s", errMsg))
+			}
+		} else {
+			v.logStep(result, *currentStep, totalSteps, stepMsg+" ✓", stmt.RollbackSQL, true, nil)
+		}
+	}
+
+	*currentStep++
+	v.logStep(result, *currentStep, totalSteps, "验证回滚结果（对比目标环境）...", "", true, nil)
+	if callback != nil {
+		callback(*currentStep, totalSteps, "验证回滚结果...", nil)
+	}
+
+	remaining, err := v.compareSchemaInContainer(ctx, container, targetSchema, options.IgnoreRules)
+	if err != nil {
+		rollback.SchemaDiffs = []string{err.Error()}
+		return rollback
+	}
+	rollback.RemainingDiff = remaining
+	rollback.SchemaDiffs = describeSchemaDiff(remaining)
+	rollback.Match = !remaining.HasDiff() && len(rollback.FailedSteps) == 0
+	return rollback
+}
+
+// importSchema 导入Schema到容器，视图、存储过程、函数和触发器的 DEFINER 按策略改写，与生成的脚本保持一致
+func (v *Validator) importSchema(ctx context.Context, container *Container, schema *extractor.DatabaseSchema, definerPolicy config.DefinerPolicy) error {
 	// 第一步：导入表和视图（普通SQL，用分号分隔）
 	var sqlBuilder strings.Builder
 
@@ -253,7 +409,8 @@ func (v *Validator) importSchema(ctx context.Context, container *Container, sche
 	// 创建视图
 	for name, view := range schema.Views {
 		if view.Definition != "" {
-			sqlBuilder.WriteString(fmt.Sprintf("CREATE VIEW `%s` AS %s;\n\n", name, view.Definition))
+			sqlBuilder.WriteString(fmt.Sprintf("CREATE %sVIEW `%s` AS %s;\n\n",
+				extractor.DefinerClause(definerPolicy, view.Definer), name, view.Definition))
 		}
 	}
 
@@ -271,7 +428,7 @@ func (v *Validator) importSchema(ctx context.Context, container *Container, sche
 	// 第二步：单独导入存储过程（使用 $$ 作为分隔符）
 	for name, proc := range schema.Procedures {
 		if proc.Definition != "" {
-			if err := v.importRoutine(ctx, container, "PROCEDURE", name, proc.Definition); err != nil {
+			if err := v.importRoutine(ctx, container, "PROCEDURE", name, extractor.ApplyDefinerPolicy(definerPolicy, proc.Definition, proc.Definer)); err != nil {
 				return fmt.Errorf("导入存储过程 %s 失败: %w", name, err)
 			}
 		}
@@ -280,7 +437,7 @@ func (v *Validator) importSchema(ctx context.Context, container *Container, sche
 	// 第三步：单独导入函数
 	for name, fn := range schema.Functions {
 		if fn.Definition != "" {
-			if err := v.importRoutine(ctx, container, "FUNCTION", name, fn.Definition); err != nil {
+			if err := v.importRoutine(ctx, container, "FUNCTION", name, extractor.ApplyDefinerPolicy(definerPolicy, fn.Definition, fn.Definer)); err != nil {
 				return fmt.Errorf("导入函数 %s 失败: %w", name, err)
 			}
 		}
@@ -288,17 +445,36 @@ func (v *Validator) importSchema(ctx context.Context, container *Container, sche
 
 	// 第四步：导入触发器
 	for _, trigger := range schema.Triggers {
-		triggerSQL := fmt.Sprintf("CREATE TRIGGER `%s` %s %s ON `%s` FOR EACH ROW %s",
-			trigger.Name, trigger.Timing, trigger.Event, trigger.Table, trigger.Statement)
+		triggerSQL := fmt.Sprintf("CREATE %sTRIGGER `%s` %s %s ON `%s` FOR EACH ROW %s",
+			extractor.DefinerClause(definerPolicy, trigger.Definer), trigger.Name, trigger.Timing, trigger.Event, trigger.Table, trigger.Statement)
 		if err := v.importRoutine(ctx, container, "TRIGGER", trigger.Name, triggerSQL); err != nil {
 			return fmt.Errorf("导入触发器 %s 失败: %w", trigger.Name, err)
 		}
 	}
 
+	// 第五步：导入事件
+	for _, event := range schema.Events {
+		eventSQL := fmt.Sprintf("CREATE %sEVENT `%s` ON SCHEDULE %s ON COMPLETION %s",
+			extractor.DefinerClause(definerPolicy, event.Definer), event.Name, event.Schedule(), event.OnCompletion)
+		switch event.Status {
+		case "DISABLED":
+			eventSQL += " DISABLE"
+		case "SLAVESIDE_DISABLED":
+			eventSQL += " DISABLE ON SLAVE"
+		}
+		if event.Comment != "" {
+			eventSQL += fmt.Sprintf(" COMMENT '%s'", strings.ReplaceAll(event.Comment, "'", "''"))
+		}
+		eventSQL += " DO " + event.Body
+		if err := v.importRoutine(ctx, container, "EVENT", event.Name, eventSQL); err != nil {
+			return fmt.Errorf("导入事件 %s 失败: %w", event.Name, err)
+		}
+	}
+
 	return nil
 }
 
-// importRoutine 导入存储过程/函数/触发器（使用自定义分隔符）
+// importRoutine 导入存储过程/函数/触发器/事件（使用自定义分隔符）
 func (v *Validator) importRoutine(ctx context.Context, container *Container, routineType, name, definition string) error {
 	// 使用 $$ 作为分隔符来执行包含分号的语句
 	sql := definition + "\n$$"
@@ -315,8 +491,9 @@ func (v *Validator) importRoutine(ctx context.Context, container *Container, rou
 	return nil
 }
 
-// compareSchemaInContainer 在容器中比较Schema
-func (v *Validator) compareSchemaInContainer(ctx context.Context, container *Container, expectedSchema *extractor.DatabaseSchema) (bool, []string) {
+// compareSchemaInContainer 提取容器中升级后的Schema，与期望Schema做完整对比
+// 返回从容器当前状态到期望状态仍需的差异，为空表示脚本已完全收敛
+func (v *Validator) compareSchemaInContainer(ctx context.Context, container *Container, expectedSchema *extractor.DatabaseSchema, ignoreRules config.IgnoreConfig) (*diff.SchemaDiff, error) {
 	// 创建提取器连接到容器
 	env := &config.Environment{
 		Host:     container.Host,
@@ -329,37 +506,88 @@ func (v *Validator) compareSchemaInContainer(ctx context.Context, container *Con
 
 	ext, err := extractor.NewMySQLExtractor(env)
 	if err != nil {
-		return false, []string{"创建提取器失败: " + err.Error()}
+		return nil, fmt.Errorf("创建提取器失败: %w", err)
 	}
 	defer ext.Close()
 
 	if err := ext.Connect(ctx); err != nil {
-		return false, []string{"连接容器数据库失败: " + err.Error()}
+		return nil, fmt.Errorf("连接容器数据库失败: %w", err)
 	}
 
 	// 提取当前Schema
-	opts := extractor.DefaultExtractOptions()
-	currentSchema, err := ext.ExtractSchema(ctx, opts)
+	currentSchema, err := ext.ExtractSchema(ctx, extractor.DefaultExtractOptions())
 	if err != nil {
-		return false, []string{"提取Schema失败: " + err.Error()}
+		return nil, fmt.Errorf("提取Schema失败: %w", err)
+	}
+
+	// 视图定义中带有库名限定，统一为期望Schema的库名，避免因容器库名不同产生差异
+	if expectedSchema.Database != "" && expectedSchema.Database != env.Database {
+		for _, view := range currentSchema.Views {
+			view.Definition = strings.ReplaceAll(view.Definition,
+				"`"+env.Database+"`.", "`"+expectedSchema.Database+"`.")
+		}
 	}
 
-	// 简单比较表数量
-	var diffs []string
+	// 升级后不应再出现重命名，关闭启发式识别以免掩盖残留的新增/删除
+	engine := diff.NewDiffEngine(ignoreRules)
+	engine.SetRenameRules(config.RenameConfig{DisableHeuristic: true})
+	return engine.Compare(expectedSchema, currentSchema), nil
+}
 
-	if len(currentSchema.Tables) != len(expectedSchema.Tables) {
-		diffs = append(diffs, fmt.Sprintf("表数量不匹配: 期望 %d, 实际 %d",
-			len(expectedSchema.Tables), len(currentSchema.Tables)))
+// describeSchemaDiff 将差异展开为逐项说明
+func describeSchemaDiff(schemaDiff *diff.SchemaDiff) []string {
+	var lines []string
+	add := func(kind, name string, diffType diff.DiffType, description string) {
+		lines = append(lines, fmt.Sprintf("%s %s [%s] %s", kind, name, diffType, description))
 	}
 
-	// 检查每个表
-	for tableName := range expectedSchema.Tables {
-		if _, exists := currentSchema.Tables[tableName]; !exists {
-			diffs = append(diffs, fmt.Sprintf("缺少表: %s", tableName))
+	for _, td := range schemaDiff.TableDiffs {
+		add("表", td.TableName, td.DiffType, td.Description)
+		for _, cd := range td.ColumnDiffs {
+			add("  列", td.TableName+"."+cd.ColumnName, cd.DiffType, describeChanges(cd.Changes))
+		}
+		for _, id := range td.IndexDiffs {
+			add("  索引", td.TableName+"."+id.IndexName, id.DiffType, id.Description)
+		}
+		for _, fkd := range td.FKeyDiffs {
+			add("  外键", td.TableName+"."+fkd.FKeyName, fkd.DiffType, fkd.Description)
+		}
+		for _, ckd := range td.CheckDiffs {
+			add("  CHECK", td.TableName+"."+ckd.CheckName, ckd.DiffType, ckd.Description)
 		}
+		if len(td.TableProps) > 0 {
+			add("  表属性", td.TableName, diff.DiffTypeModified, describeChanges(td.TableProps))
+		}
+		if td.PartitionDiff != nil {
+			add("  分区", td.TableName, td.PartitionDiff.DiffType, td.PartitionDiff.Description)
+		}
+	}
+	for _, vd := range schemaDiff.ViewDiffs {
+		add("视图", vd.ViewName, vd.DiffType, vd.Description)
+	}
+	for _, pd := range schemaDiff.ProcDiffs {
+		add("存储过程", pd.ProcName, pd.DiffType, pd.Description)
+	}
+	for _, fd := range schemaDiff.FuncDiffs {
+		add("函数", fd.FuncName, fd.DiffType, fd.Description)
+	}
+	for _, td := range schemaDiff.TriggerDiffs {
+		add("触发器", td.TriggerName, td.DiffType, td.Description)
+	}
+	for _, ed := range schemaDiff.EventDiffs {
+		add("事件", ed.EventName, ed.DiffType, ed.Description)
 	}
 
-	return len(diffs) == 0, diffs
+	return lines
+}
+
+// describeChanges 将属性变更格式化为 "属性: 旧值 -> 新值" 列表
+func describeChanges(changes []diff.PropertyDiff) string {
+	parts := make([]string, 0, len(changes))
+	for _, change := range changes {
+		parts = append(parts, fmt.Sprintf("%s: %s -> %s", change.Property, change.OldValue, change.NewValue))
+	}
+	return strings.Join(parts, "; ")
 }
 
 // logStep 记录步骤日志
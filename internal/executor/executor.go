@@ -0,0 +1,223 @@
+package executor
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/extractor"
+	"github.com/starvpn/schemapatch/internal/sqlgen"
+)
+
+// ErrConfirmationRequired 生产环境未确认
+var ErrConfirmationRequired = errors.New("生产环境执行需要确认")
+
+// ConfirmationName 返回在生产环境执行时需要确认的名称：目标数据库名，多库环境没有数据库名时为环境名
+func ConfirmationName(env *config.Environment) string {
+	if env.Database != "" {
+		return env.Database
+	}
+	return env.Name
+}
+
+// Options 执行选项
+type Options struct {
+	Confirm     string                              // 生产环境必须填写 ConfirmationName 以确认执行
+	Resume      bool                                // 跳过已成功的语句，从上次失败处继续
+	JournalFile string                              // 执行记录文件，为空时写入目标库的 schemapatch_journal 表（多库环境必须指定）
+	OnStatement func(entry JournalEntry, total int) // 每条语句执行后回调
+}
+
+// Result 执行结果
+type Result struct {
+	ScriptID string        `json:"script_id"`
+	Total    int           `json:"total"`
+	Executed int           `json:"executed"`
+	Skipped  int           `json:"skipped"` // 续跑时跳过的已成功语句
+	Failed   *JournalEntry `json:"failed,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Executor 在目标环境上逐条执行迁移脚本
+type Executor struct {
+	env *config.Environment
+	ext *extractor.MySQLExtractor
+}
+
+// NewExecutor 创建执行器
+func NewExecutor(env *config.Environment) *Executor {
+	return &Executor{env: env}
+}
+
+// Connect 连接目标环境
+func (x *Executor) Connect(ctx context.Context) error {
+	ext, err := extractor.NewMySQLExtractor(x.env)
+	if err != nil {
+		return err
+	}
+	if err := ext.Connect(ctx); err != nil {
+		return err
+	}
+	x.ext = ext
+	return nil
+}
+
+// DB 返回目标环境的连接，未连接时为 nil
+func (x *Executor) DB() *sql.DB {
+	if x.ext == nil {
+		return nil
+	}
+	return x.ext.DB()
+}
+
+// Close 关闭连接
+func (x *Executor) Close() error {
+	if x.ext != nil {
+		return x.ext.Close()
+	}
+	return nil
+}
+
+// Apply 逐条执行脚本语句并记录结果，遇到第一条失败的语句即停止
+func (x *Executor) Apply(ctx context.Context, script *sqlgen.MigrationScript, options Options) (*Result, error) {
+	if x.DB() == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+	if x.env.Type == config.EnvTypeProd {
+		name := ConfirmationName(x.env)
+		if name == "" {
+			return nil, fmt.Errorf("%w: 环境没有数据库名和环境名，无法确认", ErrConfirmationRequired)
+		}
+		if options.Confirm != name {
+			return nil, fmt.Errorf("%w: 请确认 %s", ErrConfirmationRequired, name)
+		}
+	}
+	for i, stmt := range script.Statements {
+		if stmt.OnlineCommand != "" {
+			return nil, fmt.Errorf("第 %d 条语句需要通过在线变更工具执行: %s", i+1, stmt.OnlineCommand)
+		}
+	}
+
+	var journal Journal
+	switch {
+	case options.JournalFile != "":
+		journal = NewFileJournal(options.JournalFile)
+	case x.env.Database == "":
+		// 多库环境的连接没有默认数据库，无法确定执行记录表写入哪个库
+		return nil, fmt.Errorf("环境 %s 没有默认数据库，无法在目标库中写入执行记录，请指定执行记录文件（-journal）", x.env.Name)
+	default:
+		journal = NewTableJournal(x.DB())
+	}
+	if err := journal.Init(ctx); err != nil {
+		return nil, err
+	}
+
+	result := &Result{ScriptID: ScriptID(script), Total: len(script.Statements)}
+	entries, err := journal.Load(ctx, result.ScriptID)
+	if err != nil {
+		return nil, err
+	}
+
+	succeeded := 0
+	for seq, entry := range entries {
+		if entry.Status == StatusSuccess && seq <= len(script.Statements) {
+			succeeded++
+		}
+	}
+	if len(entries) > 0 && succeeded < result.Total && !options.Resume {
+		return nil, fmt.Errorf("脚本 %s 已有执行记录（%d/%d 条成功），请使用续跑从失败的语句继续",
+			ShortID(result.ScriptID), succeeded, result.Total)
+	}
+
+	// 多库脚本以 USE 切换数据库，所有语句必须在同一个连接上执行
+	conn, err := x.DB().Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	for i, stmt := range script.Statements {
+		seq := i + 1
+		checksum := Checksum(stmt.SQL)
+		if entry, ok := entries[seq]; ok && entry.Status == StatusSuccess && entry.Checksum == checksum {
+			// 续跑时使用新的连接，仍需恢复该语句之后的数据库和会话设置（如 sql_mode）
+			if stmt.Operation == "USE" || stmt.Operation == "SET" {
+				if _, err := conn.ExecContext(ctx, strings.TrimSuffix(strings.TrimSpace(stmt.SQL), ";")); err != nil {
+					return result, fmt.Errorf("第 %d 条语句执行失败: %w", seq, err)
+				}
+			}
+			result.Skipped++
+			continue
+		}
+
+		entry := JournalEntry{
+			ScriptID:   result.ScriptID,
+			Version:    script.Version,
+			Seq:        seq,
+			Checksum:   checksum,
+			ObjectType: stmt.ObjectType,
+			ObjectName: stmt.ObjectName,
+			Operation:  stmt.Operation,
+			SQL:        stmt.SQL,
+			Status:     StatusSuccess,
+			ExecutedAt: time.Now(),
+		}
+
+		// DDL 会隐式提交，逐条执行并记录，失败后可从该语句继续
+		began := time.Now()
+		_, execErr := conn.ExecContext(ctx, strings.TrimSuffix(strings.TrimSpace(stmt.SQL), ";"))
+		entry.DurationMs = time.Since(began).Milliseconds()
+		if execErr != nil {
+			entry.Status = StatusFailed
+			entry.Error = execErr.Error()
+		}
+
+		if err := journal.Record(ctx, entry); err != nil {
+			return result, err
+		}
+		if options.OnStatement != nil {
+			options.OnStatement(entry, result.Total)
+		}
+
+		if execErr != nil {
+			result.Failed = &entry
+			return result, fmt.Errorf("第 %d 条语句执行失败: %w", seq, execErr)
+		}
+		result.Executed++
+	}
+
+	return result, nil
+}
+
+// Checksum 计算语句的散列
+func Checksum(sql string) string {
+	sum := sha256.Sum256([]byte(sql))
+	return hex.EncodeToString(sum[:])
+}
+
+// ScriptID 根据全部语句计算脚本标识，相同内容的脚本得到相同标识
+func ScriptID(script *sqlgen.MigrationScript) string {
+	hash := sha256.New()
+	for _, stmt := range script.Statements {
+		hash.Write([]byte(Checksum(stmt.SQL)))
+		hash.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// ShortID 返回便于显示的脚本短标识（前 12 位）
+func ShortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
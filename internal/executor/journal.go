@@ -0,0 +1,192 @@
+package executor
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/config"
+)
+
+// Status 语句执行状态
+type Status string
+
+const (
+	StatusSuccess Status = "success"
+	StatusFailed  Status = "failed"
+)
+
+// JournalEntry 单条语句的执行记录
+type JournalEntry struct {
+	ScriptID   string    `json:"script_id"` // 脚本语句内容的散列，同一脚本重复执行时不变
+	Version    string    `json:"version"`
+	Seq        int       `json:"seq"` // 语句序号，从1开始
+	Checksum   string    `json:"checksum"`
+	ObjectType string    `json:"object_type"`
+	ObjectName string    `json:"object_name"`
+	Operation  string    `json:"operation"`
+	SQL        string    `json:"sql"`
+	Status     Status    `json:"status"`
+	DurationMs int64     `json:"duration_ms"`
+	Error      string    `json:"error,omitempty"`
+	ExecutedAt time.Time `json:"executed_at"`
+}
+
+// Journal 执行记录存储
+type Journal interface {
+	// Init 准备存储（建表或检查文件）
+	Init(ctx context.Context) error
+	// Load 返回脚本每条语句最近一次的执行记录
+	Load(ctx context.Context, scriptID string) (map[int]JournalEntry, error)
+	// Record 追加一条执行记录
+	Record(ctx context.Context, entry JournalEntry) error
+}
+
+// TableJournal 将执行记录写入目标库的 schemapatch_journal 表
+type TableJournal struct {
+	db *sql.DB
+}
+
+// NewTableJournal 创建表存储的执行记录
+func NewTableJournal(db *sql.DB) *TableJournal {
+	return &TableJournal{db: db}
+}
+
+// Init 创建执行记录表
+func (j *TableJournal) Init(ctx context.Context) error {
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` ("+
+		"`id` bigint NOT NULL AUTO_INCREMENT, "+
+		"`script_id` char(64) NOT NULL, "+
+		"`version` varchar(32) NOT NULL, "+
+		"`seq` int NOT NULL, "+
+		"`checksum` char(64) NOT NULL, "+
+		"`object_type` varchar(32) NOT NULL, "+
+		"`object_name` varchar(255) NOT NULL, "+
+		"`operation` varchar(32) NOT NULL, "+
+		"`sql_text` longtext NOT NULL, "+
+		"`status` varchar(16) NOT NULL, "+
+		"`duration_ms` bigint NOT NULL, "+
+		"`error` text, "+
+		"`executed_at` datetime(3) NOT NULL, "+
+		"PRIMARY KEY (`id`), "+
+		"KEY `idx_script_seq` (`script_id`, `seq`)"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", config.JournalTable)
+	if _, err := j.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("创建执行记录表失败: %w", err)
+	}
+	return nil
+}
+
+// Load 读取脚本的执行记录
+func (j *TableJournal) Load(ctx context.Context, scriptID string) (map[int]JournalEntry, error) {
+	query := fmt.Sprintf("SELECT `script_id`, `version`, `seq`, `checksum`, `object_type`, `object_name`, `operation`, "+
+		"`sql_text`, `status`, `duration_ms`, IFNULL(`error`, ''), `executed_at` FROM `%s` WHERE `script_id` = ? ORDER BY `id`",
+		config.JournalTable)
+	rows, err := j.db.QueryContext(ctx, query, scriptID)
+	if err != nil {
+		return nil, fmt.Errorf("读取执行记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make(map[int]JournalEntry)
+	for rows.Next() {
+		var entry JournalEntry
+		if err := rows.Scan(&entry.ScriptID, &entry.Version, &entry.Seq, &entry.Checksum, &entry.ObjectType,
+			&entry.ObjectName, &entry.Operation, &entry.SQL, &entry.Status, &entry.DurationMs, &entry.Error,
+			&entry.ExecutedAt); err != nil {
+			return nil, fmt.Errorf("读取执行记录失败: %w", err)
+		}
+		entries[entry.Seq] = entry
+	}
+	return entries, rows.Err()
+}
+
+// Record 写入执行记录
+func (j *TableJournal) Record(ctx context.Context, entry JournalEntry) error {
+	query := fmt.Sprintf("INSERT INTO `%s` (`script_id`, `version`, `seq`, `checksum`, `object_type`, `object_name`, "+
+		"`operation`, `sql_text`, `status`, `duration_ms`, `error`, `executed_at`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		config.JournalTable)
+	var errText interface{}
+	if entry.Error != "" {
+		errText = entry.Error
+	}
+	if _, err := j.db.ExecContext(ctx, query, entry.ScriptID, entry.Version, entry.Seq, entry.Checksum, entry.ObjectType,
+		entry.ObjectName, entry.Operation, entry.SQL, entry.Status, entry.DurationMs, errText, entry.ExecutedAt); err != nil {
+		return fmt.Errorf("写入执行记录失败: %w", err)
+	}
+	return nil
+}
+
+// FileJournal 将执行记录以 JSON Lines 格式追加到本地文件
+type FileJournal struct {
+	path string
+}
+
+// NewFileJournal 创建文件存储的执行记录
+func NewFileJournal(path string) *FileJournal {
+	return &FileJournal{path: path}
+}
+
+// Init 确认文件可写
+func (j *FileJournal) Init(ctx context.Context) error {
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开执行记录文件失败: %w", err)
+	}
+	return file.Close()
+}
+
+// Load 读取脚本的执行记录
+func (j *FileJournal) Load(ctx context.Context, scriptID string) (map[int]JournalEntry, error) {
+	file, err := os.Open(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[int]JournalEntry{}, nil
+		}
+		return nil, fmt.Errorf("读取执行记录失败: %w", err)
+	}
+	defer file.Close()
+
+	entries := make(map[int]JournalEntry)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("解析执行记录第 %d 行失败: %w", line, err)
+		}
+		if entry.ScriptID == scriptID {
+			entries[entry.Seq] = entry
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取执行记录失败: %w", err)
+	}
+	return entries, nil
+}
+
+// Record 追加执行记录
+func (j *FileJournal) Record(ctx context.Context, entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("序列化执行记录失败: %w", err)
+	}
+
+	file, err := os.OpenFile(j.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("写入执行记录失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("写入执行记录失败: %w", err)
+	}
+	return file.Sync()
+}
@@ -0,0 +1,359 @@
+package extractor
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/config"
+)
+
+// SchemaCacheVersion 当前缓存文件格式版本
+const SchemaCacheVersion = 1
+
+// SchemaCache 单个环境的Schema缓存
+// 记录提取时各表的 CREATE_TIME / UPDATE_TIME、列和索引等结构的指纹，以及存储过程、函数的 LAST_ALTERED，
+// 下次提取时只重新提取这些标记发生变化的对象
+type SchemaCache struct {
+	Version       int               `json:"version"`
+	Schema        *DatabaseSchema   `json:"schema"`
+	TableStamps   map[string]string `json:"table_stamps"`
+	RoutineStamps map[string]string `json:"routine_stamps"` // 键为 类型:名称
+	ExtractedAt   time.Time         `json:"extracted_at"`
+}
+
+// reusableObjects 增量提取时变更时间未变化、可以直接复用的对象
+type reusableObjects struct {
+	tables        map[string]*TableSchema
+	changedTables []string // 需要重新提取的表
+	procedures    map[string]*ProcedureSchema
+	functions     map[string]*FunctionSchema
+}
+
+// CachePath 返回环境在缓存目录中的缓存文件路径，以连接地址和库名区分
+func CachePath(dir string, env *config.Environment) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s@%s:%d/%s", env.Username, env.Host, env.Port, env.Database)))
+	name := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+			return r
+		}
+		return '_'
+	}, env.Database)
+	return filepath.Join(dir, fmt.Sprintf("%s_%x.json", name, sum[:6]))
+}
+
+// LoadSchemaCache 读取缓存文件，文件不存在时返回 nil
+func LoadSchemaCache(path string) (*SchemaCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取缓存文件失败: %w", err)
+	}
+
+	var cache SchemaCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return nil, fmt.Errorf("解析缓存文件失败: %w", err)
+	}
+	if cache.Version != SchemaCacheVersion || cache.Schema == nil {
+		return nil, nil
+	}
+	cache.Schema.ensureMaps()
+	return &cache, nil
+}
+
+// Save 写入缓存文件，先写临时文件再替换，避免中断时留下不完整的缓存
+func (c *SchemaCache) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建缓存目录失败: %w", err)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("序列化缓存失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入缓存文件失败: %w", err)
+	}
+	return nil
+}
+
+// ExtractSchemaCached 使用 cacheDir 中的缓存增量提取Schema，完成后更新缓存
+// 视图、触发器和事件每次只需一次查询，总是重新提取；缓存不存在或无法读取时完整提取
+func (e *MySQLExtractor) ExtractSchemaCached(ctx context.Context, options ExtractOptions, cacheDir string) (*DatabaseSchema, error) {
+	tableStamps, routineStamps, err := e.objectStamps(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("查询对象变更时间失败: %w", err)
+	}
+
+	path := CachePath(cacheDir, e.env)
+	var reuse *reusableObjects
+	if cache, err := LoadSchemaCache(path); err == nil && cache != nil {
+		reuse = &reusableObjects{
+			tables:     make(map[string]*TableSchema),
+			procedures: make(map[string]*ProcedureSchema),
+			functions:  make(map[string]*FunctionSchema),
+		}
+		for name, stamp := range tableStamps {
+			table := cache.Schema.Tables[name]
+			if table != nil && stamp != "" && cache.TableStamps[name] == stamp {
+				reuse.tables[name] = table
+			} else {
+				reuse.changedTables = append(reuse.changedTables, name)
+			}
+		}
+		for name, proc := range cache.Schema.Procedures {
+			key := "PROCEDURE:" + name
+			if stamp := routineStamps[key]; stamp != "" && cache.RoutineStamps[key] == stamp {
+				reuse.procedures[name] = proc
+			}
+		}
+		for name, fn := range cache.Schema.Functions {
+			key := "FUNCTION:" + name
+			if stamp := routineStamps[key]; stamp != "" && cache.RoutineStamps[key] == stamp {
+				reuse.functions[name] = fn
+			}
+		}
+	}
+
+	schema, err := e.extractSchema(ctx, options, reuse)
+	if err != nil {
+		return nil, err
+	}
+
+	// 缓存写入失败不影响本次提取结果
+	cache := newSchemaCache(schema, tableStamps, routineStamps)
+	_ = cache.Save(path)
+	return schema, nil
+}
+
+// newSchemaCache 创建缓存，建表语句或定义为空（SHOW CREATE 失败）的对象不缓存也不记录时间，下次重新提取
+func newSchemaCache(schema *DatabaseSchema, tableStamps, routineStamps map[string]string) *SchemaCache {
+	cached := *schema
+	cached.Tables = make(map[string]*TableSchema, len(schema.Tables))
+	cached.Procedures = make(map[string]*ProcedureSchema, len(schema.Procedures))
+	cached.Functions = make(map[string]*FunctionSchema, len(schema.Functions))
+	cache := &SchemaCache{
+		Version:       SchemaCacheVersion,
+		Schema:        &cached,
+		TableStamps:   make(map[string]string, len(tableStamps)),
+		RoutineStamps: make(map[string]string, len(routineStamps)),
+		ExtractedAt:   time.Now(),
+	}
+
+	for name, table := range schema.Tables {
+		if table.CreateSQL != "" {
+			cached.Tables[name] = table
+		}
+	}
+	for name, stamp := range tableStamps {
+		if table := schema.Tables[name]; table == nil || table.CreateSQL != "" {
+			cache.TableStamps[name] = stamp
+		}
+	}
+
+	for name, proc := range schema.Procedures {
+		if proc.Definition != "" {
+			cached.Procedures[name] = proc
+		}
+	}
+	for name, fn := range schema.Functions {
+		if fn.Definition != "" {
+			cached.Functions[name] = fn
+		}
+	}
+	for key, stamp := range routineStamps {
+		routineType, name, _ := strings.Cut(key, ":")
+		if routineType == "PROCEDURE" && schema.Procedures[name] != nil && schema.Procedures[name].Definition == "" ||
+			routineType == "FUNCTION" && schema.Functions[name] != nil && schema.Functions[name].Definition == "" {
+			continue
+		}
+		cache.RoutineStamps[key] = stamp
+	}
+	return cache
+}
+
+// extractChangedTables 只重新提取变更时间变化的表，其余表复用缓存
+func (e *MySQLExtractor) extractChangedTables(ctx context.Context, concurrency int, reuse *reusableObjects) (map[string]*TableSchema, error) {
+	tables := make(map[string]*TableSchema, len(reuse.tables)+len(reuse.changedTables))
+	for name, table := range reuse.tables {
+		tables[name] = table
+	}
+	if len(reuse.changedTables) == 0 {
+		return tables, nil
+	}
+
+	changed, err := e.extractTables(ctx, concurrency, reuse.changedTables)
+	if err != nil {
+		return nil, err
+	}
+	for name, table := range changed {
+		tables[name] = table
+	}
+	return tables, nil
+}
+
+// objectStamps 查询各表和存储过程、函数的变更标记，返回 表名 -> 标记 和 类型:名称 -> 标记
+// 表的标记包含变更时间和结构指纹：部分 ALTER TABLE（如 INSTANT 加列、修改默认值）不会更新 CREATE_TIME，
+// 因此同时比较 COLUMNS、STATISTICS 和 TABLE_CONSTRAINTS 中的行。时间为空的对象无法判断是否变化，每次都重新提取
+func (e *MySQLExtractor) objectStamps(ctx context.Context) (map[string]string, map[string]string, error) {
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+
+	// MySQL 8.0 默认缓存 information_schema 中的表时间，关闭缓存以读取最新值；5.7 没有该变量，忽略错误
+	conn.ExecContext(ctx, "SET SESSION information_schema_stats_expiry = 0")
+
+	rows, err := conn.QueryContext(ctx, `
+		SELECT
+			TABLE_NAME, CREATE_TIME, UPDATE_TIME, ENGINE, TABLE_COLLATION, TABLE_COMMENT
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'
+	`, e.env.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	fingerprints, err := tableFingerprints(ctx, conn, e.env.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tableStamps := make(map[string]string)
+	for rows.Next() {
+		var name string
+		var created, updated sql.NullTime
+		var engine, collation, comment sql.NullString
+		if err := rows.Scan(&name, &created, &updated, &engine, &collation, &comment); err != nil {
+			return nil, nil, err
+		}
+		if created.Valid {
+			tableStamps[name] = fmt.Sprintf("%s|%s|%s|%s|%s|%s", formatStamp(created), formatStamp(updated), engine.String, collation.String, comment.String, fingerprints[name])
+		} else {
+			tableStamps[name] = ""
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	routineRows, err := conn.QueryContext(ctx, `
+		SELECT
+			ROUTINE_TYPE, ROUTINE_NAME, CREATED, LAST_ALTERED
+		FROM information_schema.ROUTINES
+		WHERE ROUTINE_SCHEMA = ?
+	`, e.env.Database)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer routineRows.Close()
+
+	routineStamps := make(map[string]string)
+	for routineRows.Next() {
+		var routineType, name string
+		var created, altered sql.NullTime
+		if err := routineRows.Scan(&routineType, &name, &created, &altered); err != nil {
+			return nil, nil, err
+		}
+		if altered.Valid {
+			routineStamps[routineType+":"+name] = formatStamp(created) + "|" + formatStamp(altered)
+		}
+	}
+	return tableStamps, routineStamps, routineRows.Err()
+}
+
+// fingerprintQueries 计算表结构指纹的查询，第一列为表名，按表名排序
+var fingerprintQueries = []string{
+	`SELECT TABLE_NAME, COLUMN_NAME, ORDINAL_POSITION, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT, EXTRA,
+		CHARACTER_SET_NAME, COLLATION_NAME, COLUMN_COMMENT, GENERATION_EXPRESSION
+	FROM information_schema.COLUMNS
+	WHERE TABLE_SCHEMA = ?
+	ORDER BY TABLE_NAME, ORDINAL_POSITION`,
+	`SELECT TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX, COLUMN_NAME, NON_UNIQUE, SUB_PART, INDEX_TYPE, INDEX_COMMENT
+	FROM information_schema.STATISTICS
+	WHERE TABLE_SCHEMA = ?
+	ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`,
+	`SELECT TABLE_NAME, CONSTRAINT_NAME, CONSTRAINT_TYPE
+	FROM information_schema.TABLE_CONSTRAINTS
+	WHERE TABLE_SCHEMA = ?
+	ORDER BY TABLE_NAME, CONSTRAINT_NAME`,
+}
+
+// tableFingerprints 按表汇总列、索引和约束的元数据并计算散列，返回 表名 -> 指纹
+// 每个查询只扫描一次整个库，比逐表 SHOW CREATE TABLE 开销小得多
+func tableFingerprints(ctx context.Context, conn *sql.Conn, database string) (map[string]string, error) {
+	hashes := make(map[string]hash.Hash)
+	for _, query := range fingerprintQueries {
+		if err := hashTableRows(ctx, conn, query, database, hashes); err != nil {
+			return nil, err
+		}
+	}
+
+	fingerprints := make(map[string]string, len(hashes))
+	for name, h := range hashes {
+		fingerprints[name] = fmt.Sprintf("%x", h.Sum(nil)[:8])
+	}
+	return fingerprints, nil
+}
+
+// hashTableRows 将查询结果逐行写入对应表的散列，NULL 与空字符串区分开
+func hashTableRows(ctx context.Context, conn *sql.Conn, query, database string, hashes map[string]hash.Hash) error {
+	rows, err := conn.QueryContext(ctx, query, database)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		h := hashes[values[0].String]
+		if h == nil {
+			h = sha256.New()
+			hashes[values[0].String] = h
+		}
+		for _, v := range values[1:] {
+			if v.Valid {
+				fmt.Fprintf(h, "%q\x1f", v.String)
+			} else {
+				h.Write([]byte("NULL\x1f"))
+			}
+		}
+		h.Write([]byte("\n"))
+	}
+	return rows.Err()
+}
+
+// formatStamp 格式化变更时间，NULL 为空字符串
+func formatStamp(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format(time.RFC3339Nano)
+}
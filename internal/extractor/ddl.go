@@ -0,0 +1,237 @@
+package extractor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DDLExtractor 从 .sql DDL 文件解析Schema的提取器
+// 路径可以是单个文件，也可以是目录（按路径顺序读取其中所有 .sql 文件）
+type DDLExtractor struct {
+	path     string
+	database string
+	schema   *DatabaseSchema
+	warnings []string
+}
+
+// NewDDLExtractor 创建DDL文件提取器
+func NewDDLExtractor(path, database string) *DDLExtractor {
+	if database == "" {
+		database = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return &DDLExtractor{path: path, database: database}
+}
+
+// Connect 读取并解析DDL文件
+func (e *DDLExtractor) Connect(ctx context.Context) error {
+	files, err := sqlFiles(e.path)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%s 中没有 .sql 文件", e.path)
+	}
+
+	loader := newDDLLoader(e.database)
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("读取DDL文件失败: %w", err)
+		}
+		if err := loader.load(string(data)); err != nil {
+			return fmt.Errorf("解析 %s 失败: %w", file, err)
+		}
+	}
+
+	schema, err := loader.finish()
+	if err != nil {
+		return fmt.Errorf("解析 %s 失败: %w", e.path, err)
+	}
+
+	for _, warning := range loader.warnings {
+		zap.S().Warnf("DDL解析: %s", warning)
+	}
+
+	e.schema = schema
+	e.warnings = loader.warnings
+	return nil
+}
+
+// sqlFiles 返回路径下按名称排序的 .sql 文件
+func sqlFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("DDL路径不可用: %w", err)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(file), ".sql") {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("扫描DDL目录失败: %w", err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// Warnings 返回解析时跳过的语句说明
+func (e *DDLExtractor) Warnings() []string {
+	return e.warnings
+}
+
+// Close 释放解析结果
+func (e *DDLExtractor) Close() error {
+	e.schema = nil
+	return nil
+}
+
+// TestConnection 检查DDL路径是否可读
+func (e *DDLExtractor) TestConnection(ctx context.Context) error {
+	files, err := sqlFiles(e.path)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%s 中没有 .sql 文件", e.path)
+	}
+	return nil
+}
+
+// GetServerVersion DDL文件没有服务器版本
+func (e *DDLExtractor) GetServerVersion(ctx context.Context) (string, error) {
+	return "ddl", nil
+}
+
+// GetServerVariables DDL文件没有服务器变量
+func (e *DDLExtractor) GetServerVariables(ctx context.Context) (map[string]string, error) {
+	return map[string]string{}, nil
+}
+
+// ExtractSchema 返回解析得到的Schema，各类对象的提取错误合并后返回
+func (e *DDLExtractor) ExtractSchema(ctx context.Context, options ExtractOptions) (*DatabaseSchema, error) {
+	if e.schema == nil {
+		return nil, fmt.Errorf("DDL文件尚未解析")
+	}
+
+	schema := NewDatabaseSchema(e.schema.Database)
+	schema.Charset = e.schema.Charset
+	schema.Collation = e.schema.Collation
+
+	var errs []error
+	var err error
+	if options.IncludeTables {
+		if schema.Tables, err = e.ExtractTables(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("提取表失败: %w", err))
+		}
+	}
+	if options.IncludeViews {
+		if schema.Views, err = e.ExtractViews(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("提取视图失败: %w", err))
+		}
+	}
+	if options.IncludeProcedures {
+		if schema.Procedures, err = e.ExtractProcedures(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("提取存储过程失败: %w", err))
+		}
+	}
+	if options.IncludeFunctions {
+		if schema.Functions, err = e.ExtractFunctions(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("提取函数失败: %w", err))
+		}
+	}
+	if options.IncludeTriggers {
+		if schema.Triggers, err = e.ExtractTriggers(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("提取触发器失败: %w", err))
+		}
+	}
+	if options.IncludeEvents {
+		if schema.Events, err = e.ExtractEvents(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("提取事件失败: %w", err))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return schema, nil
+}
+
+// ExtractTables 返回解析得到的表
+func (e *DDLExtractor) ExtractTables(ctx context.Context, tableNames ...string) (map[string]*TableSchema, error) {
+	if e.schema == nil {
+		return nil, fmt.Errorf("DDL文件尚未解析")
+	}
+	if len(tableNames) == 0 {
+		return e.schema.Tables, nil
+	}
+
+	tables := make(map[string]*TableSchema)
+	for _, name := range tableNames {
+		if table, ok := e.schema.Tables[name]; ok {
+			tables[name] = table
+		}
+	}
+	return tables, nil
+}
+
+// ExtractViews 返回解析得到的视图
+func (e *DDLExtractor) ExtractViews(ctx context.Context) (map[string]*ViewSchema, error) {
+	if e.schema == nil {
+		return nil, fmt.Errorf("DDL文件尚未解析")
+	}
+	return e.schema.Views, nil
+}
+
+// ExtractProcedures 返回解析得到的存储过程
+func (e *DDLExtractor) ExtractProcedures(ctx context.Context) (map[string]*ProcedureSchema, error) {
+	if e.schema == nil {
+		return nil, fmt.Errorf("DDL文件尚未解析")
+	}
+	return e.schema.Procedures, nil
+}
+
+// ExtractFunctions 返回解析得到的函数
+func (e *DDLExtractor) ExtractFunctions(ctx context.Context) (map[string]*FunctionSchema, error) {
+	if e.schema == nil {
+		return nil, fmt.Errorf("DDL文件尚未解析")
+	}
+	return e.schema.Functions, nil
+}
+
+// ExtractTriggers 返回解析得到的触发器
+func (e *DDLExtractor) ExtractTriggers(ctx context.Context) (map[string]*TriggerSchema, error) {
+	if e.schema == nil {
+		return nil, fmt.Errorf("DDL文件尚未解析")
+	}
+	return e.schema.Triggers, nil
+}
+
+// ExtractEvents 返回解析得到的事件
+func (e *DDLExtractor) ExtractEvents(ctx context.Context) (map[string]*EventSchema, error) {
+	if e.schema == nil {
+		return nil, fmt.Errorf("DDL文件尚未解析")
+	}
+	return e.schema.Events, nil
+}
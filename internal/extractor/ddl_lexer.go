@@ -0,0 +1,288 @@
+package extractor
+
+import (
+	"strings"
+)
+
+// ddlTokenKind DDL词法单元类型
+type ddlTokenKind int
+
+const (
+	tokenWord   ddlTokenKind = iota // 关键字或未加引号的标识符
+	tokenQuoted                     // 反引号标识符
+	tokenString                     // 字符串常量
+	tokenNumber                     // 数字常量
+	tokenPunct                      // 标点符号
+)
+
+// ddlToken DDL词法单元
+type ddlToken struct {
+	kind  ddlTokenKind
+	value string // 去掉引号、处理转义后的值
+	start int    // 在语句中的起始偏移
+	end   int    // 结束偏移（不含）
+}
+
+// SplitSQLStatements 按分隔符拆分SQL脚本
+// 支持 mysql 客户端的 DELIMITER 指令，字符串、标识符和注释中的分隔符不会被拆分
+func SplitSQLStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	delimiter := ";"
+
+	flush := func() {
+		stmt := strings.TrimSpace(current.String())
+		if stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	n := len(script)
+	i := 0
+	lineStart := true
+	for i < n {
+		// DELIMITER 指令只能出现在语句开头的行首（之前可以有注释行）
+		if lineStart && isBlankOrComment(current.String()) {
+			j := i
+			for j < n && (script[j] == ' ' || script[j] == '\t') {
+				j++
+			}
+			if j+9 < n && strings.EqualFold(script[j:j+9], "DELIMITER") && isSpaceByte(script[j+9]) {
+				lineEnd := strings.IndexByte(script[j:], '\n')
+				if lineEnd < 0 {
+					lineEnd = n
+				} else {
+					lineEnd += j
+				}
+				if d := strings.TrimSpace(script[j+9 : lineEnd]); d != "" {
+					delimiter = d
+				}
+				current.Reset()
+				i = lineEnd + 1
+				continue
+			}
+		}
+		lineStart = false
+
+		c := script[i]
+		switch {
+		case strings.HasPrefix(script[i:], delimiter):
+			flush()
+			i += len(delimiter)
+			continue
+
+		case c == '\'' || c == '"' || c == '`':
+			end := scanQuoted(script, i, c)
+			current.WriteString(script[i:end])
+			i = end
+			continue
+
+		case c == '#' || isLineComment(script, i):
+			end := strings.IndexByte(script[i:], '\n')
+			if end < 0 {
+				end = n
+			} else {
+				end += i
+			}
+			current.WriteString(script[i:end])
+			i = end
+			continue
+
+		case c == '/' && i+1 < n && script[i+1] == '*':
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				end = n
+			} else {
+				end += i + 4
+			}
+			current.WriteString(script[i:end])
+			i = end
+			continue
+		}
+
+		current.WriteByte(c)
+		if c == '\n' {
+			lineStart = true
+		}
+		i++
+	}
+	flush()
+
+	return statements
+}
+
+// isBlankOrComment 判断文本是否只包含空白和单行注释
+func isBlankOrComment(text string) bool {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") && !isLineComment(line, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenizeDDL 将单条语句切分为词法单元
+// 普通注释会被跳过，可执行注释 /*!40101 ... */ 中的内容按正文处理
+func tokenizeDDL(src string) []ddlToken {
+	var tokens []ddlToken
+	n := len(src)
+	execComments := 0
+
+	i := 0
+	for i < n {
+		c := src[i]
+		switch {
+		case isSpaceByte(c):
+			i++
+
+		case c == '#' || isLineComment(src, i):
+			for i < n && src[i] != '\n' {
+				i++
+			}
+
+		case c == '/' && i+1 < n && src[i+1] == '*':
+			if i+2 < n && src[i+2] == '!' {
+				i += 3
+				for i < n && src[i] >= '0' && src[i] <= '9' {
+					i++
+				}
+				execComments++
+				continue
+			}
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = n
+			} else {
+				i += end + 4
+			}
+
+		case c == '*' && execComments > 0 && i+1 < n && src[i+1] == '/':
+			execComments--
+			i += 2
+
+		case c == '`':
+			end := scanQuoted(src, i, c)
+			tokens = append(tokens, ddlToken{kind: tokenQuoted, value: unquote(src[i:end]), start: i, end: end})
+			i = end
+
+		case c == '\'' || c == '"':
+			end := scanQuoted(src, i, c)
+			tokens = append(tokens, ddlToken{kind: tokenString, value: unquote(src[i:end]), start: i, end: end})
+			i = end
+
+		case isWordByte(c) || (c == '.' && i+1 < n && isDigitByte(src[i+1]) && !lastIsWord(tokens, i)):
+			j := i
+			numeric := true
+			for j < n && (isWordByte(src[j]) || (src[j] == '.' && numeric && j+1 < n && isDigitByte(src[j+1]))) {
+				if !isDigitByte(src[j]) && src[j] != '.' {
+					numeric = false
+				}
+				j++
+			}
+			kind := tokenWord
+			if numeric {
+				kind = tokenNumber
+			}
+			tokens = append(tokens, ddlToken{kind: kind, value: src[i:j], start: i, end: j})
+			i = j
+
+		default:
+			tokens = append(tokens, ddlToken{kind: tokenPunct, value: string(c), start: i, end: i + 1})
+			i++
+		}
+	}
+
+	return tokens
+}
+
+// scanQuoted 返回从 start 处引号开始的字符串结束位置（不含）
+func scanQuoted(src string, start int, quote byte) int {
+	n := len(src)
+	i := start + 1
+	for i < n {
+		c := src[i]
+		if c == '\\' && quote != '`' {
+			i += 2
+			continue
+		}
+		if c == quote {
+			if i+1 < n && src[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		}
+		i++
+	}
+	return n
+}
+
+// unquote 去掉引号并处理转义
+func unquote(s string) string {
+	if len(s) < 2 {
+		return s
+	}
+	quote := s[0]
+	body := s[1:]
+	if body[len(body)-1] == quote {
+		body = body[:len(body)-1]
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		c := body[i]
+		if c == quote && i+1 < len(body) && body[i+1] == quote {
+			b.WriteByte(quote)
+			i++
+			continue
+		}
+		if c == '\\' && quote != '`' && i+1 < len(body) {
+			i++
+			switch body[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '0':
+				b.WriteByte(0)
+			default:
+				b.WriteByte(body[i])
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// isLineComment 检查是否为 "-- " 单行注释
+func isLineComment(src string, i int) bool {
+	return i+1 < len(src) && src[i] == '-' && src[i+1] == '-' &&
+		(i+2 >= len(src) || isSpaceByte(src[i+2]))
+}
+
+// lastIsWord 检查紧邻的前一个词法单元是否为标识符（如 t.1 中的点号）
+func lastIsWord(tokens []ddlToken, pos int) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	return last.end == pos && (last.kind == tokenWord || last.kind == tokenQuoted)
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigitByte(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c == '$' || isDigitByte(c) ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
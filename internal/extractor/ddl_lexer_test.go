@@ -0,0 +1,160 @@
+package extractor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSQLStatements(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "分号分隔",
+			script: "CREATE TABLE a (id int);\nCREATE TABLE b (id int);\n",
+			want:   []string{"CREATE TABLE a (id int)", "CREATE TABLE b (id int)"},
+		},
+		{
+			name:   "字符串和标识符中的分号",
+			script: "INSERT INTO t VALUES ('a;b', \"c;d\");\nCREATE TABLE `x;y` (id int);",
+			want:   []string{"INSERT INTO t VALUES ('a;b', \"c;d\")", "CREATE TABLE `x;y` (id int)"},
+		},
+		{
+			name:   "转义的引号",
+			script: "SELECT 'it''s;', 'a\\';b';SELECT 1",
+			want:   []string{"SELECT 'it''s;', 'a\\';b'", "SELECT 1"},
+		},
+		{
+			name:   "注释中的分号",
+			script: "-- a; b\nSELECT 1 /* c; d */;\n# e; f\nSELECT 2;",
+			want:   []string{"-- a; b\nSELECT 1 /* c; d */", "# e; f\nSELECT 2"},
+		},
+		{
+			name: "DELIMITER 块",
+			script: "DELIMITER $$\n" +
+				"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END$$\n" +
+				"DELIMITER ;\n" +
+				"CREATE TABLE t (id int);",
+			want: []string{"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END", "CREATE TABLE t (id int)"},
+		},
+		{
+			name:   "注释之后的 DELIMITER",
+			script: "-- routines\ndelimiter //\nCREATE FUNCTION f() RETURNS int RETURN 1//\n",
+			want:   []string{"CREATE FUNCTION f() RETURNS int RETURN 1"},
+		},
+		{
+			name:   "语句中间的 DELIMITER 不是指令",
+			script: "SELECT 1 AS x,\nDELIMITER FROM t;",
+			want:   []string{"SELECT 1 AS x,\nDELIMITER FROM t"},
+		},
+		{
+			name:   "空白和空语句",
+			script: " ;\n;\n  ",
+			want:   nil,
+		},
+		{
+			name:   "未闭合的字符串",
+			script: "SELECT 'abc; SELECT 2",
+			want:   []string{"SELECT 'abc; SELECT 2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitSQLStatements(tt.script); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitSQLStatements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeDDL(t *testing.T) {
+	type token struct {
+		kind  ddlTokenKind
+		value string
+	}
+	tests := []struct {
+		name string
+		src  string
+		want []token
+	}{
+		{
+			name: "关键字、标识符和标点",
+			src:  "CREATE TABLE `my table` (id int);",
+			want: []token{
+				{tokenWord, "CREATE"}, {tokenWord, "TABLE"}, {tokenQuoted, "my table"},
+				{tokenPunct, "("}, {tokenWord, "id"}, {tokenWord, "int"}, {tokenPunct, ")"}, {tokenPunct, ";"},
+			},
+		},
+		{
+			name: "反引号转义",
+			src:  "`a``b`",
+			want: []token{{tokenQuoted, "a`b"}},
+		},
+		{
+			name: "字符串转义",
+			src:  `'it''s' 'a\nb' "x\"y"`,
+			want: []token{{tokenString, "it's"}, {tokenString, "a\nb"}, {tokenString, `x"y`}},
+		},
+		{
+			name: "数字和限定名",
+			src:  "1.5 .5 t.c 10",
+			want: []token{
+				{tokenNumber, "1.5"}, {tokenNumber, ".5"},
+				{tokenWord, "t"}, {tokenPunct, "."}, {tokenWord, "c"}, {tokenNumber, "10"},
+			},
+		},
+		{
+			name: "跳过注释",
+			src:  "a -- comment\n b # hash\n /* block */ c",
+			want: []token{{tokenWord, "a"}, {tokenWord, "b"}, {tokenWord, "c"}},
+		},
+		{
+			name: "可执行注释按正文处理",
+			src:  "/*!40101 SET NAMES utf8 */",
+			want: []token{{tokenWord, "SET"}, {tokenWord, "NAMES"}, {tokenWord, "utf8"}},
+		},
+		{
+			name: "不是注释的减号",
+			src:  "a--1",
+			want: []token{{tokenWord, "a"}, {tokenPunct, "-"}, {tokenPunct, "-"}, {tokenNumber, "1"}},
+		},
+		{
+			name: "user@host",
+			src:  "`dev`@`%`",
+			want: []token{{tokenQuoted, "dev"}, {tokenPunct, "@"}, {tokenQuoted, "%"}},
+		},
+		{
+			name: "未闭合的引号读到末尾",
+			src:  "`abc",
+			want: []token{{tokenQuoted, "abc"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []token
+			for _, tok := range tokenizeDDL(tt.src) {
+				got = append(got, token{tok.kind, tok.value})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tokenizeDDL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeDDLOffsets(t *testing.T) {
+	src := "CREATE VIEW `v` AS SELECT 1"
+	for _, tok := range tokenizeDDL(src) {
+		text := src[tok.start:tok.end]
+		if tok.kind == tokenQuoted {
+			text = unquote(text)
+		}
+		if text != tok.value {
+			t.Errorf("token %q: offsets [%d,%d) cover %q", tok.value, tok.start, tok.end, src[tok.start:tok.end])
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package extractor
+
+import (
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/config"
+)
+
+// NormalizeOptions 规范化定义时的选项
+type NormalizeOptions struct {
+	Databases       []string // 去掉这些库名的限定（通常为两侧的当前库）
+	FoldIdentifiers bool     // 标识符不区分大小写，服务器 lower_case_table_names 不为 0 时使用
+}
+
+// NormalizeSQL 规范化视图、存储过程、触发器等定义，用于判断两个定义是否等价
+// 去掉注释、多余空白、DEFINER 子句、字符集前缀和 Databases 中库名的限定，标识符统一加反引号，
+// MySQL 改写视图时补充的同名别名（`t`.`id` AS `id`）也会去掉。未加引号的词（关键字、函数名和标识符）一律转为小写，
+// 只有加反引号的标识符保留大小写（lower_case_table_names=0 时表名区分大小写），FoldIdentifiers 时一起转为小写
+func NormalizeSQL(sql string, options NormalizeOptions) string {
+	tokens := tokenizeDDL(sql)
+	for len(tokens) > 0 && isPunctToken(tokens[len(tokens)-1], ";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+
+	var parts []string
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case isKeywordToken(tok, "DEFINER") && i+1 < len(tokens) && isPunctToken(tokens[i+1], "="):
+			i = skipDefiner(tokens, i+2) - 1
+			continue
+
+		case isIdentifierToken(tok) && i+2 < len(tokens) && isPunctToken(tokens[i+1], ".") &&
+			isIdentifierToken(tokens[i+2]) && containsString(options.Databases, tok.value):
+			// 当前库的限定，跳过库名和点号
+			i++
+			continue
+
+		case tok.kind == tokenWord && strings.HasPrefix(tok.value, "_") && i+1 < len(tokens) &&
+			tokens[i+1].kind == tokenString && tokens[i+1].start == tok.end:
+			continue
+
+		case isKeywordToken(tok, "AS") && i > 0 && i+1 < len(tokens) &&
+			isIdentifierToken(tokens[i-1]) && isIdentifierToken(tokens[i+1]) &&
+			identifierName(tokens[i-1], options.FoldIdentifiers) == identifierName(tokens[i+1], options.FoldIdentifiers) &&
+			(i+2 >= len(tokens) || !isPunctToken(tokens[i+2], "(")):
+			i++
+			continue
+		}
+
+		switch {
+		case tok.kind == tokenWord && (isSQLKeyword(tok.value) || isFunctionCall(tokens, i)):
+			parts = append(parts, strings.ToLower(tok.value))
+		case isIdentifierToken(tok):
+			parts = append(parts, "`"+identifierName(tok, options.FoldIdentifiers)+"`")
+		case tok.kind == tokenString:
+			parts = append(parts, "'"+strings.ReplaceAll(tok.value, "'", "''")+"'")
+		default:
+			parts = append(parts, tok.value)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// identifierName 返回规范化后的标识符名称
+// 未加引号的词无法可靠区分关键字和标识符，一律转为小写；加反引号的标识符只在 fold 时转为小写
+func identifierName(tok ddlToken, fold bool) string {
+	if fold || tok.kind == tokenWord {
+		return strings.ToLower(tok.value)
+	}
+	return tok.value
+}
+
+// isFunctionCall 判断未加引号的词是否为函数调用（紧跟左括号），INTO 之后的是表名
+func isFunctionCall(tokens []ddlToken, i int) bool {
+	if i+1 >= len(tokens) || !isPunctToken(tokens[i+1], "(") || tokens[i+1].start != tokens[i].end {
+		return false
+	}
+	return i == 0 || !isKeywordToken(tokens[i-1], "INTO")
+}
+
+// sqlKeywords 规范化时不加反引号的关键字和类型名，其余未加引号的词按标识符处理
+var sqlKeywords = toKeywordSet(`
+	select from where and or not xor null is in as on join inner left right outer cross natural straight_join using
+	group by order having limit offset union all distinct case when then else end exists like regexp between asc desc
+	with recursive interval true false div mod collate binary escape any some rollup window over partition
+	begin declare set return returns into values value insert update delete replace call if elseif while do loop
+	repeat until leave iterate open close fetch cursor continue exit handler for condition sqlexception sqlwarning
+	found signal resignal sqlstate message_text new old each row default
+	int integer bigint smallint tinyint mediumint decimal numeric float double real bit bool boolean char varchar
+	text tinytext mediumtext longtext date datetime timestamp time year json blob longblob varbinary signed unsigned
+	charset character current_timestamp current_date current_time current_user localtime localtimestamp
+	algorithm undefined merge temptable sql security invoker check option cascaded local lock share mode
+`)
+
+// toKeywordSet 将空白分隔的关键字转换为集合
+func toKeywordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		set[word] = true
+	}
+	return set
+}
+
+// isSQLKeyword 判断是否为关键字（不区分大小写）
+func isSQLKeyword(word string) bool {
+	return sqlKeywords[strings.ToLower(word)]
+}
+
+// skipDefiner 跳过 DEFINER = 之后的用户（user@host 或 CURRENT_USER），返回下一个词法单元的位置
+func skipDefiner(tokens []ddlToken, i int) int {
+	if i >= len(tokens) {
+		return i
+	}
+	if isKeywordToken(tokens[i], "CURRENT_USER") {
+		i++
+		if i+1 < len(tokens) && isPunctToken(tokens[i], "(") && isPunctToken(tokens[i+1], ")") {
+			i += 2
+		}
+		return i
+	}
+	i++
+	if i+1 < len(tokens) && isPunctToken(tokens[i], "@") {
+		i += 2
+	}
+	return i
+}
+
+// isKeywordToken 判断词法单元是否为指定关键字（不区分大小写）
+func isKeywordToken(tok ddlToken, keyword string) bool {
+	return tok.kind == tokenWord && strings.EqualFold(tok.value, keyword)
+}
+
+// isPunctToken 判断词法单元是否为指定标点
+func isPunctToken(tok ddlToken, punct string) bool {
+	return tok.kind == tokenPunct && tok.value == punct
+}
+
+// isIdentifierToken 判断词法单元是否可能是标识符
+func isIdentifierToken(tok ddlToken) bool {
+	return tok.kind == tokenWord || tok.kind == tokenQuoted
+}
+
+// containsString 判断 values 中是否包含 s
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v != "" && v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RoutineBody 返回存储过程/函数定义的主体，即名称、参数、RETURNS 和特性（COMMENT、SQL SECURITY 等）之后的部分
+// 参数和特性单独比较，主体不受其影响；无法解析时返回原始定义
+func RoutineBody(definition string) string {
+	p := newDDLParser(definition)
+	if !p.acceptWords("CREATE") {
+		return definition
+	}
+	for !p.eof() {
+		switch {
+		case p.acceptWords("DEFINER"):
+			p.skipEquals()
+			if _, err := p.definer(); err != nil {
+				return definition
+			}
+		case p.acceptWords("AGGREGATE"):
+		case p.isWord("PROCEDURE", "FUNCTION"):
+			isProcedure := p.isWord("PROCEDURE")
+			p.next()
+			header, err := p.parseRoutineHeader(isProcedure)
+			if err != nil {
+				return definition
+			}
+			if !isProcedure {
+				if !p.acceptWords("RETURNS") {
+					return definition
+				}
+				p.routineType()
+			}
+			p.parseCharacteristics(header)
+			return p.rest()
+		default:
+			return definition
+		}
+	}
+	return definition
+}
+
+// RewriteDefiner 替换 CREATE 语句中的 DEFINER 子句，definer 为 user@host 格式，为空时删除该子句
+// 语句中没有 DEFINER 且 definer 不为空时，在 SQL SECURITY 或对象类型关键字之前插入；不是 CREATE 语句时原样返回
+func RewriteDefiner(createSQL, definer string) string {
+	tokens := tokenizeDDL(createSQL)
+	if len(tokens) == 0 || !isKeywordToken(tokens[0], "CREATE") {
+		return createSQL
+	}
+
+	clause := ""
+	if definer != "" {
+		clause = "DEFINER=" + QuoteDefiner(definer) + " "
+	}
+	for i := 1; i < len(tokens); i++ {
+		tok := tokens[i]
+		if isKeywordToken(tok, "DEFINER") && i+1 < len(tokens) && isPunctToken(tokens[i+1], "=") {
+			end := len(createSQL)
+			if next := skipDefiner(tokens, i+2); next < len(tokens) {
+				end = tokens[next].start
+			}
+			return createSQL[:tok.start] + clause + createSQL[end:]
+		}
+		if isKeywordToken(tok, "SQL") || isKeywordToken(tok, "AGGREGATE") || isDefinedObjectKeyword(tok) {
+			if clause == "" {
+				return createSQL
+			}
+			return createSQL[:tok.start] + clause + createSQL[tok.start:]
+		}
+	}
+	return createSQL
+}
+
+// DefinerClause 按策略返回视图、触发器和事件 CREATE/ALTER 语句中的 DEFINER 子句（含末尾空格）
+// 未配置策略、strip 模式或源 DEFINER 为空时返回空字符串，由执行脚本的用户作为 DEFINER
+func DefinerClause(policy config.DefinerPolicy, definer string) string {
+	if !policy.Enabled() || definer == "" {
+		return ""
+	}
+	if definer = policy.Apply(definer); definer == "" {
+		return ""
+	}
+	return "DEFINER=" + QuoteDefiner(definer) + " "
+}
+
+// ApplyDefinerPolicy 按策略改写存储过程、函数完整定义中的 DEFINER
+// 未配置策略时原样返回；源 DEFINER 为空时只有 strip 模式会改写
+func ApplyDefinerPolicy(policy config.DefinerPolicy, definition, definer string) string {
+	if !policy.Enabled() || (definer == "" && policy.Mode != config.DefinerStrip) {
+		return definition
+	}
+	return RewriteDefiner(definition, policy.Apply(definer))
+}
+
+// QuoteDefiner 将 user@host 格式的 DEFINER 转为 `user`@`host`
+func QuoteDefiner(definer string) string {
+	definer = strings.NewReplacer("`", "", "'", "", "\"", "").Replace(definer)
+	i := strings.LastIndex(definer, "@")
+	if i < 0 {
+		return "`" + definer + "`"
+	}
+	return "`" + definer[:i] + "`@`" + definer[i+1:] + "`"
+}
+
+// isDefinedObjectKeyword 判断是否为可以指定 DEFINER 的对象类型关键字
+func isDefinedObjectKeyword(tok ddlToken) bool {
+	for _, keyword := range []string{"VIEW", "PROCEDURE", "FUNCTION", "TRIGGER", "EVENT"} {
+		if isKeywordToken(tok, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package extractor
+
+import (
+	"testing"
+)
+
+func TestNormalizeSQLEquivalent(t *testing.T) {
+	current := NormalizeOptions{Databases: []string{"app", "app_dev"}}
+	tests := []struct {
+		name    string
+		a, b    string
+		options NormalizeOptions
+	}{
+		{
+			name: "DEFINER",
+			a:    "CREATE DEFINER=`root`@`%` PROCEDURE p() SELECT 1",
+			b:    "CREATE DEFINER = 'dev'@'localhost' PROCEDURE p() SELECT 1",
+		},
+		{
+			name: "DEFINER=CURRENT_USER",
+			a:    "CREATE DEFINER=CURRENT_USER() TRIGGER tr BEFORE INSERT ON t FOR EACH ROW SET NEW.a = 1",
+			b:    "CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW SET NEW.a = 1",
+		},
+		{
+			name:    "当前库名限定",
+			a:       "select `app`.`t`.`id` from `app`.`t`",
+			b:       "select `t`.`id` from `t`",
+			options: current,
+		},
+		{
+			name:    "两侧库名不同",
+			a:       "SELECT * FROM app_dev.t",
+			b:       "SELECT * FROM `app`.`t`",
+			options: current,
+		},
+		{
+			name: "字符集前缀",
+			a:    "select `t`.`id` from `t` where `t`.`name` = _utf8mb4'x'",
+			b:    "select `t`.`id` from `t` where `t`.`name` = 'x'",
+		},
+		{
+			name: "同名别名",
+			a:    "select `t`.`id` AS `id`,`t`.`name` AS `name` from `t`",
+			b:    "SELECT t.id, t.name FROM t",
+		},
+		{
+			name: "注释、空白和关键字大小写",
+			a:    "SELECT id -- 主键\nFROM   t /* 表 */ WHERE id > 1;",
+			b:    "select id from t where id > 1",
+		},
+		{
+			name: "函数名大小写",
+			a:    "select count(`t`.`id`) from `t`",
+			b:    "SELECT COUNT(t.id) FROM t",
+		},
+		{
+			name: "未加引号的标识符不区分大小写",
+			a:    "SELECT id FROM Users",
+			b:    "select ID from `users`",
+		},
+		{
+			name: "INTO 之后的表名不是函数",
+			a:    "INSERT INTO logs(id) VALUES (1)",
+			b:    "insert into `logs` (`id`) values (1)",
+		},
+		{
+			name: "INSERT IGNORE",
+			a:    "insert ignore into t (id) values (1)",
+			b:    "INSERT IGNORE INTO t (id) VALUES (1)",
+		},
+		{
+			name: "GROUP_CONCAT SEPARATOR",
+			a:    "select group_concat(a separator ',') from t",
+			b:    "SELECT GROUP_CONCAT(a SEPARATOR ',') FROM t",
+		},
+		{
+			name: "ON DUPLICATE KEY UPDATE",
+			a:    "insert into t (id, n) values (1, 1) on duplicate key update n = n + 1",
+			b:    "INSERT INTO t (id, n) VALUES (1, 1) ON DUPLICATE KEY UPDATE n = n + 1",
+		},
+		{
+			name:    "lower_case_table_names 不为 0 时标识符不区分大小写",
+			a:       "SELECT `Users`.`ID` FROM `Users`",
+			b:       "select users.id from users",
+			options: NormalizeOptions{FoldIdentifiers: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := NormalizeSQL(tt.a, tt.options), NormalizeSQL(tt.b, tt.options)
+			if a != b {
+				t.Errorf("应等价:\n  %s\n  %s", a, b)
+			}
+		})
+	}
+}
+
+func TestNormalizeSQLDifferent(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		options NormalizeOptions
+	}{
+		{
+			name: "加引号的标识符区分大小写",
+			a:    "select `id` from `Users`",
+			b:    "select `id` from `users`",
+		},
+		{
+			name: "其他库的限定",
+			a:    "SELECT * FROM other.t",
+			b:    "SELECT * FROM t",
+		},
+		{
+			name: "不同名的别名",
+			a:    "select `t`.`id` AS `user_id` from `t`",
+			b:    "select `t`.`id` from `t`",
+		},
+		{
+			name: "字符串大小写",
+			a:    "SELECT 'A'",
+			b:    "SELECT 'a'",
+		},
+		{
+			name: "常量不同",
+			a:    "SELECT id FROM t WHERE id > 1",
+			b:    "SELECT id FROM t WHERE id > 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if a, b := NormalizeSQL(tt.a, tt.options), NormalizeSQL(tt.b, tt.options); a == b {
+				t.Errorf("不应等价: %s", a)
+			}
+		})
+	}
+}
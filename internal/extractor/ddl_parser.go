@@ -0,0 +1,1598 @@
+package extractor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ddlParser 单条DDL语句的语法解析器
+type ddlParser struct {
+	src    string
+	tokens []ddlToken
+	pos    int
+}
+
+// newDDLParser 创建语句解析器
+func newDDLParser(stmt string) *ddlParser {
+	return &ddlParser{src: stmt, tokens: tokenizeDDL(stmt)}
+}
+
+// sub 基于部分词法单元创建子解析器
+func (p *ddlParser) sub(tokens []ddlToken) *ddlParser {
+	return &ddlParser{src: p.src, tokens: tokens}
+}
+
+func (p *ddlParser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *ddlParser) peek() *ddlToken {
+	return p.peekAt(0)
+}
+
+func (p *ddlParser) peekAt(offset int) *ddlToken {
+	if p.pos+offset >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos+offset]
+}
+
+func (p *ddlParser) next() *ddlToken {
+	tok := p.peek()
+	if tok != nil {
+		p.pos++
+	}
+	return tok
+}
+
+// isWord 检查当前词法单元是否为指定关键字之一
+func (p *ddlParser) isWord(words ...string) bool {
+	return p.isWordAt(0, words...)
+}
+
+func (p *ddlParser) isWordAt(offset int, words ...string) bool {
+	tok := p.peekAt(offset)
+	if tok == nil || tok.kind != tokenWord {
+		return false
+	}
+	for _, word := range words {
+		if strings.EqualFold(tok.value, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptWords 按顺序匹配一组关键字，全部匹配时前进
+func (p *ddlParser) acceptWords(words ...string) bool {
+	for i, word := range words {
+		if !p.isWordAt(i, word) {
+			return false
+		}
+	}
+	p.pos += len(words)
+	return true
+}
+
+func (p *ddlParser) isPunct(punct string) bool {
+	tok := p.peek()
+	return tok != nil && tok.kind == tokenPunct && tok.value == punct
+}
+
+func (p *ddlParser) acceptPunct(punct string) bool {
+	if p.isPunct(punct) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// skipEquals 跳过可选的等号
+func (p *ddlParser) skipEquals() {
+	p.acceptPunct("=")
+}
+
+// identifier 读取标识符
+func (p *ddlParser) identifier() (string, error) {
+	tok := p.next()
+	if tok == nil {
+		return "", fmt.Errorf("语句意外结束")
+	}
+	switch tok.kind {
+	case tokenWord, tokenQuoted, tokenString:
+		return tok.value, nil
+	}
+	return "", fmt.Errorf("期望标识符，实际为 %q", tok.value)
+}
+
+// qualifiedName 读取可能带库名前缀的对象名，返回对象名部分
+func (p *ddlParser) qualifiedName() (string, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+	for p.acceptPunct(".") {
+		if name, err = p.identifier(); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+// value 读取一个选项值（标识符、字符串或数字）
+func (p *ddlParser) value() string {
+	tok := p.next()
+	if tok == nil {
+		return ""
+	}
+	return tok.value
+}
+
+// matchParen 返回与 open 处左括号匹配的右括号位置
+func (p *ddlParser) matchParen(open int) (int, error) {
+	depth := 0
+	for i := open; i < len(p.tokens); i++ {
+		tok := p.tokens[i]
+		if tok.kind != tokenPunct {
+			continue
+		}
+		switch tok.value {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("括号不匹配")
+}
+
+// parenGroup 读取括号内的词法单元并前进到右括号之后
+func (p *ddlParser) parenGroup() ([]ddlToken, error) {
+	if !p.isPunct("(") {
+		return nil, fmt.Errorf("期望 '('")
+	}
+	closePos, err := p.matchParen(p.pos)
+	if err != nil {
+		return nil, err
+	}
+	group := p.tokens[p.pos+1 : closePos]
+	p.pos = closePos + 1
+	return group, nil
+}
+
+// text 返回词法单元对应的原始文本
+func (p *ddlParser) text(tokens []ddlToken) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	return p.src[tokens[0].start:tokens[len(tokens)-1].end]
+}
+
+// rest 返回当前位置到语句末尾的原始文本
+func (p *ddlParser) rest() string {
+	if p.eof() {
+		return ""
+	}
+	return strings.TrimSpace(p.src[p.tokens[p.pos].start:])
+}
+
+// splitTopLevel 按顶层逗号拆分词法单元
+func splitTopLevel(tokens []ddlToken) [][]ddlToken {
+	var parts [][]ddlToken
+	depth := 0
+	start := 0
+	for i, tok := range tokens {
+		if tok.kind != tokenPunct {
+			continue
+		}
+		switch tok.value {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				parts = append(parts, tokens[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(tokens) {
+		parts = append(parts, tokens[start:])
+	}
+	return parts
+}
+
+// ddlLoader 将DDL语句逐条应用到Schema
+type ddlLoader struct {
+	schema       *DatabaseSchema
+	deferred     []string               // 依赖表已存在的语句（CREATE INDEX / ALTER TABLE）
+	fkIndexNames map[*ForeignKey]string // 外键对应的自动索引名称
+	warnings     []string
+}
+
+// newDDLLoader 创建DDL加载器
+func newDDLLoader(database string) *ddlLoader {
+	return &ddlLoader{
+		schema:       NewDatabaseSchema(database),
+		fkIndexNames: make(map[*ForeignKey]string),
+	}
+}
+
+// ParseDDL 解析DDL脚本为Schema
+func ParseDDL(script, database string) (*DatabaseSchema, error) {
+	loader := newDDLLoader(database)
+	if err := loader.load(script); err != nil {
+		return nil, err
+	}
+	return loader.finish()
+}
+
+// load 解析脚本中的所有语句
+func (l *ddlLoader) load(script string) error {
+	for _, stmt := range SplitSQLStatements(script) {
+		p := newDDLParser(stmt)
+		if p.eof() {
+			continue
+		}
+
+		if p.isWord("ALTER") && p.isWordAt(1, "TABLE") || p.isWord("CREATE") && p.isCreateIndex() {
+			l.deferred = append(l.deferred, stmt)
+			continue
+		}
+
+		if err := l.apply(p); err != nil {
+			return fmt.Errorf("%w: %s", err, statementSummary(stmt))
+		}
+	}
+	return nil
+}
+
+// finish 应用延迟语句并补全表信息
+func (l *ddlLoader) finish() (*DatabaseSchema, error) {
+	for _, stmt := range l.deferred {
+		if err := l.apply(newDDLParser(stmt)); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, statementSummary(stmt))
+		}
+	}
+	l.deferred = nil
+
+	for _, table := range l.schema.Tables {
+		l.finalizeTable(table)
+	}
+	return l.schema, nil
+}
+
+// apply 应用单条语句
+func (l *ddlLoader) apply(p *ddlParser) error {
+	switch {
+	case p.acceptWords("CREATE"):
+		return l.applyCreate(p)
+	case p.acceptWords("ALTER", "TABLE"):
+		return l.applyAlterTable(p)
+	default:
+		// DROP / USE / SET / INSERT 等语句与结构无关
+		return nil
+	}
+}
+
+// isCreateIndex 检查 CREATE 之后是否为索引定义
+func (p *ddlParser) isCreateIndex() bool {
+	offset := 1
+	if p.isWordAt(offset, "UNIQUE", "FULLTEXT", "SPATIAL") {
+		offset++
+	}
+	return p.isWordAt(offset, "INDEX")
+}
+
+// statementSummary 返回语句开头用于错误信息
+func statementSummary(stmt string) string {
+	line := []rune(strings.Join(strings.Fields(stmt), " "))
+	if len(line) > 80 {
+		return string(line[:80]) + "..."
+	}
+	return string(line)
+}
+
+// createClauses CREATE 与对象类型之间的可选子句
+type createClauses struct {
+	definer   string
+	security  string
+	indexKind string
+}
+
+// applyCreate 处理 CREATE 语句
+func (l *ddlLoader) applyCreate(p *ddlParser) error {
+	var clauses createClauses
+
+	for !p.eof() {
+		switch {
+		case p.acceptWords("OR", "REPLACE"), p.acceptWords("TEMPORARY"), p.acceptWords("AGGREGATE"):
+		case p.acceptWords("ALGORITHM"):
+			p.skipEquals()
+			p.next()
+		case p.acceptWords("DEFINER"):
+			p.skipEquals()
+			definer, err := p.definer()
+			if err != nil {
+				return err
+			}
+			clauses.definer = definer
+		case p.acceptWords("SQL", "SECURITY"):
+			clauses.security = strings.ToUpper(p.value())
+		case p.isWord("UNIQUE", "FULLTEXT", "SPATIAL"):
+			clauses.indexKind = strings.ToUpper(p.next().value)
+		case p.acceptWords("DATABASE"), p.acceptWords("SCHEMA"):
+			l.applyCreateDatabase(p)
+			return nil
+		case p.acceptWords("TABLE"):
+			return l.applyCreateTable(p)
+		case p.acceptWords("VIEW"):
+			return l.applyCreateView(p, clauses)
+		case p.acceptWords("PROCEDURE"):
+			return l.applyCreateProcedure(p, clauses)
+		case p.acceptWords("FUNCTION"):
+			return l.applyCreateFunction(p, clauses)
+		case p.acceptWords("TRIGGER"):
+			return l.applyCreateTrigger(p, clauses)
+		case p.acceptWords("EVENT"):
+			return l.applyCreateEvent(p, clauses)
+		case p.acceptWords("INDEX"):
+			return l.applyCreateIndex(p, clauses.indexKind)
+		default:
+			l.warnings = append(l.warnings, "跳过不支持的语句: "+statementSummary(p.src))
+			return nil
+		}
+	}
+	return nil
+}
+
+// definer 读取 DEFINER 值，统一为 user@host 形式
+func (p *ddlParser) definer() (string, error) {
+	if p.acceptWords("CURRENT_USER") {
+		if p.acceptPunct("(") {
+			p.acceptPunct(")")
+		}
+		return "", nil
+	}
+	user, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+	if !p.acceptPunct("@") {
+		return user, nil
+	}
+	host, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+	return user + "@" + host, nil
+}
+
+// applyCreateDatabase 从 CREATE DATABASE 读取默认字符集
+func (l *ddlLoader) applyCreateDatabase(p *ddlParser) {
+	p.acceptWords("IF", "NOT", "EXISTS")
+	if name, err := p.identifier(); err == nil && l.schema.Database == "" {
+		l.schema.Database = name
+	}
+
+	for !p.eof() {
+		switch {
+		case p.acceptWords("DEFAULT"):
+		case p.acceptWords("CHARACTER", "SET"), p.acceptWords("CHARSET"):
+			p.skipEquals()
+			l.schema.Charset = strings.ToLower(p.value())
+		case p.acceptWords("COLLATE"):
+			p.skipEquals()
+			l.schema.Collation = strings.ToLower(p.value())
+		default:
+			p.next()
+		}
+	}
+
+	if l.schema.Collation == "" {
+		l.schema.Collation = defaultCollation(l.schema.Charset)
+	}
+}
+
+// applyCreateTable 处理 CREATE TABLE
+func (l *ddlLoader) applyCreateTable(p *ddlParser) error {
+	p.acceptWords("IF", "NOT", "EXISTS")
+	name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+
+	if !p.isPunct("(") {
+		// CREATE TABLE ... LIKE / AS SELECT 无法静态确定结构
+		l.warnings = append(l.warnings, fmt.Sprintf("表 %s 的定义方式不受支持，已跳过", name))
+		return nil
+	}
+
+	body, err := p.parenGroup()
+	if err != nil {
+		return err
+	}
+
+	table := &TableSchema{
+		Name:        name,
+		Engine:      "InnoDB",
+		Columns:     []*ColumnSchema{},
+		Indexes:     make(map[string]*IndexSchema),
+		ForeignKeys: make(map[string]*ForeignKey),
+		Checks:      make(map[string]*CheckConstraint),
+		CreateSQL:   strings.TrimSpace(p.src),
+	}
+
+	for _, item := range splitTopLevel(body) {
+		if err := l.addTableItem(table, p.sub(item)); err != nil {
+			return fmt.Errorf("解析表 %s 失败: %w", name, err)
+		}
+	}
+
+	l.parseTableOptions(table, p)
+	l.schema.Tables[name] = table
+	return nil
+}
+
+// parseTableOptions 解析表选项
+func (l *ddlLoader) parseTableOptions(table *TableSchema, p *ddlParser) {
+	for !p.eof() {
+		switch {
+		case p.acceptPunct(","), p.acceptWords("DEFAULT"):
+		case p.acceptWords("ENGINE"):
+			p.skipEquals()
+			table.Engine = normalizeEngine(p.value())
+		case p.acceptWords("CHARACTER", "SET"), p.acceptWords("CHARSET"):
+			p.skipEquals()
+			table.Charset = strings.ToLower(p.value())
+		case p.acceptWords("COLLATE"):
+			p.skipEquals()
+			table.Collation = strings.ToLower(p.value())
+		case p.acceptWords("COMMENT"):
+			p.skipEquals()
+			table.Comment = p.value()
+		case p.acceptWords("AUTO_INCREMENT"):
+			p.skipEquals()
+			table.AutoIncr, _ = strconv.ParseInt(p.value(), 10, 64)
+		case p.isWord("PARTITION"):
+			partition, err := p.partitionBy()
+			if err != nil {
+				l.warnings = append(l.warnings, fmt.Sprintf("表 %s 的分区定义无法解析，已忽略: %v", table.Name, err))
+				return
+			}
+			table.Partition = partition
+			return
+		default:
+			p.next()
+			if p.acceptPunct("=") {
+				p.next()
+			}
+		}
+	}
+}
+
+// addTableItem 解析表定义中的一项（列、索引或约束）
+func (l *ddlLoader) addTableItem(table *TableSchema, p *ddlParser) error {
+	if p.eof() {
+		return nil
+	}
+
+	constraintName := ""
+	if p.acceptWords("CONSTRAINT") {
+		if !p.isWord("PRIMARY", "UNIQUE", "FOREIGN", "CHECK") {
+			name, err := p.identifier()
+			if err != nil {
+				return err
+			}
+			constraintName = name
+		}
+	}
+
+	switch {
+	case p.acceptWords("PRIMARY", "KEY"):
+		return l.addIndex(table, p, "PRIMARY", IndexTypePrimary)
+	case p.acceptWords("UNIQUE"):
+		if !p.acceptWords("KEY") {
+			p.acceptWords("INDEX")
+		}
+		return l.addIndex(table, p, constraintName, IndexTypeUnique)
+	case p.isWord("FULLTEXT", "SPATIAL"):
+		kind := IndexTypeFulltext
+		if strings.EqualFold(p.next().value, "SPATIAL") {
+			kind = IndexTypeSpatial
+		}
+		if !p.acceptWords("KEY") {
+			p.acceptWords("INDEX")
+		}
+		return l.addIndex(table, p, "", kind)
+	case p.isWord("KEY", "INDEX") && constraintName == "":
+		p.next()
+		return l.addIndex(table, p, "", IndexTypeNormal)
+	case p.acceptWords("FOREIGN", "KEY"):
+		return l.addForeignKey(table, p, constraintName)
+	case p.acceptWords("CHECK"):
+		return l.addCheck(table, p, constraintName)
+	}
+
+	if constraintName != "" {
+		return fmt.Errorf("无法识别的约束 %s", constraintName)
+	}
+	return l.addColumn(table, p)
+}
+
+// addIndex 解析索引定义，keyword 之后的部分
+func (l *ddlLoader) addIndex(table *TableSchema, p *ddlParser, name string, kind IndexType) error {
+	idx := &IndexSchema{
+		Type:      kind,
+		IsUnique:  kind == IndexTypePrimary || kind == IndexTypeUnique,
+		IsPrimary: kind == IndexTypePrimary,
+		IndexType: "BTREE",
+		Columns:   []IndexColumn{},
+	}
+	switch kind {
+	case IndexTypeFulltext:
+		idx.IndexType = "FULLTEXT"
+	case IndexTypeSpatial:
+		idx.IndexType = "SPATIAL"
+	}
+
+	if kind != IndexTypePrimary && !p.isPunct("(") && !p.isWord("USING") {
+		indexName, err := p.identifier()
+		if err != nil {
+			return err
+		}
+		name = indexName
+	}
+	if p.acceptWords("USING") {
+		p.next()
+	}
+
+	group, err := p.parenGroup()
+	if err != nil {
+		return err
+	}
+	for i, part := range splitTopLevel(group) {
+		idx.Columns = append(idx.Columns, parseIndexColumn(p.sub(part), i+1))
+	}
+	if len(idx.Columns) == 0 {
+		return fmt.Errorf("索引没有列")
+	}
+
+	for !p.eof() {
+		switch {
+		case p.acceptWords("COMMENT"):
+			idx.Comment = p.value()
+		case p.acceptWords("USING"):
+			p.next()
+		default:
+			p.next()
+		}
+	}
+
+	if kind == IndexTypePrimary {
+		name = "PRIMARY"
+	} else if name == "" {
+		name = uniqueIndexName(table, idx.Columns[0].Name)
+	}
+	idx.Name = name
+	table.Indexes[name] = idx
+	return nil
+}
+
+// parseIndexColumn 解析索引列，如 `name`(10) DESC
+func parseIndexColumn(p *ddlParser, seq int) IndexColumn {
+	col := IndexColumn{SeqInIdx: seq}
+
+	if p.isPunct("(") {
+		// 函数索引 ((expr))
+		group, err := p.parenGroup()
+		if err == nil {
+			col.Name = strings.TrimSpace(p.text(group))
+		}
+	} else if name, err := p.identifier(); err == nil {
+		col.Name = name
+		if p.isPunct("(") {
+			if group, err := p.parenGroup(); err == nil && len(group) == 1 {
+				if length, err := strconv.Atoi(group[0].value); err == nil {
+					col.SubPart = &length
+				}
+			}
+		}
+	}
+
+	if p.acceptWords("DESC") {
+		col.IsDesc = true
+	}
+	return col
+}
+
+// uniqueIndexName 按 MySQL 规则为未命名索引生成名称
+func uniqueIndexName(table *TableSchema, base string) string {
+	name := base
+	for i := 2; ; i++ {
+		if _, exists := table.Indexes[name]; !exists && !strings.EqualFold(name, "PRIMARY") {
+			return name
+		}
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// addForeignKey 解析外键定义，FOREIGN KEY 之后的部分
+func (l *ddlLoader) addForeignKey(table *TableSchema, p *ddlParser, name string) error {
+	indexName := ""
+	if !p.isPunct("(") {
+		id, err := p.identifier()
+		if err != nil {
+			return err
+		}
+		indexName = id
+	}
+
+	columns, err := p.identifierList()
+	if err != nil {
+		return err
+	}
+	if !p.acceptWords("REFERENCES") {
+		return fmt.Errorf("外键缺少 REFERENCES")
+	}
+	refTable, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	refColumns, err := p.identifierList()
+	if err != nil {
+		return err
+	}
+
+	fk := &ForeignKey{
+		Name:       name,
+		Columns:    columns,
+		RefTable:   refTable,
+		RefColumns: refColumns,
+		OnDelete:   "NO ACTION",
+		OnUpdate:   "NO ACTION",
+	}
+
+	for !p.eof() {
+		switch {
+		case p.acceptWords("ON", "DELETE"):
+			fk.OnDelete = p.referenceAction()
+		case p.acceptWords("ON", "UPDATE"):
+			fk.OnUpdate = p.referenceAction()
+		default:
+			p.next()
+		}
+	}
+
+	if fk.Name == "" {
+		fk.Name = nextForeignKeyName(table)
+	}
+	if indexName == "" {
+		indexName = fk.Name
+	}
+
+	table.ForeignKeys[fk.Name] = fk
+	l.fkIndexNames[fk] = indexName
+	return nil
+}
+
+// addCheck 解析CHECK约束，CHECK 之后的部分
+func (l *ddlLoader) addCheck(table *TableSchema, p *ddlParser, name string) error {
+	group, err := p.parenGroup()
+	if err != nil {
+		return err
+	}
+
+	check := &CheckConstraint{
+		Name:       name,
+		Expression: stripOuterParens(p.text(group)),
+		Enforced:   true,
+	}
+	if p.acceptWords("NOT", "ENFORCED") {
+		check.Enforced = false
+	} else {
+		p.acceptWords("ENFORCED")
+	}
+
+	if check.Name == "" {
+		check.Name = nextCheckName(table)
+	}
+	table.Checks[check.Name] = check
+	return nil
+}
+
+// nextCheckName 按 MySQL 规则生成未命名CHECK约束的名称，序号只计算自动生成的名称
+func nextCheckName(table *TableSchema) string {
+	for i := 1; ; i++ {
+		name := fmt.Sprintf("%s_chk_%d", table.Name, i)
+		if _, exists := table.Checks[name]; !exists {
+			return name
+		}
+	}
+}
+
+// ensureForeignKeyIndex 外键列没有可用索引时，按 InnoDB 行为自动创建索引
+func ensureForeignKeyIndex(table *TableSchema, fk *ForeignKey, indexName string) {
+	for _, idx := range table.Indexes {
+		if len(idx.Columns) < len(fk.Columns) {
+			continue
+		}
+		covered := true
+		for i, column := range fk.Columns {
+			if !strings.EqualFold(idx.Columns[i].Name, column) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return
+		}
+	}
+
+	idx := &IndexSchema{
+		Name:      uniqueIndexName(table, indexName),
+		Type:      IndexTypeNormal,
+		IndexType: "BTREE",
+		Columns:   make([]IndexColumn, len(fk.Columns)),
+	}
+	for i, column := range fk.Columns {
+		idx.Columns[i] = IndexColumn{Name: column, SeqInIdx: i + 1}
+	}
+	table.Indexes[idx.Name] = idx
+}
+
+// nextForeignKeyName 按 MySQL 规则生成未命名外键的名称
+func nextForeignKeyName(table *TableSchema) string {
+	for i := len(table.ForeignKeys) + 1; ; i++ {
+		name := fmt.Sprintf("%s_ibfk_%d", table.Name, i)
+		if _, exists := table.ForeignKeys[name]; !exists {
+			return name
+		}
+	}
+}
+
+// identifierList 读取括号中的标识符列表
+func (p *ddlParser) identifierList() ([]string, error) {
+	group, err := p.parenGroup()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, part := range splitTopLevel(group) {
+		name, err := p.sub(part).identifier()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// referenceAction 读取外键动作
+func (p *ddlParser) referenceAction() string {
+	switch {
+	case p.acceptWords("SET", "NULL"):
+		return "SET NULL"
+	case p.acceptWords("SET", "DEFAULT"):
+		return "SET DEFAULT"
+	case p.acceptWords("NO", "ACTION"):
+		return "NO ACTION"
+	}
+	return strings.ToUpper(p.value())
+}
+
+// addColumn 解析列定义
+func (l *ddlLoader) addColumn(table *TableSchema, p *ddlParser) error {
+	name, err := p.identifier()
+	if err != nil {
+		return err
+	}
+
+	col := &ColumnSchema{Name: name, IsNullable: true}
+	if err := p.columnType(col); err != nil {
+		return fmt.Errorf("列 %s: %w", name, err)
+	}
+
+	var extras []string
+	defaultGenerated := false
+
+	for !p.eof() {
+		switch {
+		case p.acceptWords("NOT", "NULL"):
+			col.IsNullable = false
+		case p.acceptWords("NULL"):
+			col.IsNullable = true
+		case p.acceptWords("DEFAULT"):
+			value, generated := p.defaultValue(col)
+			col.DefaultValue = value
+			defaultGenerated = generated
+		case p.acceptWords("AUTO_INCREMENT"):
+			col.IsAutoIncr = true
+		case p.acceptWords("ON", "UPDATE"):
+			extras = append(extras, "on update "+p.currentTimestamp())
+		case p.acceptWords("COMMENT"):
+			col.Comment = p.value()
+		case p.acceptWords("CHARACTER", "SET"), p.acceptWords("CHARSET"):
+			col.CharsetName = strings.ToLower(p.value())
+		case p.acceptWords("COLLATE"):
+			col.CollationName = strings.ToLower(p.value())
+		case p.acceptWords("PRIMARY", "KEY"), p.acceptWords("KEY"):
+			col.IsNullable = false
+			table.Indexes["PRIMARY"] = &IndexSchema{
+				Name:      "PRIMARY",
+				Type:      IndexTypePrimary,
+				IsUnique:  true,
+				IsPrimary: true,
+				IndexType: "BTREE",
+				Columns:   []IndexColumn{{Name: name, SeqInIdx: 1}},
+			}
+		case p.acceptWords("UNIQUE"):
+			p.acceptWords("KEY")
+			indexName := uniqueIndexName(table, name)
+			table.Indexes[indexName] = &IndexSchema{
+				Name:      indexName,
+				Type:      IndexTypeUnique,
+				IsUnique:  true,
+				IndexType: "BTREE",
+				Columns:   []IndexColumn{{Name: name, SeqInIdx: 1}},
+			}
+		case p.acceptWords("GENERATED", "ALWAYS", "AS"), p.acceptWords("AS"):
+			group, err := p.parenGroup()
+			if err != nil {
+				return fmt.Errorf("列 %s: %w", name, err)
+			}
+			col.IsGenerated = true
+			col.GeneratedExpr = strings.TrimSpace(p.text(group))
+		case p.acceptWords("STORED"), p.acceptWords("PERSISTENT"):
+			extras = append(extras, "STORED GENERATED")
+		case p.acceptWords("VIRTUAL"):
+			extras = append(extras, "VIRTUAL GENERATED")
+		case p.acceptWords("CONSTRAINT"):
+			checkName := ""
+			if !p.isWord("CHECK") {
+				if checkName, err = p.identifier(); err != nil {
+					return fmt.Errorf("列 %s: %w", name, err)
+				}
+			}
+			if !p.acceptWords("CHECK") {
+				return fmt.Errorf("列 %s: 无法识别的约束 %s", name, checkName)
+			}
+			if err := l.addCheck(table, p, checkName); err != nil {
+				return fmt.Errorf("列 %s: %w", name, err)
+			}
+		case p.acceptWords("CHECK"):
+			if err := l.addCheck(table, p, ""); err != nil {
+				return fmt.Errorf("列 %s: %w", name, err)
+			}
+		case p.acceptWords("REFERENCES"):
+			// 列级 REFERENCES 不会创建外键，不参与对比
+			for !p.eof() && !p.isPunct("(") {
+				p.next()
+			}
+			p.parenGroup()
+		default:
+			p.next()
+		}
+	}
+
+	if col.IsAutoIncr {
+		extras = append([]string{"auto_increment"}, extras...)
+	}
+	if defaultGenerated {
+		extras = append([]string{"DEFAULT_GENERATED"}, extras...)
+	}
+	if col.IsGenerated && !strings.Contains(strings.Join(extras, " "), "GENERATED") {
+		extras = append(extras, "VIRTUAL GENERATED")
+	}
+	col.Extra = strings.Join(extras, " ")
+
+	table.Columns = append(table.Columns, col)
+	return nil
+}
+
+// columnType 解析列类型并按 information_schema 的格式规范化
+func (p *ddlParser) columnType(col *ColumnSchema) error {
+	tok := p.next()
+	if tok == nil || tok.kind != tokenWord {
+		return fmt.Errorf("缺少列类型")
+	}
+	base := strings.ToLower(tok.value)
+
+	switch {
+	case base == "double" && p.acceptWords("PRECISION"):
+	case base == "character" && p.acceptWords("VARYING"):
+		base = "varchar"
+	case base == "national" || base == "long":
+		if next := p.next(); next != nil {
+			base = strings.ToLower(next.value)
+		}
+	}
+
+	args := ""
+	if p.isPunct("(") {
+		group, err := p.parenGroup()
+		if err != nil {
+			return err
+		}
+		args = typeArgs(group)
+	}
+
+	unsigned, zerofill := false, false
+	for {
+		switch {
+		case p.acceptWords("UNSIGNED"):
+			unsigned = true
+			continue
+		case p.acceptWords("SIGNED"):
+			continue
+		case p.acceptWords("ZEROFILL"):
+			zerofill, unsigned = true, true
+			continue
+		case p.acceptWords("BINARY"):
+			continue
+		}
+		break
+	}
+
+	col.DataType, col.ColumnType = normalizeColumnType(base, args, unsigned, zerofill)
+	fillTypeMetrics(col)
+	return nil
+}
+
+// typeArgs 将类型参数规范化，如 (10, 2) -> 10,2，enum("a") -> 'a'
+func typeArgs(tokens []ddlToken) string {
+	parts := make([]string, 0, len(tokens))
+	for _, part := range splitTopLevel(tokens) {
+		var values []string
+		for _, tok := range part {
+			if tok.kind == tokenString {
+				values = append(values, "'"+strings.ReplaceAll(tok.value, "'", "''")+"'")
+			} else {
+				values = append(values, tok.value)
+			}
+		}
+		parts = append(parts, strings.Join(values, ""))
+	}
+	return strings.Join(parts, ",")
+}
+
+// normalizeColumnType 规范化数据类型，返回 DATA_TYPE 与 COLUMN_TYPE
+// 整数类型按 MySQL 8.0 规则省略显示宽度（tinyint(1) 与 zerofill 除外）
+func normalizeColumnType(base, args string, unsigned, zerofill bool) (string, string) {
+	switch base {
+	case "integer", "int4":
+		base = "int"
+	case "int1":
+		base = "tinyint"
+	case "int2":
+		base = "smallint"
+	case "int3", "middleint":
+		base = "mediumint"
+	case "int8":
+		base = "bigint"
+	case "bool", "boolean":
+		base, args = "tinyint", "1"
+	case "dec", "numeric", "fixed":
+		base = "decimal"
+	case "real":
+		base = "double"
+	case "varchar", "varcharacter":
+		base = "varchar"
+	case "character":
+		base = "char"
+	}
+
+	switch base {
+	case "tinyint", "smallint", "mediumint", "int", "bigint":
+		if !zerofill && !(base == "tinyint" && args == "1") {
+			args = ""
+		}
+	case "decimal":
+		if args == "" {
+			args = "10,0"
+		} else if !strings.Contains(args, ",") {
+			args += ",0"
+		}
+	case "char", "binary", "bit":
+		if args == "" {
+			args = "1"
+		}
+	case "year":
+		args = ""
+	}
+
+	columnType := base
+	if args != "" {
+		columnType += "(" + args + ")"
+	}
+	if unsigned {
+		columnType += " unsigned"
+	}
+	if zerofill {
+		columnType += " zerofill"
+	}
+	return base, columnType
+}
+
+// fillTypeMetrics 填充长度、精度等信息
+func fillTypeMetrics(col *ColumnSchema) {
+	int64Ptr := func(v int64) *int64 { return &v }
+
+	args := ""
+	if open, closing := strings.Index(col.ColumnType, "("), strings.LastIndex(col.ColumnType, ")"); open >= 0 && closing > open {
+		args = col.ColumnType[open+1 : closing]
+	}
+
+	switch col.DataType {
+	case "char", "varchar", "binary", "varbinary":
+		if length, err := strconv.ParseInt(args, 10, 64); err == nil {
+			col.CharMaxLen = int64Ptr(length)
+		}
+	case "tinytext", "tinyblob":
+		col.CharMaxLen = int64Ptr(255)
+	case "text", "blob":
+		col.CharMaxLen = int64Ptr(65535)
+	case "mediumtext", "mediumblob":
+		col.CharMaxLen = int64Ptr(16777215)
+	case "longtext", "longblob":
+		col.CharMaxLen = int64Ptr(4294967295)
+	case "decimal":
+		parts := strings.SplitN(args, ",", 2)
+		if len(parts) == 2 {
+			if prec, err := strconv.ParseInt(parts[0], 10, 64); err == nil {
+				col.NumericPrec = int64Ptr(prec)
+			}
+			if scale, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+				col.NumericScale = int64Ptr(scale)
+			}
+		}
+	}
+}
+
+// isStringType 判断是否为带字符集的类型
+func isStringType(dataType string) bool {
+	switch dataType {
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set":
+		return true
+	}
+	return false
+}
+
+// defaultValue 解析默认值，返回值与是否为表达式默认值
+func (p *ddlParser) defaultValue(col *ColumnSchema) (*string, bool) {
+	tok := p.peek()
+	if tok == nil {
+		return nil, false
+	}
+
+	switch {
+	case p.acceptWords("NULL"):
+		return nil, false
+	case p.acceptWords("TRUE"):
+		v := "1"
+		return &v, false
+	case p.acceptWords("FALSE"):
+		v := "0"
+		return &v, false
+	case p.isWord("CURRENT_TIMESTAMP", "NOW", "LOCALTIME", "LOCALTIMESTAMP"):
+		v := p.currentTimestamp()
+		return &v, true
+	case p.isPunct("("):
+		group, _ := p.parenGroup()
+		v := strings.TrimSpace(p.text(group))
+		return &v, true
+	case tok.kind == tokenWord && (strings.EqualFold(tok.value, "b") || strings.EqualFold(tok.value, "x")):
+		next := p.peekAt(1)
+		if next != nil && next.kind == tokenString && next.start == tok.end {
+			p.pos += 2
+			v := strings.ToLower(tok.value) + "'" + next.value + "'"
+			return &v, false
+		}
+	}
+
+	sign := ""
+	if p.acceptPunct("-") {
+		sign = "-"
+	} else {
+		p.acceptPunct("+")
+	}
+
+	tok = p.next()
+	if tok == nil {
+		return nil, false
+	}
+	v := sign + tok.value
+	if col.DataType == "decimal" && col.NumericScale != nil {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			v = strconv.FormatFloat(f, 'f', int(*col.NumericScale), 64)
+		}
+	}
+	return &v, false
+}
+
+// currentTimestamp 读取 CURRENT_TIMESTAMP[(n)] 及其同义写法
+func (p *ddlParser) currentTimestamp() string {
+	p.next()
+	value := "CURRENT_TIMESTAMP"
+	if p.isPunct("(") {
+		if group, err := p.parenGroup(); err == nil && len(group) == 1 {
+			value += "(" + group[0].value + ")"
+		}
+	}
+	return value
+}
+
+// applyCreateIndex 处理 CREATE INDEX
+func (l *ddlLoader) applyCreateIndex(p *ddlParser, kind string) error {
+	indexName, err := p.identifier()
+	if err != nil {
+		return err
+	}
+	if p.acceptWords("USING") {
+		p.next()
+	}
+	if !p.acceptWords("ON") {
+		return fmt.Errorf("CREATE INDEX 缺少 ON")
+	}
+	tableName, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	table, ok := l.schema.Tables[tableName]
+	if !ok {
+		return fmt.Errorf("索引 %s 引用的表 %s 不存在", indexName, tableName)
+	}
+
+	table.CreateSQL += ";\n" + strings.TrimSpace(p.src)
+
+	indexType := IndexTypeNormal
+	switch kind {
+	case "UNIQUE":
+		indexType = IndexTypeUnique
+	case "FULLTEXT":
+		indexType = IndexTypeFulltext
+	case "SPATIAL":
+		indexType = IndexTypeSpatial
+	}
+	return l.addIndex(table, p, indexName, indexType)
+}
+
+// applyAlterTable 处理 ALTER TABLE 中的 ADD 子句
+func (l *ddlLoader) applyAlterTable(p *ddlParser) error {
+	tableName, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	table, ok := l.schema.Tables[tableName]
+	if !ok {
+		return fmt.Errorf("ALTER TABLE 引用的表 %s 不存在", tableName)
+	}
+	// 新建表时需要连同后续修改一起执行
+	table.CreateSQL += ";\n" + strings.TrimSpace(p.src)
+
+	for _, clause := range splitTopLevel(p.tokens[p.pos:]) {
+		cp := p.sub(clause)
+		if !cp.acceptWords("ADD") {
+			l.warnings = append(l.warnings, fmt.Sprintf("表 %s: 跳过不支持的 ALTER 子句: %s", tableName, statementSummary(cp.text(clause))))
+			continue
+		}
+		cp.acceptWords("COLUMN")
+		if cp.isPunct("(") {
+			group, err := cp.parenGroup()
+			if err != nil {
+				return err
+			}
+			for _, item := range splitTopLevel(group) {
+				if err := l.addTableItem(table, p.sub(item)); err != nil {
+					return fmt.Errorf("解析表 %s 失败: %w", tableName, err)
+				}
+			}
+			continue
+		}
+		if err := l.addTableItem(table, cp.sub(cp.tokens[cp.pos:])); err != nil {
+			return fmt.Errorf("解析表 %s 失败: %w", tableName, err)
+		}
+	}
+	return nil
+}
+
+// finalizeTable 补全列位置、继承的字符集与排序规则
+func (l *ddlLoader) finalizeTable(table *TableSchema) {
+	if table.Charset == "" && table.Collation == "" {
+		table.Charset = l.schema.Charset
+		table.Collation = l.schema.Collation
+	}
+	if table.Collation == "" {
+		table.Collation = defaultCollation(table.Charset)
+	}
+	if table.Collation != "" {
+		// 与 MySQLExtractor 一致，从排序规则推导字符集
+		table.Charset = strings.Split(table.Collation, "_")[0]
+	}
+
+	for i, col := range table.Columns {
+		col.Position = i + 1
+
+		if !isStringType(col.DataType) {
+			continue
+		}
+		if col.CharsetName == "" && col.CollationName == "" {
+			col.CharsetName = table.Charset
+			col.CollationName = table.Collation
+		} else if col.CollationName == "" {
+			col.CollationName = defaultCollation(col.CharsetName)
+		} else if col.CharsetName == "" {
+			col.CharsetName = strings.Split(col.CollationName, "_")[0]
+		}
+	}
+
+	for _, fk := range table.ForeignKeys {
+		ensureForeignKeyIndex(table, fk, l.fkIndexNames[fk])
+	}
+
+	for _, idx := range table.Indexes {
+		if !idx.IsPrimary {
+			continue
+		}
+		for _, idxCol := range idx.Columns {
+			if col := table.GetColumn(idxCol.Name); col != nil {
+				col.IsNullable = false
+			}
+		}
+	}
+}
+
+// defaultCollation 返回字符集在 MySQL 8.0 下的默认排序规则
+func defaultCollation(charset string) string {
+	switch strings.ToLower(charset) {
+	case "":
+		return ""
+	case "utf8mb4":
+		return "utf8mb4_0900_ai_ci"
+	case "utf8", "utf8mb3":
+		return "utf8mb3_general_ci"
+	case "latin1":
+		return "latin1_swedish_ci"
+	case "binary":
+		return "binary"
+	case "gbk":
+		return "gbk_chinese_ci"
+	default:
+		return strings.ToLower(charset) + "_general_ci"
+	}
+}
+
+// normalizeEngine 规范化存储引擎名称大小写
+func normalizeEngine(engine string) string {
+	switch strings.ToLower(engine) {
+	case "innodb":
+		return "InnoDB"
+	case "myisam":
+		return "MyISAM"
+	case "memory", "heap":
+		return "MEMORY"
+	case "csv":
+		return "CSV"
+	case "archive":
+		return "ARCHIVE"
+	case "blackhole":
+		return "BLACKHOLE"
+	}
+	return engine
+}
+
+// applyCreateView 处理 CREATE VIEW
+func (l *ddlLoader) applyCreateView(p *ddlParser, clauses createClauses) error {
+	p.acceptWords("IF", "NOT", "EXISTS")
+	name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+	if p.isPunct("(") {
+		if _, err := p.parenGroup(); err != nil {
+			return err
+		}
+	}
+	if !p.acceptWords("AS") {
+		return fmt.Errorf("视图 %s 缺少 AS", name)
+	}
+
+	view := &ViewSchema{
+		Name:     name,
+		Definer:  clauses.definer,
+		Security: clauses.security,
+		CheckOpt: "NONE",
+	}
+	if view.Security == "" {
+		view.Security = "DEFINER"
+	}
+
+	// 截掉 WITH [CASCADED|LOCAL] CHECK OPTION
+	end := len(p.tokens)
+checkOption:
+	for i := p.pos; i < len(p.tokens); i++ {
+		sp := p.sub(p.tokens[i:])
+		switch {
+		case sp.acceptWords("WITH", "CHECK", "OPTION"), sp.acceptWords("WITH", "CASCADED", "CHECK", "OPTION"):
+			view.CheckOpt = "CASCADED"
+		case sp.acceptWords("WITH", "LOCAL", "CHECK", "OPTION"):
+			view.CheckOpt = "LOCAL"
+		default:
+			continue
+		}
+		if sp.eof() {
+			end = i
+			break checkOption
+		}
+		view.CheckOpt = "NONE"
+	}
+	view.Definition = strings.TrimSpace(p.text(p.tokens[p.pos:end]))
+
+	l.schema.Views[name] = view
+	return nil
+}
+
+// routineHeader 存储过程/函数的公共部分
+type routineHeader struct {
+	name          string
+	params        []ProcedureParam
+	comment       string
+	security      string
+	deterministic bool
+}
+
+// parseRoutineHeader 解析名称、参数列表
+func (p *ddlParser) parseRoutineHeader(withMode bool) (*routineHeader, error) {
+	p.acceptWords("IF", "NOT", "EXISTS")
+	name, err := p.qualifiedName()
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := p.parenGroup()
+	if err != nil {
+		return nil, fmt.Errorf("%s 参数列表: %w", name, err)
+	}
+
+	header := &routineHeader{name: name, security: "DEFINER"}
+	for i, part := range splitTopLevel(group) {
+		pp := p.sub(part)
+		param := ProcedureParam{Position: i + 1}
+		if withMode {
+			param.Mode = "IN"
+			if pp.isWord("IN", "OUT", "INOUT") {
+				param.Mode = strings.ToUpper(pp.next().value)
+			}
+		}
+		if param.Name, err = pp.identifier(); err != nil {
+			return nil, fmt.Errorf("%s 参数: %w", name, err)
+		}
+		param.DataType = pp.routineType()
+		header.params = append(header.params, param)
+	}
+	return header, nil
+}
+
+// routineType 解析参数或返回值类型，格式与 DTD_IDENTIFIER 一致
+func (p *ddlParser) routineType() string {
+	var col ColumnSchema
+	if err := p.columnType(&col); err != nil {
+		return ""
+	}
+	for p.acceptWords("CHARACTER", "SET") || p.acceptWords("CHARSET") || p.acceptWords("COLLATE") {
+		p.next()
+	}
+	return col.ColumnType
+}
+
+// parseCharacteristics 解析存储过程/函数特性，停在主体开始处
+func (p *ddlParser) parseCharacteristics(header *routineHeader) {
+	for !p.eof() {
+		switch {
+		case p.acceptWords("COMMENT"):
+			header.comment = p.value()
+		case p.acceptWords("LANGUAGE", "SQL"),
+			p.acceptWords("CONTAINS", "SQL"),
+			p.acceptWords("NO", "SQL"),
+			p.acceptWords("READS", "SQL", "DATA"),
+			p.acceptWords("MODIFIES", "SQL", "DATA"):
+		case p.acceptWords("NOT", "DETERMINISTIC"):
+			header.deterministic = false
+		case p.acceptWords("DETERMINISTIC"):
+			header.deterministic = true
+		case p.acceptWords("SQL", "SECURITY"):
+			header.security = strings.ToUpper(p.value())
+		default:
+			return
+		}
+	}
+}
+
+// applyCreateProcedure 处理 CREATE PROCEDURE
+func (l *ddlLoader) applyCreateProcedure(p *ddlParser, clauses createClauses) error {
+	header, err := p.parseRoutineHeader(true)
+	if err != nil {
+		return err
+	}
+	p.parseCharacteristics(header)
+	if p.eof() {
+		return fmt.Errorf("存储过程 %s 缺少过程体", header.name)
+	}
+
+	l.schema.Procedures[header.name] = &ProcedureSchema{
+		Name:       header.name,
+		Definition: strings.TrimSpace(p.src),
+		Definer:    clauses.definer,
+		Params:     header.params,
+		Comment:    header.comment,
+		Security:   header.security,
+	}
+	return nil
+}
+
+// applyCreateFunction 处理 CREATE FUNCTION
+func (l *ddlLoader) applyCreateFunction(p *ddlParser, clauses createClauses) error {
+	header, err := p.parseRoutineHeader(false)
+	if err != nil {
+		return err
+	}
+	if !p.acceptWords("RETURNS") {
+		return fmt.Errorf("函数 %s 缺少 RETURNS", header.name)
+	}
+	returns := p.routineType()
+	p.parseCharacteristics(header)
+	if p.eof() {
+		return fmt.Errorf("函数 %s 缺少函数体", header.name)
+	}
+
+	l.schema.Functions[header.name] = &FunctionSchema{
+		Name:       header.name,
+		Definition: strings.TrimSpace(p.src),
+		Definer:    clauses.definer,
+		Params:     header.params,
+		Returns:    returns,
+		Comment:    header.comment,
+		Security:   header.security,
+		IsDetermin: header.deterministic,
+	}
+	return nil
+}
+
+// applyCreateTrigger 处理 CREATE TRIGGER
+func (l *ddlLoader) applyCreateTrigger(p *ddlParser, clauses createClauses) error {
+	p.acceptWords("IF", "NOT", "EXISTS")
+	name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+
+	trigger := &TriggerSchema{Name: name, Definer: clauses.definer}
+	if !p.isWord("BEFORE", "AFTER") {
+		return fmt.Errorf("触发器 %s 缺少 BEFORE/AFTER", name)
+	}
+	trigger.Timing = strings.ToUpper(p.next().value)
+	if !p.isWord("INSERT", "UPDATE", "DELETE") {
+		return fmt.Errorf("触发器 %s 缺少触发事件", name)
+	}
+	trigger.Event = strings.ToUpper(p.next().value)
+
+	if !p.acceptWords("ON") {
+		return fmt.Errorf("触发器 %s 缺少 ON", name)
+	}
+	if trigger.Table, err = p.qualifiedName(); err != nil {
+		return err
+	}
+	if !p.acceptWords("FOR", "EACH", "ROW") {
+		return fmt.Errorf("触发器 %s 缺少 FOR EACH ROW", name)
+	}
+	if p.isWord("FOLLOWS", "PRECEDES") {
+		p.pos += 2
+	}
+
+	trigger.Statement = p.rest()
+	if trigger.Statement == "" {
+		return fmt.Errorf("触发器 %s 缺少触发体", name)
+	}
+
+	l.schema.Triggers[name] = trigger
+	return nil
+}
+
+// applyCreateEvent 处理 CREATE EVENT
+func (l *ddlLoader) applyCreateEvent(p *ddlParser, clauses createClauses) error {
+	p.acceptWords("IF", "NOT", "EXISTS")
+	name, err := p.qualifiedName()
+	if err != nil {
+		return err
+	}
+
+	event := &EventSchema{
+		Name:         name,
+		Definer:      clauses.definer,
+		Status:       "ENABLED",
+		OnCompletion: "NOT PRESERVE",
+	}
+	if !p.acceptWords("ON", "SCHEDULE") {
+		return fmt.Errorf("事件 %s 缺少 ON SCHEDULE", name)
+	}
+	if err := p.eventSchedule(event); err != nil {
+		return fmt.Errorf("事件 %s: %w", name, err)
+	}
+
+	for !p.eof() {
+		switch {
+		case p.acceptWords("ON", "COMPLETION", "NOT", "PRESERVE"):
+			event.OnCompletion = "NOT PRESERVE"
+		case p.acceptWords("ON", "COMPLETION", "PRESERVE"):
+			event.OnCompletion = "PRESERVE"
+		case p.acceptWords("ENABLE"):
+			event.Status = "ENABLED"
+		case p.acceptWords("DISABLE", "ON"):
+			p.next()
+			event.Status = "SLAVESIDE_DISABLED"
+		case p.acceptWords("DISABLE"):
+			event.Status = "DISABLED"
+		case p.acceptWords("COMMENT"):
+			event.Comment = p.value()
+		case p.acceptWords("DO"):
+			event.Body = p.rest()
+			if event.Body == "" {
+				return fmt.Errorf("事件 %s 缺少事件体", name)
+			}
+			l.schema.Events[name] = event
+			return nil
+		default:
+			return fmt.Errorf("事件 %s 存在无法识别的子句 %q", name, p.peek().value)
+		}
+	}
+	return fmt.Errorf("事件 %s 缺少 DO", name)
+}
+
+// eventSchedule 解析 AT 或 EVERY 调度子句
+func (p *ddlParser) eventSchedule(event *EventSchema) error {
+	switch {
+	case p.acceptWords("AT"):
+		event.EventType = "ONE TIME"
+		event.ExecuteAt = p.eventTime()
+	case p.acceptWords("EVERY"):
+		event.EventType = "RECURRING"
+		interval := p.eventClauseTokens()
+		if len(interval) < 2 {
+			return fmt.Errorf("EVERY 缺少间隔")
+		}
+		event.IntervalValue = p.text(interval[:len(interval)-1])
+		event.IntervalField = strings.ToUpper(interval[len(interval)-1].value)
+		if p.acceptWords("STARTS") {
+			event.Starts = p.eventTime()
+		}
+		if p.acceptWords("ENDS") {
+			event.Ends = p.eventTime()
+		}
+	default:
+		return fmt.Errorf("期望 AT 或 EVERY")
+	}
+	return nil
+}
+
+// eventTime 读取调度时间，字符串常量返回其值，表达式返回原始文本
+func (p *ddlParser) eventTime() string {
+	tokens := p.eventClauseTokens()
+	if len(tokens) == 1 && tokens[0].kind == tokenString {
+		return tokens[0].value
+	}
+	return p.text(tokens)
+}
+
+// eventClauseTokens 读取到下一个事件子句关键字为止的词法单元
+func (p *ddlParser) eventClauseTokens() []ddlToken {
+	start := p.pos
+	for !p.eof() && !p.isWord("STARTS", "ENDS", "ON", "ENABLE", "DISABLE", "COMMENT", "DO") {
+		p.pos++
+	}
+	return p.tokens[start:p.pos]
+}
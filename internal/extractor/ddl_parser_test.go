@@ -0,0 +1,269 @@
+package extractor
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mustParseDDL 解析DDL脚本，失败时终止测试
+func mustParseDDL(t *testing.T, script string) *DatabaseSchema {
+	t.Helper()
+	schema, err := ParseDDL(script, "app")
+	if err != nil {
+		t.Fatalf("ParseDDL() error = %v", err)
+	}
+	return schema
+}
+
+// mustTable 返回指定的表，不存在时终止测试
+func mustTable(t *testing.T, schema *DatabaseSchema, name string) *TableSchema {
+	t.Helper()
+	table := schema.Tables[name]
+	if table == nil {
+		t.Fatalf("表 %s 不存在，已解析: %v", name, tableNames(schema))
+	}
+	return table
+}
+
+func tableNames(schema *DatabaseSchema) []string {
+	var names []string
+	for name := range schema.Tables {
+		names = append(names, name)
+	}
+	return names
+}
+
+func columnNames(table *TableSchema) []string {
+	var names []string
+	for _, col := range table.Columns {
+		names = append(names, col.Name)
+	}
+	return names
+}
+
+func findColumn(table *TableSchema, name string) *ColumnSchema {
+	for _, col := range table.Columns {
+		if col.Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+func TestParseDDLTable(t *testing.T) {
+	schema := mustParseDDL(t, "CREATE TABLE `app`.`Order Items` (\n"+
+		"  `id` bigint unsigned NOT NULL AUTO_INCREMENT,\n"+
+		"  `name` varchar(64) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT '' COMMENT '名称',\n"+
+		"  price decimal(10,2) DEFAULT NULL,\n"+
+		"  `updated_at` timestamp NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  UNIQUE KEY `uk_name` (`name`(10)),\n"+
+		"  KEY `idx_price` (`price` DESC)\n"+
+		") ENGINE=InnoDB AUTO_INCREMENT=100 DEFAULT CHARSET=utf8mb4 COMMENT='订单明细';")
+
+	table := mustTable(t, schema, "Order Items")
+	if got, want := columnNames(table), []string{"id", "name", "price", "updated_at"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %v, want %v", got, want)
+	}
+	if table.Engine != "InnoDB" || table.Charset != "utf8mb4" || table.Comment != "订单明细" {
+		t.Errorf("table options = %q %q %q", table.Engine, table.Charset, table.Comment)
+	}
+
+	id := findColumn(table, "id")
+	if !id.IsAutoIncr || id.IsNullable || !strings.Contains(strings.ToLower(id.ColumnType), "unsigned") {
+		t.Errorf("id = %+v", id)
+	}
+	name := findColumn(table, "name")
+	if name.DefaultValue == nil || *name.DefaultValue != "" || name.Comment != "名称" || name.CollationName != "utf8mb4_bin" {
+		t.Errorf("name = %+v", name)
+	}
+	if price := findColumn(table, "price"); !price.IsNullable || price.DefaultValue != nil {
+		t.Errorf("price 应可为空且没有默认值: %+v", price)
+	}
+	if updated := findColumn(table, "updated_at"); !strings.Contains(strings.ToLower(updated.Extra), "on update current_timestamp") {
+		t.Errorf("updated_at extra = %q", updated.Extra)
+	}
+
+	if pk := table.Indexes["PRIMARY"]; pk == nil || !pk.IsPrimary {
+		t.Errorf("PRIMARY = %+v", pk)
+	}
+	uk := table.Indexes["uk_name"]
+	if uk == nil || !uk.IsUnique || uk.Columns[0].SubPart == nil || *uk.Columns[0].SubPart != 10 {
+		t.Errorf("uk_name = %+v", uk)
+	}
+	if idx := table.Indexes["idx_price"]; idx == nil || !idx.Columns[0].IsDesc {
+		t.Errorf("idx_price = %+v", idx)
+	}
+}
+
+func TestParseDDLGeneratedColumns(t *testing.T) {
+	schema := mustParseDDL(t, `CREATE TABLE t (
+		a int,
+		b int GENERATED ALWAYS AS (a * 2) STORED,
+		c int AS (a + 1) VIRTUAL
+	)`)
+	table := mustTable(t, schema, "t")
+
+	for _, name := range []string{"b", "c"} {
+		col := findColumn(table, name)
+		if col == nil || !col.IsGenerated || col.GeneratedExpr == "" {
+			t.Errorf("%s 应为生成列: %+v", name, col)
+		}
+	}
+	if b := findColumn(table, "b"); !strings.Contains(strings.ToUpper(b.Extra), "STORED") {
+		t.Errorf("b extra = %q", b.Extra)
+	}
+	if a := findColumn(table, "a"); a.IsGenerated {
+		t.Errorf("a 不是生成列")
+	}
+}
+
+func TestParseDDLConstraints(t *testing.T) {
+	schema := mustParseDDL(t, `
+		CREATE TABLE parent (id int PRIMARY KEY);
+		CREATE TABLE child (
+			id int PRIMARY KEY,
+			parent_id int,
+			qty int,
+			CONSTRAINT fk_parent FOREIGN KEY (parent_id) REFERENCES parent (id) ON DELETE CASCADE,
+			CONSTRAINT chk_qty CHECK (qty > 0) NOT ENFORCED,
+			CHECK (qty < 100)
+		);`)
+	child := mustTable(t, schema, "child")
+
+	fk := child.ForeignKeys["fk_parent"]
+	if fk == nil || fk.RefTable != "parent" || !reflect.DeepEqual(fk.Columns, []string{"parent_id"}) || fk.OnDelete != "CASCADE" {
+		t.Fatalf("fk_parent = %+v", fk)
+	}
+	// InnoDB 为没有索引的外键列自动创建索引
+	if child.Indexes["fk_parent"] == nil {
+		t.Errorf("缺少外键自动索引，索引: %v", child.Indexes)
+	}
+
+	if chk := child.Checks["chk_qty"]; chk == nil || chk.Enforced || chk.Expression != "qty > 0" {
+		t.Errorf("chk_qty = %+v", chk)
+	}
+	if chk := child.Checks["child_chk_1"]; chk == nil || !chk.Enforced {
+		t.Errorf("未命名CHECK约束应为 child_chk_1，约束: %v", child.Checks)
+	}
+}
+
+func TestParseDDLDeferredStatements(t *testing.T) {
+	// CREATE INDEX 和 ALTER TABLE 在表定义之前出现时也能应用
+	schema := mustParseDDL(t, `
+		CREATE INDEX idx_a ON t (a);
+		ALTER TABLE t ADD COLUMN b varchar(10);
+		CREATE TABLE t (a int);`)
+	table := mustTable(t, schema, "t")
+
+	if table.Indexes["idx_a"] == nil {
+		t.Errorf("缺少索引 idx_a")
+	}
+	if got, want := columnNames(table), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("columns = %v, want %v", got, want)
+	}
+}
+
+func TestParseDDLPartitions(t *testing.T) {
+	schema := mustParseDDL(t, `CREATE TABLE logs (
+		id int NOT NULL,
+		created date NOT NULL
+	) ENGINE=InnoDB
+	/*!50100 PARTITION BY RANGE (year(created))
+	(PARTITION p2023 VALUES LESS THAN (2024) ENGINE = InnoDB,
+	 PARTITION pmax VALUES LESS THAN MAXVALUE ENGINE = InnoDB) */;
+
+	CREATE TABLE h (id int) PARTITION BY HASH (id) PARTITIONS 4;`)
+
+	ranged := mustTable(t, schema, "logs").Partition
+	if ranged == nil || !ranged.IsRange() || len(ranged.Partitions) != 2 {
+		t.Fatalf("logs partition = %+v", ranged)
+	}
+	if ranged.Partitions[0].Name != "p2023" || ranged.Partitions[1].Name != "pmax" {
+		t.Errorf("partitions = %+v", ranged.Partitions)
+	}
+	if !strings.Contains(ranged.Partitions[0].Description, "2024") || !strings.EqualFold(ranged.Partitions[1].Description, "MAXVALUE") {
+		t.Errorf("descriptions = %q, %q", ranged.Partitions[0].Description, ranged.Partitions[1].Description)
+	}
+
+	hash := mustTable(t, schema, "h").Partition
+	if hash == nil || hash.Method != "HASH" || len(hash.Partitions) != 4 {
+		t.Errorf("h partition = %+v", hash)
+	}
+}
+
+func TestParseDDLRoutinesAndTriggers(t *testing.T) {
+	schema := mustParseDDL(t, "CREATE TABLE t (v int);\n"+
+		"CREATE DEFINER=`dev`@`%` SQL SECURITY INVOKER VIEW v AS SELECT v FROM t;\n"+
+		"DELIMITER $$\n"+
+		"CREATE DEFINER=`dev`@`%` PROCEDURE p(IN a int, OUT b varchar(10))\n"+
+		"  SQL SECURITY INVOKER COMMENT 'proc'\n"+
+		"BEGIN\n  SELECT a; SET b = 'x';\nEND$$\n"+
+		"CREATE FUNCTION f(x int) RETURNS int DETERMINISTIC RETURN x + 1$$\n"+
+		"CREATE TRIGGER tr BEFORE INSERT ON t FOR EACH ROW SET NEW.v = 1$$\n"+
+		"DELIMITER ;\n")
+
+	if v := schema.Views["v"]; v == nil || v.Definer != "dev@%" || !strings.EqualFold(v.Security, "INVOKER") || v.Definition != "SELECT v FROM t" {
+		t.Errorf("view = %+v", v)
+	}
+
+	p := schema.Procedures["p"]
+	if p == nil {
+		t.Fatalf("缺少存储过程 p")
+	}
+	wantParams := []ProcedureParam{
+		{Name: "a", Mode: "IN", DataType: "int", Position: 1},
+		{Name: "b", Mode: "OUT", DataType: "varchar(10)", Position: 2},
+	}
+	if !reflect.DeepEqual(p.Params, wantParams) {
+		t.Errorf("params = %+v, want %+v", p.Params, wantParams)
+	}
+	if p.Definer != "dev@%" || p.Comment != "proc" || !strings.EqualFold(p.Security, "INVOKER") {
+		t.Errorf("procedure = %+v", p)
+	}
+	if !strings.Contains(p.Definition, "SET b = 'x';") {
+		t.Errorf("definition 应包含完整主体: %q", p.Definition)
+	}
+
+	if f := schema.Functions["f"]; f == nil || !f.IsDetermin || !strings.EqualFold(f.Returns, "int") {
+		t.Errorf("function = %+v", f)
+	}
+
+	tr := schema.Triggers["tr"]
+	if tr == nil || tr.Table != "t" || tr.Timing != "BEFORE" || tr.Event != "INSERT" || tr.Statement != "SET NEW.v = 1" {
+		t.Errorf("trigger = %+v", tr)
+	}
+}
+
+func TestParseDDLIgnoresUnrelatedStatements(t *testing.T) {
+	schema := mustParseDDL(t, `
+		/*!40101 SET NAMES utf8mb4 */;
+		SET FOREIGN_KEY_CHECKS = 0;
+		DROP TABLE IF EXISTS t;
+		USE app;
+		CREATE TABLE t (id int);
+		INSERT INTO t VALUES (1);`)
+	if len(schema.Tables) != 1 {
+		t.Errorf("tables = %v", tableNames(schema))
+	}
+}
+
+func TestParseDDLMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"缺少右括号", "CREATE TABLE t (id int"},
+		{"缺少表名", "CREATE TABLE (id int)"},
+		{"外键缺少引用", "CREATE TABLE t (a int, FOREIGN KEY (a))"},
+		{"ALTER 不存在的表", "ALTER TABLE missing ADD COLUMN a int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseDDL(tt.script, "app"); err == nil {
+				t.Errorf("ParseDDL(%q) 应返回错误", tt.script)
+			}
+		})
+	}
+}
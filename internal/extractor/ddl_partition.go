@@ -0,0 +1,161 @@
+package extractor
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// partitionBy 解析 PARTITION BY 子句
+func (p *ddlParser) partitionBy() (*PartitionInfo, error) {
+	if !p.acceptWords("PARTITION", "BY") {
+		return nil, fmt.Errorf("期望 PARTITION BY")
+	}
+
+	info := &PartitionInfo{}
+	var err error
+	if info.Method, info.Expression, err = p.partitionMethod(); err != nil {
+		return nil, err
+	}
+	count := 0
+	if p.acceptWords("PARTITIONS") {
+		count, _ = strconv.Atoi(p.value())
+	}
+
+	subCount := 0
+	if p.acceptWords("SUBPARTITION", "BY") {
+		if info.SubMethod, info.SubExpression, err = p.partitionMethod(); err != nil {
+			return nil, err
+		}
+		if p.acceptWords("SUBPARTITIONS") {
+			subCount, _ = strconv.Atoi(p.value())
+		}
+	}
+
+	if p.isPunct("(") {
+		group, err := p.parenGroup()
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range splitTopLevel(group) {
+			def, err := p.sub(item).partitionDefinition()
+			if err != nil {
+				return nil, err
+			}
+			info.Partitions = append(info.Partitions, def)
+		}
+	} else {
+		// 只指定 PARTITIONS n 时 MySQL 自动命名为 p0, p1, ...
+		for i := 0; i < count; i++ {
+			info.Partitions = append(info.Partitions, PartitionDef{Name: fmt.Sprintf("p%d", i)})
+		}
+	}
+	if len(info.Partitions) == 0 {
+		return nil, fmt.Errorf("没有分区定义")
+	}
+
+	// 未显式定义的子分区按 MySQL 规则命名为 <分区名>sp0, <分区名>sp1, ...
+	for i := range info.Partitions {
+		def := &info.Partitions[i]
+		if len(def.SubPartitions) > 0 {
+			continue
+		}
+		for j := 0; j < subCount; j++ {
+			def.SubPartitions = append(def.SubPartitions, fmt.Sprintf("%ssp%d", def.Name, j))
+		}
+	}
+
+	return info, nil
+}
+
+// partitionMethod 解析分区方式及括号中的表达式或列
+func (p *ddlParser) partitionMethod() (string, string, error) {
+	method := ""
+	if p.acceptWords("LINEAR") {
+		method = "LINEAR "
+	}
+
+	switch {
+	case p.acceptWords("HASH"):
+		method += "HASH"
+	case p.acceptWords("KEY"):
+		method += "KEY"
+		if p.acceptWords("ALGORITHM") {
+			p.skipEquals()
+			p.next()
+		}
+	case p.acceptWords("RANGE"):
+		method += "RANGE"
+	case p.acceptWords("LIST"):
+		method += "LIST"
+	default:
+		return "", "", fmt.Errorf("无法识别的分区方式 %q", p.value())
+	}
+	if (method == "RANGE" || method == "LIST") && p.acceptWords("COLUMNS") {
+		method += " COLUMNS"
+	}
+
+	group, err := p.parenGroup()
+	if err != nil {
+		return "", "", err
+	}
+	return method, p.text(group), nil
+}
+
+// partitionDefinition 解析单个分区定义
+func (p *ddlParser) partitionDefinition() (PartitionDef, error) {
+	var def PartitionDef
+	if !p.acceptWords("PARTITION") {
+		return def, fmt.Errorf("期望 PARTITION")
+	}
+	name, err := p.identifier()
+	if err != nil {
+		return def, err
+	}
+	def.Name = name
+
+	if p.acceptWords("VALUES") {
+		switch {
+		case p.acceptWords("LESS", "THAN"):
+			if p.acceptWords("MAXVALUE") {
+				def.Description = "MAXVALUE"
+				break
+			}
+			fallthrough
+		case p.acceptWords("IN"):
+			group, err := p.parenGroup()
+			if err != nil {
+				return def, err
+			}
+			def.Description = p.text(group)
+		}
+	}
+
+	for !p.eof() {
+		switch {
+		case p.acceptWords("COMMENT"):
+			p.skipEquals()
+			def.Comment = p.value()
+		case p.isPunct("("):
+			group, err := p.parenGroup()
+			if err != nil {
+				return def, err
+			}
+			for _, item := range splitTopLevel(group) {
+				sub := p.sub(item)
+				if !sub.acceptWords("SUBPARTITION") {
+					continue
+				}
+				subName, err := sub.identifier()
+				if err != nil {
+					return def, err
+				}
+				def.SubPartitions = append(def.SubPartitions, subName)
+			}
+		default:
+			// ENGINE、DATA DIRECTORY 等存储选项不参与对比
+			p.next()
+		}
+	}
+
+	return def, nil
+}
@@ -0,0 +1,88 @@
+package extractor
+
+import (
+	"strings"
+)
+
+// Identifiers 返回语句中出现的所有标识符（小写），字符串常量和注释中的内容不计入
+// 用于从视图定义和存储过程体中粗略找出引用的对象
+func Identifiers(sql string) map[string]bool {
+	names := make(map[string]bool)
+	for _, tok := range tokenizeDDL(sql) {
+		if tok.kind == tokenWord || tok.kind == tokenQuoted {
+			names[strings.ToLower(tok.value)] = true
+		}
+	}
+	return names
+}
+
+// RemoveForeignKeys 从 CREATE TABLE 语句中删除引用 refTables 中任一表的外键定义
+// 返回改写后的语句以及是否删除了外键，其余内容保持原样
+func RemoveForeignKeys(createSQL string, refTables map[string]bool) (string, bool) {
+	p := newDDLParser(createSQL)
+	open := -1
+	for i, tok := range p.tokens {
+		if tok.kind == tokenPunct && tok.value == "(" {
+			open = i
+			break
+		}
+	}
+	if open < 0 {
+		return createSQL, false
+	}
+	closePos, err := p.matchParen(open)
+	if err != nil {
+		return createSQL, false
+	}
+
+	items := splitTopLevel(p.tokens[open+1 : closePos])
+	type span struct{ start, end int }
+	var removed []span
+	kept := false
+	for i, item := range items {
+		if len(item) == 0 || !referencesTable(p.sub(item), refTables) {
+			kept = true
+			continue
+		}
+		if kept {
+			// 连同前面的逗号一起删除
+			prev := items[i-1]
+			removed = append(removed, span{prev[len(prev)-1].end, item[len(item)-1].end})
+		} else if i+1 < len(items) && len(items[i+1]) > 0 {
+			// 第一项，连同后面的逗号一起删除
+			removed = append(removed, span{item[0].start, items[i+1][0].start})
+		} else {
+			return createSQL, false
+		}
+	}
+	if len(removed) == 0 {
+		return createSQL, false
+	}
+
+	var builder strings.Builder
+	offset := 0
+	for _, s := range removed {
+		builder.WriteString(createSQL[offset:s.start])
+		offset = s.end
+	}
+	builder.WriteString(createSQL[offset:])
+	return builder.String(), true
+}
+
+// referencesTable 判断表定义项是否为引用 refTables 中任一表的外键
+func referencesTable(p *ddlParser, refTables map[string]bool) bool {
+	if p.acceptWords("CONSTRAINT") && !p.isWord("FOREIGN") {
+		p.next()
+	}
+	if !p.acceptWords("FOREIGN", "KEY") {
+		return false
+	}
+	for !p.eof() {
+		if p.acceptWords("REFERENCES") {
+			name, err := p.qualifiedName()
+			return err == nil && refTables[name]
+		}
+		p.next()
+	}
+	return false
+}
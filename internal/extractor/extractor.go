@@ -13,8 +13,10 @@ type ExtractOptions struct {
 	IncludeProcedures bool     // 是否包含存储过程
 	IncludeFunctions  bool     // 是否包含函数
 	IncludeTriggers   bool     // 是否包含触发器
+	IncludeEvents     bool     // 是否包含事件
 	TableFilter       []string // 只提取这些表（为空则提取全部）
 	ExcludeTables     []string // 排除这些表
+	Concurrency       int      // 并发执行 SHOW CREATE TABLE 的连接数（为0则使用默认值）
 }
 
 // DefaultExtractOptions 默认提取选项
@@ -25,6 +27,7 @@ func DefaultExtractOptions() ExtractOptions {
 		IncludeProcedures: true,
 		IncludeFunctions:  true,
 		IncludeTriggers:   true,
+		IncludeEvents:     true,
 	}
 }
 
@@ -54,6 +57,9 @@ type SchemaExtractor interface {
 	// ExtractTriggers 只提取触发器
 	ExtractTriggers(ctx context.Context) (map[string]*TriggerSchema, error)
 
+	// ExtractEvents 只提取事件
+	ExtractEvents(ctx context.Context) (map[string]*EventSchema, error)
+
 	// GetServerVersion 获取数据库版本
 	GetServerVersion(ctx context.Context) (string, error)
 
@@ -66,9 +72,27 @@ type SchemaExtractor interface {
 
 // NewExtractor 根据环境配置创建提取器
 func NewExtractor(env *config.Environment) (SchemaExtractor, error) {
+	if env.SchemaPath != "" {
+		return NewDDLExtractor(env.SchemaPath, env.Database), nil
+	}
 	return NewMySQLExtractor(env)
 }
 
+// Extract 连接环境并提取完整Schema，完成后自动关闭连接
+func Extract(ctx context.Context, env *config.Environment, options ExtractOptions) (*DatabaseSchema, error) {
+	ext, err := NewExtractor(env)
+	if err != nil {
+		return nil, err
+	}
+	defer ext.Close()
+
+	if err := ext.Connect(ctx); err != nil {
+		return nil, err
+	}
+
+	return ext.ExtractSchema(ctx, options)
+}
+
 // ProgressCallback 进度回调函数类型
 type ProgressCallback func(current, total int, message string)
 
@@ -94,6 +118,9 @@ func ExtractWithProgress(ctx context.Context, extractor SchemaExtractor, options
 	if options.IncludeTriggers {
 		totalSteps++
 	}
+	if options.IncludeEvents {
+		totalSteps++
+	}
 
 	// 提取表
 	if options.IncludeTables {
@@ -160,5 +187,18 @@ func ExtractWithProgress(ctx context.Context, extractor SchemaExtractor, options
 		schema.Triggers = triggers
 	}
 
+	// 提取事件
+	if options.IncludeEvents {
+		currentStep++
+		if callback != nil {
+			callback(currentStep, totalSteps, "正在提取事件...")
+		}
+		events, err := extractor.ExtractEvents(ctx)
+		if err != nil {
+			return nil, err
+		}
+		schema.Events = events
+	}
+
 	return schema, nil
 }
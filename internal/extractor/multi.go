@@ -0,0 +1,112 @@
+package extractor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/starvpn/schemapatch/internal/config"
+)
+
+// systemDatabases MySQL 自带的库，多库模式下总是跳过
+var systemDatabases = map[string]bool{
+	"mysql":              true,
+	"information_schema": true,
+	"performance_schema": true,
+	"sys":                true,
+}
+
+// ExtractDatabases 提取环境中的所有数据库，返回 库名 -> Schema
+// 环境未配置 Databases 时只提取 Database 一个库
+func ExtractDatabases(ctx context.Context, env *config.Environment, options ExtractOptions) (map[string]*DatabaseSchema, error) {
+	if len(env.Databases) == 0 {
+		schema, err := Extract(ctx, env, options)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]*DatabaseSchema{schema.Database: schema}, nil
+	}
+
+	names, err := ListDatabases(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("环境 %s 中没有匹配 %v 的数据库", env.Name, env.Databases)
+	}
+
+	schemas := make(map[string]*DatabaseSchema, len(names))
+	for _, name := range names {
+		dbEnv := *env
+		dbEnv.Databases = nil
+		dbEnv.Database = name
+		if env.SchemaPath != "" {
+			dbEnv.SchemaPath = filepath.Join(env.SchemaPath, name)
+		}
+		schema, err := Extract(ctx, &dbEnv, options)
+		if err != nil {
+			return nil, fmt.Errorf("提取数据库 %s 失败: %w", name, err)
+		}
+		schemas[name] = schema
+	}
+	return schemas, nil
+}
+
+// ListDatabases 列出环境中匹配 Databases 的库名（已排序）
+// 使用DDL文件时为 SchemaPath 下的子目录
+func ListDatabases(ctx context.Context, env *config.Environment) ([]string, error) {
+	var candidates []string
+	if env.SchemaPath != "" {
+		entries, err := os.ReadDir(env.SchemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("读取DDL目录失败: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				candidates = append(candidates, entry.Name())
+			}
+		}
+	} else {
+		ext, err := NewMySQLExtractor(env)
+		if err != nil {
+			return nil, err
+		}
+		if err := ext.Connect(ctx); err != nil {
+			return nil, err
+		}
+		defer ext.Close()
+
+		rows, err := ext.DB().QueryContext(ctx, "SELECT SCHEMA_NAME FROM information_schema.SCHEMATA")
+		if err != nil {
+			return nil, fmt.Errorf("查询数据库列表失败: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var name string
+			if err := rows.Scan(&name); err != nil {
+				return nil, fmt.Errorf("读取数据库列表失败: %w", err)
+			}
+			candidates = append(candidates, name)
+		}
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("读取数据库列表失败: %w", err)
+		}
+	}
+
+	var names []string
+	for _, name := range candidates {
+		if systemDatabases[name] {
+			continue
+		}
+		for _, pattern := range env.Databases {
+			if matched, _ := filepath.Match(pattern, name); matched {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -3,10 +3,12 @@ package extractor
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/starvpn/schemapatch/internal/config"
 )
 
@@ -73,6 +75,11 @@ func (e *MySQLExtractor) Close() error {
 	return nil
 }
 
+// DB 返回已建立的连接，未连接时为 nil
+func (e *MySQLExtractor) DB() *sql.DB {
+	return e.db
+}
+
 // TestConnection 测试连接
 func (e *MySQLExtractor) TestConnection(ctx context.Context) error {
 	if e.db == nil {
@@ -112,6 +119,11 @@ func (e *MySQLExtractor) GetServerVariables(ctx context.Context) (map[string]str
 
 // ExtractSchema 提取完整Schema
 func (e *MySQLExtractor) ExtractSchema(ctx context.Context, options ExtractOptions) (*DatabaseSchema, error) {
+	return e.extractSchema(ctx, options, nil)
+}
+
+// extractSchema 提取完整Schema，reuse 不为 nil 时复用其中未变化的表、存储过程和函数
+func (e *MySQLExtractor) extractSchema(ctx context.Context, options ExtractOptions, reuse *reusableObjects) (*DatabaseSchema, error) {
 	schema := NewDatabaseSchema(e.env.Database)
 
 	// 获取数据库字符集
@@ -126,9 +138,20 @@ func (e *MySQLExtractor) ExtractSchema(ctx context.Context, options ExtractOptio
 		schema.Collation = dbCollation
 	}
 
+	// 比较视图等定义时据此决定标识符是否区分大小写
+	if err := e.db.QueryRowContext(ctx, "SELECT @@lower_case_table_names").Scan(&schema.LowerCaseTableNames); err != nil {
+		return nil, fmt.Errorf("查询 lower_case_table_names 失败: %w", err)
+	}
+
 	// 提取表
 	if options.IncludeTables {
-		tables, err := e.ExtractTables(ctx)
+		var tables map[string]*TableSchema
+		var err error
+		if reuse == nil {
+			tables, err = e.extractTables(ctx, options.Concurrency, nil)
+		} else {
+			tables, err = e.extractChangedTables(ctx, options.Concurrency, reuse)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("提取表失败: %w", err)
 		}
@@ -146,7 +169,11 @@ func (e *MySQLExtractor) ExtractSchema(ctx context.Context, options ExtractOptio
 
 	// 提取存储过程
 	if options.IncludeProcedures {
-		procedures, err := e.ExtractProcedures(ctx)
+		var reuseProcedures map[string]*ProcedureSchema
+		if reuse != nil {
+			reuseProcedures = reuse.procedures
+		}
+		procedures, err := e.extractProcedures(ctx, reuseProcedures)
 		if err != nil {
 			return nil, fmt.Errorf("提取存储过程失败: %w", err)
 		}
@@ -155,7 +182,11 @@ func (e *MySQLExtractor) ExtractSchema(ctx context.Context, options ExtractOptio
 
 	// 提取函数
 	if options.IncludeFunctions {
-		functions, err := e.ExtractFunctions(ctx)
+		var reuseFunctions map[string]*FunctionSchema
+		if reuse != nil {
+			reuseFunctions = reuse.functions
+		}
+		functions, err := e.extractFunctions(ctx, reuseFunctions)
 		if err != nil {
 			return nil, fmt.Errorf("提取函数失败: %w", err)
 		}
@@ -171,30 +202,39 @@ func (e *MySQLExtractor) ExtractSchema(ctx context.Context, options ExtractOptio
 		schema.Triggers = triggers
 	}
 
+	// 提取事件
+	if options.IncludeEvents {
+		events, err := e.ExtractEvents(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("提取事件失败: %w", err)
+		}
+		schema.Events = events
+	}
+
 	return schema, nil
 }
 
+// DefaultExtractConcurrency 默认并发执行 SHOW CREATE TABLE 的连接数
+const DefaultExtractConcurrency = 8
+
 // ExtractTables 提取表结构
 func (e *MySQLExtractor) ExtractTables(ctx context.Context, tableNames ...string) (map[string]*TableSchema, error) {
+	return e.extractTables(ctx, DefaultExtractConcurrency, tableNames)
+}
+
+// extractTables 提取表结构
+// 列、索引、外键、CHECK约束和分区按整个库各查询一次，SHOW CREATE TABLE 以 concurrency 个连接并发执行
+func (e *MySQLExtractor) extractTables(ctx context.Context, concurrency int, tableNames []string) (map[string]*TableSchema, error) {
 	tables := make(map[string]*TableSchema)
 
 	// 查询表信息
+	filter, args := tableFilter("TABLE_NAME", e.env.Database, tableNames)
 	query := `
 		SELECT 
 			TABLE_NAME, ENGINE, TABLE_COLLATION, TABLE_COMMENT, AUTO_INCREMENT
 		FROM information_schema.TABLES 
 		WHERE TABLE_SCHEMA = ? AND TABLE_TYPE = 'BASE TABLE'
-	`
-	args := []interface{}{e.env.Database}
-
-	if len(tableNames) > 0 {
-		placeholders := make([]string, len(tableNames))
-		for i, name := range tableNames {
-			placeholders[i] = "?"
-			args = append(args, name)
-		}
-		query += " AND TABLE_NAME IN (" + strings.Join(placeholders, ",") + ")"
-	}
+	` + filter
 
 	rows, err := e.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -228,69 +268,166 @@ func (e *MySQLExtractor) ExtractTables(ctx context.Context, tableNames ...string
 
 		tables[table.Name] = &table
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(tables) == 0 {
+		return tables, nil
+	}
 
-	// 为每个表提取列、索引、外键
-	for tableName, table := range tables {
-		// 提取列
-		columns, err := e.extractColumns(ctx, tableName)
-		if err != nil {
-			return nil, fmt.Errorf("提取表 %s 的列失败: %w", tableName, err)
-		}
-		table.Columns = columns
+	// 提取列
+	columns, err := e.extractColumns(ctx, tableNames)
+	if err != nil {
+		return nil, fmt.Errorf("提取列失败: %w", err)
+	}
 
-		// 提取索引
-		indexes, err := e.extractIndexes(ctx, tableName)
-		if err != nil {
-			return nil, fmt.Errorf("提取表 %s 的索引失败: %w", tableName, err)
-		}
-		table.Indexes = indexes
+	// 提取索引
+	indexes, err := e.extractIndexes(ctx, tableNames)
+	if err != nil {
+		return nil, fmt.Errorf("提取索引失败: %w", err)
+	}
 
-		// 提取外键
-		foreignKeys, err := e.extractForeignKeys(ctx, tableName)
-		if err != nil {
-			return nil, fmt.Errorf("提取表 %s 的外键失败: %w", tableName, err)
+	// 提取外键
+	foreignKeys, err := e.extractForeignKeys(ctx, tableNames)
+	if err != nil {
+		return nil, fmt.Errorf("提取外键失败: %w", err)
+	}
+
+	// 提取CHECK约束（MySQL 8.0.16 之前没有 CHECK_CONSTRAINTS 表和 ENFORCED 列，按无约束处理）
+	checks, err := e.extractChecks(ctx, tableNames)
+	if err != nil {
+		if !isMissingSchemaObject(err) {
+			return nil, fmt.Errorf("提取CHECK约束失败: %w", err)
 		}
-		table.ForeignKeys = foreignKeys
+		checks = make(map[string]map[string]*CheckConstraint)
+	}
 
-		// 获取CREATE TABLE语句
-		createSQL, err := e.getCreateTableSQL(ctx, tableName)
-		if err == nil {
-			table.CreateSQL = createSQL
+	// 提取分区
+	partitions, err := e.extractPartitions(ctx, tableNames)
+	if err != nil {
+		return nil, fmt.Errorf("提取分区失败: %w", err)
+	}
+
+	for tableName, table := range tables {
+		table.Columns = columns[tableName]
+		table.Indexes = indexes[tableName]
+		if table.Indexes == nil {
+			table.Indexes = make(map[string]*IndexSchema)
+		}
+		table.ForeignKeys = foreignKeys[tableName]
+		if table.ForeignKeys == nil {
+			table.ForeignKeys = make(map[string]*ForeignKey)
+		}
+		table.Checks = checks[tableName]
+		if table.Checks == nil {
+			table.Checks = make(map[string]*CheckConstraint)
 		}
+		table.Partition = partitions[tableName]
+	}
+
+	// 获取CREATE TABLE语句
+	if err := e.extractCreateTableSQL(ctx, tables, concurrency); err != nil {
+		return nil, err
 	}
 
 	return tables, nil
 }
 
-// extractColumns 提取表的列
-func (e *MySQLExtractor) extractColumns(ctx context.Context, tableName string) ([]*ColumnSchema, error) {
+// tableFilter 构建只查询指定表的条件，返回附加的 SQL 片段和参数（第一个参数为库名）
+func tableFilter(column, database string, tableNames []string) (string, []interface{}) {
+	args := []interface{}{database}
+	if len(tableNames) == 0 {
+		return "", args
+	}
+
+	placeholders := make([]string, len(tableNames))
+	for i, name := range tableNames {
+		placeholders[i] = "?"
+		args = append(args, name)
+	}
+	return " AND " + column + " IN (" + strings.Join(placeholders, ",") + ")", args
+}
+
+// extractCreateTableSQL 并发获取各表的CREATE TABLE语句
+// 任一表获取失败时停止其余查询，返回第一个错误；ctx 被取消时返回 ctx.Err()
+func (e *MySQLExtractor) extractCreateTableSQL(ctx context.Context, tables map[string]*TableSchema, concurrency int) error {
+	if concurrency <= 0 {
+		concurrency = DefaultExtractConcurrency
+	}
+	e.db.SetMaxIdleConns(concurrency)
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var once sync.Once
+	var firstErr error
+	queue := make(chan *TableSchema)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for table := range queue {
+				createSQL, err := e.getCreateTableSQL(workCtx, table.Name)
+				if err != nil {
+					once.Do(func() {
+						firstErr = fmt.Errorf("获取表 %s 的建表语句失败: %w", table.Name, err)
+						cancel()
+					})
+					continue
+				}
+				table.CreateSQL = createSQL
+			}
+		}()
+	}
+
+	for _, table := range tables {
+		if workCtx.Err() != nil {
+			break
+		}
+		queue <- table
+	}
+	close(queue)
+	wg.Wait()
+
+	// 外部取消优先于取消后各查询返回的错误
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// extractColumns 提取列，返回 表名 -> 按位置排序的列
+func (e *MySQLExtractor) extractColumns(ctx context.Context, tableNames []string) (map[string][]*ColumnSchema, error) {
+	filter, args := tableFilter("TABLE_NAME", e.env.Database, tableNames)
 	query := `
 		SELECT 
-			COLUMN_NAME, ORDINAL_POSITION, DATA_TYPE, COLUMN_TYPE,
+			TABLE_NAME, COLUMN_NAME, ORDINAL_POSITION, DATA_TYPE, COLUMN_TYPE,
 			IS_NULLABLE, COLUMN_DEFAULT, EXTRA,
 			CHARACTER_MAXIMUM_LENGTH, NUMERIC_PRECISION, NUMERIC_SCALE,
 			CHARACTER_SET_NAME, COLLATION_NAME, COLUMN_COMMENT,
 			GENERATION_EXPRESSION
 		FROM information_schema.COLUMNS 
-		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
-		ORDER BY ORDINAL_POSITION
+		WHERE TABLE_SCHEMA = ?` + filter + `
+		ORDER BY TABLE_NAME, ORDINAL_POSITION
 	`
 
-	rows, err := e.db.QueryContext(ctx, query, e.env.Database, tableName)
+	rows, err := e.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var columns []*ColumnSchema
+	columns := make(map[string][]*ColumnSchema)
 	for rows.Next() {
+		var tableName string
 		var col ColumnSchema
 		var isNullable string
 		var defaultVal, extra, charsetName, collationName, comment, genExpr sql.NullString
 		var charMaxLen, numPrec, numScale sql.NullInt64
 
 		if err := rows.Scan(
-			&col.Name, &col.Position, &col.DataType, &col.ColumnType,
+			&tableName, &col.Name, &col.Position, &col.DataType, &col.ColumnType,
 			&isNullable, &defaultVal, &extra,
 			&charMaxLen, &numPrec, &numScale,
 			&charsetName, &collationName, &comment,
@@ -320,42 +457,49 @@ func (e *MySQLExtractor) extractColumns(ctx context.Context, tableName string) (
 		col.GeneratedExpr = genExpr.String
 		col.IsGenerated = genExpr.Valid && genExpr.String != ""
 
-		columns = append(columns, &col)
+		columns[tableName] = append(columns[tableName], &col)
 	}
 
-	return columns, nil
+	return columns, rows.Err()
 }
 
-// extractIndexes 提取表的索引
-func (e *MySQLExtractor) extractIndexes(ctx context.Context, tableName string) (map[string]*IndexSchema, error) {
+// extractIndexes 提取索引，返回 表名 -> 索引名 -> 索引
+func (e *MySQLExtractor) extractIndexes(ctx context.Context, tableNames []string) (map[string]map[string]*IndexSchema, error) {
+	filter, args := tableFilter("TABLE_NAME", e.env.Database, tableNames)
 	query := `
 		SELECT 
-			INDEX_NAME, NON_UNIQUE, COLUMN_NAME, SEQ_IN_INDEX,
+			TABLE_NAME, INDEX_NAME, NON_UNIQUE, COLUMN_NAME, SEQ_IN_INDEX,
 			SUB_PART, INDEX_TYPE, INDEX_COMMENT
 		FROM information_schema.STATISTICS 
-		WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?
-		ORDER BY INDEX_NAME, SEQ_IN_INDEX
+		WHERE TABLE_SCHEMA = ?` + filter + `
+		ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX
 	`
 
-	rows, err := e.db.QueryContext(ctx, query, e.env.Database, tableName)
+	rows, err := e.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	indexes := make(map[string]*IndexSchema)
+	indexes := make(map[string]map[string]*IndexSchema)
 	for rows.Next() {
-		var indexName, columnName, indexType string
+		var tableName, indexName, columnName, indexType string
 		var nonUnique int
 		var seqInIdx int
 		var subPart sql.NullInt64
 		var indexComment sql.NullString
 
-		if err := rows.Scan(&indexName, &nonUnique, &columnName, &seqInIdx, &subPart, &indexType, &indexComment); err != nil {
+		if err := rows.Scan(&tableName, &indexName, &nonUnique, &columnName, &seqInIdx, &subPart, &indexType, &indexComment); err != nil {
 			return nil, err
 		}
 
-		idx, exists := indexes[indexName]
+		tableIndexes, exists := indexes[tableName]
+		if !exists {
+			tableIndexes = make(map[string]*IndexSchema)
+			indexes[tableName] = tableIndexes
+		}
+
+		idx, exists := tableIndexes[indexName]
 		if !exists {
 			idx = &IndexSchema{
 				Name:      indexName,
@@ -379,7 +523,7 @@ func (e *MySQLExtractor) extractIndexes(ctx context.Context, tableName string) (
 				idx.Type = IndexTypeNormal
 			}
 
-			indexes[indexName] = idx
+			tableIndexes[indexName] = idx
 		}
 
 		idxCol := IndexColumn{
@@ -393,13 +537,15 @@ func (e *MySQLExtractor) extractIndexes(ctx context.Context, tableName string) (
 		idx.Columns = append(idx.Columns, idxCol)
 	}
 
-	return indexes, nil
+	return indexes, rows.Err()
 }
 
-// extractForeignKeys 提取表的外键
-func (e *MySQLExtractor) extractForeignKeys(ctx context.Context, tableName string) (map[string]*ForeignKey, error) {
+// extractForeignKeys 提取外键，返回 表名 -> 外键名 -> 外键
+func (e *MySQLExtractor) extractForeignKeys(ctx context.Context, tableNames []string) (map[string]map[string]*ForeignKey, error) {
+	filter, args := tableFilter("kcu.TABLE_NAME", e.env.Database, tableNames)
 	query := `
 		SELECT 
+			kcu.TABLE_NAME,
 			kcu.CONSTRAINT_NAME,
 			kcu.COLUMN_NAME,
 			kcu.REFERENCED_TABLE_NAME,
@@ -410,27 +556,33 @@ func (e *MySQLExtractor) extractForeignKeys(ctx context.Context, tableName strin
 		JOIN information_schema.REFERENTIAL_CONSTRAINTS rc
 			ON kcu.CONSTRAINT_NAME = rc.CONSTRAINT_NAME
 			AND kcu.TABLE_SCHEMA = rc.CONSTRAINT_SCHEMA
-		WHERE kcu.TABLE_SCHEMA = ? 
-			AND kcu.TABLE_NAME = ?
+			AND kcu.TABLE_NAME = rc.TABLE_NAME
+		WHERE kcu.TABLE_SCHEMA = ?` + filter + `
 			AND kcu.REFERENCED_TABLE_NAME IS NOT NULL
-		ORDER BY kcu.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
+		ORDER BY kcu.TABLE_NAME, kcu.CONSTRAINT_NAME, kcu.ORDINAL_POSITION
 	`
 
-	rows, err := e.db.QueryContext(ctx, query, e.env.Database, tableName)
+	rows, err := e.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	foreignKeys := make(map[string]*ForeignKey)
+	foreignKeys := make(map[string]map[string]*ForeignKey)
 	for rows.Next() {
-		var fkName, columnName, refTable, refColumn, onDelete, onUpdate string
+		var tableName, fkName, columnName, refTable, refColumn, onDelete, onUpdate string
 
-		if err := rows.Scan(&fkName, &columnName, &refTable, &refColumn, &onDelete, &onUpdate); err != nil {
+		if err := rows.Scan(&tableName, &fkName, &columnName, &refTable, &refColumn, &onDelete, &onUpdate); err != nil {
 			return nil, err
 		}
 
-		fk, exists := foreignKeys[fkName]
+		tableFKs, exists := foreignKeys[tableName]
+		if !exists {
+			tableFKs = make(map[string]*ForeignKey)
+			foreignKeys[tableName] = tableFKs
+		}
+
+		fk, exists := tableFKs[fkName]
 		if !exists {
 			fk = &ForeignKey{
 				Name:       fkName,
@@ -440,14 +592,147 @@ func (e *MySQLExtractor) extractForeignKeys(ctx context.Context, tableName strin
 				OnDelete:   onDelete,
 				OnUpdate:   onUpdate,
 			}
-			foreignKeys[fkName] = fk
+			tableFKs[fkName] = fk
 		}
 
 		fk.Columns = append(fk.Columns, columnName)
 		fk.RefColumns = append(fk.RefColumns, refColumn)
 	}
 
-	return foreignKeys, nil
+	return foreignKeys, rows.Err()
+}
+
+// extractChecks 提取CHECK约束，返回 表名 -> 约束名 -> 约束
+func (e *MySQLExtractor) extractChecks(ctx context.Context, tableNames []string) (map[string]map[string]*CheckConstraint, error) {
+	filter, args := tableFilter("tc.TABLE_NAME", e.env.Database, tableNames)
+	query := `
+		SELECT 
+			tc.TABLE_NAME, tc.CONSTRAINT_NAME, cc.CHECK_CLAUSE, tc.ENFORCED
+		FROM information_schema.TABLE_CONSTRAINTS tc
+		JOIN information_schema.CHECK_CONSTRAINTS cc
+			ON tc.CONSTRAINT_NAME = cc.CONSTRAINT_NAME
+			AND tc.CONSTRAINT_SCHEMA = cc.CONSTRAINT_SCHEMA
+		WHERE tc.TABLE_SCHEMA = ?` + filter + `
+			AND tc.CONSTRAINT_TYPE = 'CHECK'
+	`
+
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := make(map[string]map[string]*CheckConstraint)
+	for rows.Next() {
+		var tableName string
+		var check CheckConstraint
+		var enforced string
+
+		if err := rows.Scan(&tableName, &check.Name, &check.Expression, &enforced); err != nil {
+			return nil, err
+		}
+
+		check.Expression = stripOuterParens(check.Expression)
+		check.Enforced = enforced != "NO"
+		if checks[tableName] == nil {
+			checks[tableName] = make(map[string]*CheckConstraint)
+		}
+		checks[tableName][check.Name] = &check
+	}
+
+	return checks, rows.Err()
+}
+
+// isMissingSchemaObject 判断是否为表或列不存在的错误（1109 未知表、1146 表不存在、1054 未知列）
+// 旧版本 MySQL 的 information_schema 缺少部分表和列时返回这些错误
+func isMissingSchemaObject(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case 1054, 1109, 1146:
+		return true
+	}
+	return false
+}
+
+// stripOuterParens 去掉包裹整个表达式的括号
+func stripOuterParens(expr string) string {
+	expr = strings.TrimSpace(expr)
+	for len(expr) >= 2 && expr[0] == '(' && expr[len(expr)-1] == ')' {
+		depth := 0
+		for i := 0; i < len(expr); i++ {
+			switch expr[i] {
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 && i < len(expr)-1 {
+				// 第一个左括号在末尾之前就已闭合，如 (a) AND (b)
+				return expr
+			}
+		}
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	return expr
+}
+
+// extractPartitions 提取分区定义，返回 表名 -> 分区，未分区的表不在结果中
+func (e *MySQLExtractor) extractPartitions(ctx context.Context, tableNames []string) (map[string]*PartitionInfo, error) {
+	filter, args := tableFilter("TABLE_NAME", e.env.Database, tableNames)
+	query := `
+		SELECT 
+			TABLE_NAME, PARTITION_NAME, SUBPARTITION_NAME, PARTITION_METHOD, SUBPARTITION_METHOD,
+			PARTITION_EXPRESSION, SUBPARTITION_EXPRESSION, PARTITION_DESCRIPTION, PARTITION_COMMENT
+		FROM information_schema.PARTITIONS 
+		WHERE TABLE_SCHEMA = ?` + filter + ` AND PARTITION_NAME IS NOT NULL
+		ORDER BY TABLE_NAME, PARTITION_ORDINAL_POSITION, SUBPARTITION_ORDINAL_POSITION
+	`
+
+	rows, err := e.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	partitions := make(map[string]*PartitionInfo)
+	for rows.Next() {
+		var tableName, name, method string
+		var subName, subMethod, expr, subExpr, description, comment sql.NullString
+
+		if err := rows.Scan(&tableName, &name, &subName, &method, &subMethod, &expr, &subExpr, &description, &comment); err != nil {
+			return nil, err
+		}
+
+		partition := partitions[tableName]
+		if partition == nil {
+			partition = &PartitionInfo{
+				Method:        method,
+				Expression:    expr.String,
+				SubMethod:     subMethod.String,
+				SubExpression: subExpr.String,
+			}
+			partitions[tableName] = partition
+		}
+
+		// 有子分区时每个子分区一行
+		last := len(partition.Partitions) - 1
+		if last < 0 || partition.Partitions[last].Name != name {
+			partition.Partitions = append(partition.Partitions, PartitionDef{
+				Name:        name,
+				Description: description.String,
+				Comment:     comment.String,
+			})
+			last++
+		}
+		if subName.Valid {
+			partition.Partitions[last].SubPartitions = append(partition.Partitions[last].SubPartitions, subName.String)
+		}
+	}
+
+	return partitions, rows.Err()
 }
 
 // getCreateTableSQL 获取CREATE TABLE语句
@@ -494,6 +779,11 @@ func (e *MySQLExtractor) ExtractViews(ctx context.Context) (map[string]*ViewSche
 
 // ExtractProcedures 提取存储过程
 func (e *MySQLExtractor) ExtractProcedures(ctx context.Context) (map[string]*ProcedureSchema, error) {
+	return e.extractProcedures(ctx, nil)
+}
+
+// extractProcedures 提取存储过程，reuse 中的存储过程直接复用已有的定义和参数
+func (e *MySQLExtractor) extractProcedures(ctx context.Context, reuse map[string]*ProcedureSchema) (map[string]*ProcedureSchema, error) {
 	// 先获取存储过程列表和元数据
 	query := `
 		SELECT 
@@ -527,6 +817,12 @@ func (e *MySQLExtractor) ExtractProcedures(ctx context.Context) (map[string]*Pro
 
 	// 使用 SHOW CREATE PROCEDURE 获取完整定义
 	for name, proc := range procedures {
+		if cached := reuse[name]; cached != nil {
+			proc.Definition = cached.Definition
+			proc.Params = cached.Params
+			continue
+		}
+
 		createSQL, err := e.getRoutineCreateSQL(ctx, "PROCEDURE", name)
 		if err != nil {
 			// 记录警告但继续
@@ -547,6 +843,11 @@ func (e *MySQLExtractor) ExtractProcedures(ctx context.Context) (map[string]*Pro
 
 // ExtractFunctions 提取函数
 func (e *MySQLExtractor) ExtractFunctions(ctx context.Context) (map[string]*FunctionSchema, error) {
+	return e.extractFunctions(ctx, nil)
+}
+
+// extractFunctions 提取函数，reuse 中的函数直接复用已有的定义和参数
+func (e *MySQLExtractor) extractFunctions(ctx context.Context, reuse map[string]*FunctionSchema) (map[string]*FunctionSchema, error) {
 	// 先获取函数列表和元数据
 	query := `
 		SELECT 
@@ -583,6 +884,12 @@ func (e *MySQLExtractor) ExtractFunctions(ctx context.Context) (map[string]*Func
 
 	// 使用 SHOW CREATE FUNCTION 获取完整定义
 	for name, fn := range functions {
+		if cached := reuse[name]; cached != nil {
+			fn.Definition = cached.Definition
+			fn.Params = cached.Params
+			continue
+		}
+
 		createSQL, err := e.getRoutineCreateSQL(ctx, "FUNCTION", name)
 		if err != nil {
 			fn.Definition = ""
@@ -691,3 +998,58 @@ func (e *MySQLExtractor) ExtractTriggers(ctx context.Context) (map[string]*Trigg
 
 	return triggers, nil
 }
+
+// ExtractEvents 提取事件
+func (e *MySQLExtractor) ExtractEvents(ctx context.Context) (map[string]*EventSchema, error) {
+	query := `
+		SELECT 
+			EVENT_NAME, DEFINER, EVENT_TYPE, EXECUTE_AT, INTERVAL_VALUE, INTERVAL_FIELD,
+			STARTS, ENDS, STATUS, ON_COMPLETION, EVENT_DEFINITION, EVENT_COMMENT, SQL_MODE
+		FROM information_schema.EVENTS 
+		WHERE EVENT_SCHEMA = ?
+	`
+
+	rows, err := e.db.QueryContext(ctx, query, e.env.Database)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make(map[string]*EventSchema)
+	for rows.Next() {
+		var event EventSchema
+		var definer, intervalValue, intervalField, comment, sqlMode sql.NullString
+		var executeAt, starts, ends sql.NullTime
+
+		if err := rows.Scan(&event.Name, &definer, &event.EventType, &executeAt, &intervalValue, &intervalField,
+			&starts, &ends, &event.Status, &event.OnCompletion, &event.Body, &comment, &sqlMode); err != nil {
+			return nil, err
+		}
+
+		event.Definer = definer.String
+		event.ExecuteAt = formatEventTime(executeAt)
+		event.IntervalValue = intervalValue.String
+		event.IntervalField = intervalField.String
+		event.Starts = formatEventTime(starts)
+		event.Ends = formatEventTime(ends)
+		event.Comment = comment.String
+		event.SQLMode = sqlMode.String
+
+		// 带冒号等分隔符的复合间隔需要加引号，如 '1:30' HOUR_MINUTE
+		if strings.ContainsAny(event.IntervalValue, ": -.") {
+			event.IntervalValue = "'" + event.IntervalValue + "'"
+		}
+
+		events[event.Name] = &event
+	}
+
+	return events, nil
+}
+
+// formatEventTime 格式化事件调度时间
+func formatEventTime(t sql.NullTime) string {
+	if !t.Valid {
+		return ""
+	}
+	return t.Time.Format("2006-01-02 15:04:05")
+}
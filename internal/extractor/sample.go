@@ -0,0 +1,106 @@
+package extractor
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SampleRows 从表中读取最多 limit 行数据，按 columns 顺序返回，nil 表示 NULL
+// 时间类型格式化为 MySQL 字面量，其他类型保持原始字节
+func (e *MySQLExtractor) SampleRows(ctx context.Context, tableName string, columns []string, limit int) ([][]*string, error) {
+	if e.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	quoted := make([]string, len(columns))
+	for i, col := range columns {
+		quoted[i] = "`" + strings.ReplaceAll(col, "`", "``") + "`"
+	}
+	query := fmt.Sprintf("SELECT %s FROM `%s` LIMIT %d",
+		strings.Join(quoted, ", "), strings.ReplaceAll(tableName, "`", "``"), limit)
+
+	rows, err := e.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("读取表 %s 数据失败: %w", tableName, err)
+	}
+	defer rows.Close()
+
+	var result [][]*string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("读取表 %s 数据失败: %w", tableName, err)
+		}
+
+		row := make([]*string, len(columns))
+		for i, value := range values {
+			row[i] = sampleValue(value)
+		}
+		result = append(result, row)
+	}
+
+	return result, rows.Err()
+}
+
+// sampleValue 将驱动返回的值转换为字符串
+func sampleValue(value interface{}) *string {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		s = string(v)
+	case time.Time:
+		s = v.Format("2006-01-02 15:04:05.999999")
+	default:
+		s = fmt.Sprint(v)
+	}
+	return &s
+}
+
+// QueryInts 执行只返回一行整数的只读统计查询，按列顺序返回结果，NULL 视为 0
+func (e *MySQLExtractor) QueryInts(ctx context.Context, query string) ([]int64, error) {
+	if e.db == nil {
+		return nil, fmt.Errorf("数据库未连接")
+	}
+
+	rows, err := e.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullInt64, len(columns))
+	if rows.Next() {
+		dest := make([]interface{}, len(values))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]int64, len(values))
+	for i, v := range values {
+		result[i] = v.Int64
+	}
+	return result, nil
+}
@@ -1,34 +1,40 @@
 package extractor
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
 // DatabaseSchema 数据库完整Schema
 type DatabaseSchema struct {
-	Database    string                      `json:"database"`
-	Charset     string                      `json:"charset"`
-	Collation   string                      `json:"collation"`
-	Tables      map[string]*TableSchema     `json:"tables"`
-	Views       map[string]*ViewSchema      `json:"views"`
-	Procedures  map[string]*ProcedureSchema `json:"procedures"`
-	Functions   map[string]*FunctionSchema  `json:"functions"`
-	Triggers    map[string]*TriggerSchema   `json:"triggers"`
-	ExtractedAt time.Time                   `json:"extracted_at"`
+	Database            string                      `json:"database"`
+	Charset             string                      `json:"charset"`
+	Collation           string                      `json:"collation"`
+	LowerCaseTableNames int                         `json:"lower_case_table_names,omitempty"` // 服务器的 lower_case_table_names，不为 0 时标识符不区分大小写；DDL文件为 0
+	Tables              map[string]*TableSchema     `json:"tables"`
+	Views               map[string]*ViewSchema      `json:"views"`
+	Procedures          map[string]*ProcedureSchema `json:"procedures"`
+	Functions           map[string]*FunctionSchema  `json:"functions"`
+	Triggers            map[string]*TriggerSchema   `json:"triggers"`
+	Events              map[string]*EventSchema     `json:"events"`
+	ExtractedAt         time.Time                   `json:"extracted_at"`
 }
 
 // TableSchema 表结构
 type TableSchema struct {
-	Name        string                   `json:"name"`
-	Engine      string                   `json:"engine"`
-	Charset     string                   `json:"charset"`
-	Collation   string                   `json:"collation"`
-	Comment     string                   `json:"comment"`
-	AutoIncr    int64                    `json:"auto_incr"`
-	Columns     []*ColumnSchema          `json:"columns"`
-	Indexes     map[string]*IndexSchema  `json:"indexes"`
-	ForeignKeys map[string]*ForeignKey   `json:"foreign_keys"`
-	CreateSQL   string                   `json:"create_sql"`
+	Name        string                      `json:"name"`
+	Engine      string                      `json:"engine"`
+	Charset     string                      `json:"charset"`
+	Collation   string                      `json:"collation"`
+	Comment     string                      `json:"comment"`
+	AutoIncr    int64                       `json:"auto_incr"`
+	Columns     []*ColumnSchema             `json:"columns"`
+	Indexes     map[string]*IndexSchema     `json:"indexes"`
+	ForeignKeys map[string]*ForeignKey      `json:"foreign_keys"`
+	Checks      map[string]*CheckConstraint `json:"checks,omitempty"`    // CHECK 约束 (MySQL 8.0.16+)
+	Partition   *PartitionInfo              `json:"partition,omitempty"` // nil 表示未分区
+	CreateSQL   string                      `json:"create_sql"`
 }
 
 // ColumnSchema 列结构
@@ -108,6 +114,48 @@ type ForeignKey struct {
 	OnUpdate         string   `json:"on_update"`
 }
 
+// CheckConstraint CHECK 约束
+type CheckConstraint struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"` // 约束表达式（不含外层括号）
+	Enforced   bool   `json:"enforced"`   // NOT ENFORCED 时为 false
+}
+
+// PartitionInfo 表分区定义
+type PartitionInfo struct {
+	Method        string         `json:"method"`                   // RANGE, RANGE COLUMNS, LIST, LIST COLUMNS, [LINEAR] HASH, [LINEAR] KEY
+	Expression    string         `json:"expression"`               // 分区表达式或分区列
+	SubMethod     string         `json:"sub_method,omitempty"`     // 子分区方式: [LINEAR] HASH, [LINEAR] KEY
+	SubExpression string         `json:"sub_expression,omitempty"` // 子分区表达式或列
+	Partitions    []PartitionDef `json:"partitions"`
+}
+
+// PartitionDef 单个分区
+type PartitionDef struct {
+	Name          string   `json:"name"`
+	Description   string   `json:"description,omitempty"` // RANGE 的 LESS THAN 边界或 LIST 的值列表
+	Comment       string   `json:"comment,omitempty"`
+	SubPartitions []string `json:"sub_partitions,omitempty"`
+}
+
+// IsRange 是否为 RANGE / RANGE COLUMNS 分区
+func (p *PartitionInfo) IsRange() bool {
+	return strings.HasPrefix(p.Method, "RANGE")
+}
+
+// IsList 是否为 LIST / LIST COLUMNS 分区
+func (p *PartitionInfo) IsList() bool {
+	return strings.HasPrefix(p.Method, "LIST")
+}
+
+// SubPartitionCount 每个分区的子分区数量
+func (p *PartitionInfo) SubPartitionCount() int {
+	if len(p.Partitions) == 0 {
+		return 0
+	}
+	return len(p.Partitions[0].SubPartitions)
+}
+
 // ViewSchema 视图结构
 type ViewSchema struct {
 	Name       string `json:"name"`
@@ -160,6 +208,52 @@ type TriggerSchema struct {
 	SQLMode    string `json:"sql_mode"`
 }
 
+// EventSchema 事件调度器结构
+type EventSchema struct {
+	Name          string `json:"name"`
+	Definer       string `json:"definer"`
+	EventType     string `json:"event_type"`               // ONE TIME, RECURRING
+	ExecuteAt     string `json:"execute_at,omitempty"`     // 一次性事件的执行时间
+	IntervalValue string `json:"interval_value,omitempty"` // 周期事件的间隔数值，如 1 或 '1:30'
+	IntervalField string `json:"interval_field,omitempty"` // 周期事件的间隔单位，如 DAY, HOUR_MINUTE
+	Starts        string `json:"starts,omitempty"`
+	Ends          string `json:"ends,omitempty"`
+	Status        string `json:"status"`        // ENABLED, DISABLED, SLAVESIDE_DISABLED
+	OnCompletion  string `json:"on_completion"` // PRESERVE, NOT PRESERVE
+	Body          string `json:"body"`          // DO 之后的事件体
+	Comment       string `json:"comment"`
+	SQLMode       string `json:"sql_mode"`
+}
+
+// IsRecurring 是否为周期执行的事件
+func (e *EventSchema) IsRecurring() bool {
+	return e.EventType == "RECURRING"
+}
+
+// Schedule 返回 ON SCHEDULE 之后的调度子句，如 EVERY 1 DAY STARTS '...'
+func (e *EventSchema) Schedule() string {
+	if !e.IsRecurring() {
+		return "AT " + eventTimeLiteral(e.ExecuteAt)
+	}
+
+	schedule := fmt.Sprintf("EVERY %s %s", e.IntervalValue, e.IntervalField)
+	if e.Starts != "" {
+		schedule += " STARTS " + eventTimeLiteral(e.Starts)
+	}
+	if e.Ends != "" {
+		schedule += " ENDS " + eventTimeLiteral(e.Ends)
+	}
+	return schedule
+}
+
+// eventTimeLiteral 将具体时间加上引号，DDL中的时间表达式（如 CURRENT_TIMESTAMP + INTERVAL 1 DAY）原样返回
+func eventTimeLiteral(value string) string {
+	if value != "" && value[0] >= '0' && value[0] <= '9' {
+		return "'" + value + "'"
+	}
+	return value
+}
+
 // NewDatabaseSchema 创建空的数据库Schema
 func NewDatabaseSchema(database string) *DatabaseSchema {
 	return &DatabaseSchema{
@@ -169,6 +263,7 @@ func NewDatabaseSchema(database string) *DatabaseSchema {
 		Procedures:  make(map[string]*ProcedureSchema),
 		Functions:   make(map[string]*FunctionSchema),
 		Triggers:    make(map[string]*TriggerSchema),
+		Events:      make(map[string]*EventSchema),
 		ExtractedAt: time.Now(),
 	}
 }
@@ -207,6 +302,7 @@ func (s *DatabaseSchema) Clone() *DatabaseSchema {
 	clone := NewDatabaseSchema(s.Database)
 	clone.Charset = s.Charset
 	clone.Collation = s.Collation
+	clone.LowerCaseTableNames = s.LowerCaseTableNames
 	clone.ExtractedAt = s.ExtractedAt
 
 	// 复制表
@@ -234,6 +330,11 @@ func (s *DatabaseSchema) Clone() *DatabaseSchema {
 		clone.Triggers[name] = trigger
 	}
 
+	// 复制事件
+	for name, event := range s.Events {
+		clone.Events[name] = event
+	}
+
 	return clone
 }
 
@@ -245,5 +346,6 @@ func (s *DatabaseSchema) Statistics() map[string]int {
 		"procedures": len(s.Procedures),
 		"functions":  len(s.Functions),
 		"triggers":   len(s.Triggers),
+		"events":     len(s.Events),
 	}
 }
@@ -0,0 +1,121 @@
+package extractor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// SnapshotVersion 当前快照文件格式版本
+const SnapshotVersion = 1
+
+// Snapshot Schema快照文件
+type Snapshot struct {
+	Version   int             `json:"version"`
+	Source    string          `json:"source"` // 快照来源（环境名称等）
+	CreatedAt time.Time       `json:"created_at"`
+	Schema    *DatabaseSchema `json:"schema"`
+}
+
+// NewSnapshot 基于Schema创建快照
+func NewSnapshot(schema *DatabaseSchema, source string) *Snapshot {
+	return &Snapshot{
+		Version:   SnapshotVersion,
+		Source:    source,
+		CreatedAt: time.Now(),
+		Schema:    schema,
+	}
+}
+
+// WriteSnapshot 将快照写入输出流
+func WriteSnapshot(w io.Writer, snapshot *Snapshot) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(snapshot)
+}
+
+// ReadSnapshot 从输入流读取快照
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.NewDecoder(r).Decode(&snapshot); err != nil {
+		return nil, fmt.Errorf("解析快照失败: %w", err)
+	}
+
+	if snapshot.Version == 0 || snapshot.Version > SnapshotVersion {
+		return nil, fmt.Errorf("不支持的快照版本: %d (当前支持 %d)", snapshot.Version, SnapshotVersion)
+	}
+	if snapshot.Schema == nil {
+		return nil, fmt.Errorf("快照中没有Schema数据")
+	}
+
+	snapshot.Schema.ensureMaps()
+	return &snapshot, nil
+}
+
+// SaveSnapshot 将Schema保存为快照文件
+func SaveSnapshot(schema *DatabaseSchema, source, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建快照文件失败: %w", err)
+	}
+
+	if err := WriteSnapshot(file, NewSnapshot(schema, source)); err != nil {
+		file.Close()
+		return fmt.Errorf("写入快照失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入快照失败: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot 从快照文件加载Schema
+func LoadSnapshot(path string) (*DatabaseSchema, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开快照文件失败: %w", err)
+	}
+	defer file.Close()
+
+	snapshot, err := ReadSnapshot(file)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.Schema, nil
+}
+
+// ensureMaps 确保反序列化后的空集合可以直接使用
+func (s *DatabaseSchema) ensureMaps() {
+	if s.Tables == nil {
+		s.Tables = make(map[string]*TableSchema)
+	}
+	if s.Views == nil {
+		s.Views = make(map[string]*ViewSchema)
+	}
+	if s.Procedures == nil {
+		s.Procedures = make(map[string]*ProcedureSchema)
+	}
+	if s.Functions == nil {
+		s.Functions = make(map[string]*FunctionSchema)
+	}
+	if s.Triggers == nil {
+		s.Triggers = make(map[string]*TriggerSchema)
+	}
+	if s.Events == nil {
+		s.Events = make(map[string]*EventSchema)
+	}
+
+	for _, table := range s.Tables {
+		if table.Indexes == nil {
+			table.Indexes = make(map[string]*IndexSchema)
+		}
+		if table.ForeignKeys == nil {
+			table.ForeignKeys = make(map[string]*ForeignKey)
+		}
+		if table.Checks == nil {
+			table.Checks = make(map[string]*CheckConstraint)
+		}
+	}
+}
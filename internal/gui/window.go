@@ -3,6 +3,8 @@ package gui
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -43,6 +45,7 @@ type MainWindow struct {
 	ignoreComments  *widget.Check
 	ignoreCharset   *widget.Check
 	ignoreCollation *widget.Check
+	ignoreOrder     *widget.Check
 
 	// 按钮
 	compareBtn  *widget.Button
@@ -89,6 +92,10 @@ func (mw *MainWindow) buildUI() {
 	})
 	mw.ignoreCollation.SetChecked(true) // 默认忽略排序规则
 
+	mw.ignoreOrder = widget.NewCheck("忽略列顺序差异", func(checked bool) {
+		mw.updateIgnoreRules()
+	})
+
 	// 设置变更回调 - 自动保存配置
 	mw.sourceEnvPanel.SetOnChanged(mw.saveConfig)
 	mw.targetEnvPanel.SetOnChanged(mw.saveConfig)
@@ -111,6 +118,7 @@ func (mw *MainWindow) buildUI() {
 		mw.ignoreComments,
 		mw.ignoreCharset,
 		mw.ignoreCollation,
+		mw.ignoreOrder,
 	)
 
 	compareRow := container.NewHBox(
@@ -205,6 +213,9 @@ func (mw *MainWindow) createDiffTree() *widget.Tree {
 				if len(mw.schemaDiff.TriggerDiffs) > 0 {
 					roots = append(roots, "triggers")
 				}
+				if len(mw.schemaDiff.EventDiffs) > 0 {
+					roots = append(roots, "events")
+				}
 				return roots
 			}
 
@@ -239,13 +250,19 @@ func (mw *MainWindow) createDiffTree() *widget.Tree {
 					items = append(items, "trigger:"+td.TriggerName)
 				}
 				return items
+			case "events":
+				var items []string
+				for _, ed := range mw.schemaDiff.EventDiffs {
+					items = append(items, "event:"+ed.EventName)
+				}
+				return items
 			}
 
 			return []string{}
 		},
 		// isBranch
 		func(uid string) bool {
-			return uid == "" || uid == "tables" || uid == "views" || uid == "procedures" || uid == "functions" || uid == "triggers"
+			return uid == "" || uid == "tables" || uid == "views" || uid == "procedures" || uid == "functions" || uid == "triggers" || uid == "events"
 		},
 		// create
 		func(branch bool) fyne.CanvasObject {
@@ -266,6 +283,8 @@ func (mw *MainWindow) createDiffTree() *widget.Tree {
 				label.SetText(fmt.Sprintf("🔧 函数 (%d)", len(mw.schemaDiff.FuncDiffs)))
 			case "triggers":
 				label.SetText(fmt.Sprintf("⚡ 触发器 (%d)", len(mw.schemaDiff.TriggerDiffs)))
+			case "events":
+				label.SetText(fmt.Sprintf("⏰ 事件 (%d)", len(mw.schemaDiff.EventDiffs)))
 			default:
 				// 具体项
 				if len(uid) > 6 && uid[:6] == "table:" {
@@ -318,6 +337,16 @@ func (mw *MainWindow) createDiffTree() *widget.Tree {
 							break
 						}
 					}
+				} else if len(uid) > 6 && uid[:6] == "event:" {
+					eventName := uid[6:]
+					for _, ed := range mw.schemaDiff.EventDiffs {
+						if ed.EventName == eventName {
+							icon := diff.GetSeverityIcon(ed.Severity)
+							typeIcon := diff.GetDiffTypeIcon(ed.DiffType)
+							label.SetText(fmt.Sprintf("%s %s %s - %s", icon, typeIcon, eventName, ed.Description))
+							break
+						}
+					}
 				}
 			}
 		},
@@ -326,6 +355,15 @@ func (mw *MainWindow) createDiffTree() *widget.Tree {
 	return tree
 }
 
+// extractSchema 提取Schema，通过本地缓存只重新提取上次对比之后变更过的表、存储过程和函数
+func extractSchema(ctx context.Context, ext *extractor.MySQLExtractor) (*extractor.DatabaseSchema, error) {
+	cacheDir, err := config.CacheDir()
+	if err != nil {
+		return ext.ExtractSchema(ctx, extractor.DefaultExtractOptions())
+	}
+	return ext.ExtractSchemaCached(ctx, extractor.DefaultExtractOptions(), cacheDir)
+}
+
 // onCompare 对比按钮点击
 func (mw *MainWindow) onCompare() {
 	mw.setStatus("正在对比...")
@@ -347,7 +385,7 @@ func (mw *MainWindow) onCompare() {
 			return
 		}
 
-		// 提取源Schema
+		// 连接源环境
 		mw.setStatus("正在连接开发环境...")
 		mw.progressBar.SetValue(0.1)
 
@@ -367,21 +405,9 @@ func (mw *MainWindow) onCompare() {
 			return
 		}
 
-		mw.setStatus("正在提取开发环境Schema...")
-		mw.progressBar.SetValue(0.3)
-
-		sourceSchema, err := sourceExtractor.ExtractSchema(ctx, extractor.DefaultExtractOptions())
-		if err != nil {
-			mw.showError("提取开发环境Schema失败: " + err.Error())
-			mw.compareBtn.Enable()
-			mw.progressBar.Hide()
-			return
-		}
-		mw.sourceSchema = sourceSchema
-
-		// 提取目标Schema
+		// 连接目标环境
 		mw.setStatus("正在连接生产环境...")
-		mw.progressBar.SetValue(0.5)
+		mw.progressBar.SetValue(0.3)
 
 		targetExtractor, err := extractor.NewMySQLExtractor(targetEnv)
 		if err != nil {
@@ -399,16 +425,37 @@ func (mw *MainWindow) onCompare() {
 			return
 		}
 
-		mw.setStatus("正在提取生产环境Schema...")
-		mw.progressBar.SetValue(0.7)
+		// 两侧并发提取
+		mw.setStatus("正在提取开发环境和生产环境Schema...")
+		mw.progressBar.SetValue(0.5)
 
-		targetSchema, err := targetExtractor.ExtractSchema(ctx, extractor.DefaultExtractOptions())
-		if err != nil {
-			mw.showError("提取生产环境Schema失败: " + err.Error())
+		var sourceSchema, targetSchema *extractor.DatabaseSchema
+		var sourceErr, targetErr error
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sourceSchema, sourceErr = extractSchema(ctx, sourceExtractor)
+		}()
+		go func() {
+			defer wg.Done()
+			targetSchema, targetErr = extractSchema(ctx, targetExtractor)
+		}()
+		wg.Wait()
+
+		if sourceErr != nil {
+			mw.showError("提取开发环境Schema失败: " + sourceErr.Error())
 			mw.compareBtn.Enable()
 			mw.progressBar.Hide()
 			return
 		}
+		if targetErr != nil {
+			mw.showError("提取生产环境Schema失败: " + targetErr.Error())
+			mw.compareBtn.Enable()
+			mw.progressBar.Hide()
+			return
+		}
+		mw.sourceSchema = sourceSchema
 		mw.targetSchema = targetSchema
 
 		// 执行对比
@@ -417,11 +464,17 @@ func (mw *MainWindow) onCompare() {
 
 		project := mw.store.GetActiveProject()
 		var ignoreRules config.IgnoreConfig
+		var renameRules config.RenameConfig
+		var definerPolicy config.DefinerPolicy
 		if project != nil {
 			ignoreRules = project.IgnoreRules
+			renameRules = project.RenameRules
+			definerPolicy = project.DefinerPolicy
 		}
 
 		diffEngine := diff.NewDiffEngine(ignoreRules)
+		diffEngine.SetRenameRules(renameRules)
+		diffEngine.SetDefinerPolicy(definerPolicy)
 		mw.schemaDiff = diffEngine.Compare(sourceSchema, targetSchema)
 
 		mw.progressBar.SetValue(1.0)
@@ -457,6 +510,9 @@ func (mw *MainWindow) onGenerate() {
 	generator := sqlgen.NewMySQLGenerator()
 	options := sqlgen.DefaultGenerateOptions()
 	options.AddComments = true
+	if project := mw.store.GetActiveProject(); project != nil {
+		options.DefinerPolicy = project.DefinerPolicy
+	}
 
 	script, err := generator.Generate(mw.schemaDiff, options)
 	if err != nil {
@@ -504,6 +560,10 @@ func (mw *MainWindow) onValidate() {
 		defer validator.Cleanup(ctx)
 
 		options := docker.DefaultValidationOptions()
+		if project := mw.store.GetActiveProject(); project != nil {
+			options.IgnoreRules = project.IgnoreRules
+			options.DefinerPolicy = project.DefinerPolicy
+		}
 
 		// sourceSchema: 开发环境（升级目标）, targetSchema: 生产环境（当前状态）
 		result, err := validator.Validate(ctx, mw.sourceSchema, mw.targetSchema, mw.script, options,
@@ -524,6 +584,10 @@ func (mw *MainWindow) onValidate() {
 
 		if err != nil {
 			logText.SetText(logText.Text + fmt.Sprintf("\n❌ 验证失败: %s\n", err.Error()))
+		} else if result.Success && result.RemainingDiff != nil && result.RemainingDiff.HasDiff() {
+			// 脚本执行成功但未完全收敛，列出仍存在的差异
+			logText.SetText(logText.Text + fmt.Sprintf("\n⚠️ 脚本执行成功，但升级后仍有 %d 项差异:\n  %s\n",
+				result.RemainingDiff.Statistics.TotalDiffs, strings.Join(result.SchemaDiffs, "\n  ")))
 		} else if result.Success {
 			logText.SetText(logText.Text + fmt.Sprintf("\n✅ 验证成功! 耗时: %v\n", result.ExecutionTime))
 		} else {
@@ -619,6 +683,9 @@ func (mw *MainWindow) loadConfig() {
 	if mw.ignoreCollation != nil {
 		mw.ignoreCollation.SetChecked(project.IgnoreRules.IgnoreCollation)
 	}
+	if mw.ignoreOrder != nil {
+		mw.ignoreOrder.SetChecked(project.IgnoreRules.IgnoreColumnOrder)
+	}
 }
 
 // saveConfig 保存配置
@@ -656,6 +723,9 @@ func (mw *MainWindow) saveConfig() {
 	if mw.ignoreCollation != nil {
 		project.IgnoreRules.IgnoreCollation = mw.ignoreCollation.Checked
 	}
+	if mw.ignoreOrder != nil {
+		project.IgnoreRules.IgnoreColumnOrder = mw.ignoreOrder.Checked
+	}
 
 	// 保存
 	if err := mw.store.UpdateProject(*project); err != nil {
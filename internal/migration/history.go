@@ -0,0 +1,88 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/config"
+)
+
+// HistoryEntry 环境中已执行的迁移版本
+type HistoryEntry struct {
+	Version    int       `json:"version"`
+	Name       string    `json:"name"`
+	Checksum   string    `json:"checksum"`
+	ScriptID   string    `json:"script_id"` // 对应执行记录表中的脚本标识
+	DurationMs int64     `json:"duration_ms"`
+	AppliedAt  time.Time `json:"applied_at"`
+}
+
+// History 目标库中的 schemapatch_history 表
+type History struct {
+	db *sql.DB
+}
+
+// NewHistory 创建迁移历史
+func NewHistory(db *sql.DB) *History {
+	return &History{db: db}
+}
+
+// Init 创建迁移历史表
+func (h *History) Init(ctx context.Context) error {
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS `%s` ("+
+		"`version` int NOT NULL, "+
+		"`name` varchar(255) NOT NULL, "+
+		"`checksum` char(64) NOT NULL, "+
+		"`script_id` char(64) NOT NULL, "+
+		"`duration_ms` bigint NOT NULL, "+
+		"`applied_at` datetime(3) NOT NULL, "+
+		"PRIMARY KEY (`version`)"+
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", config.HistoryTable)
+	if _, err := h.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("创建迁移历史表失败: %w", err)
+	}
+	return nil
+}
+
+// Load 读取已执行的版本，按版本号排序；历史表不存在时返回空
+func (h *History) Load(ctx context.Context) ([]HistoryEntry, error) {
+	var name string
+	err := h.db.QueryRowContext(ctx, "SHOW TABLES LIKE ?", config.HistoryTable).Scan(&name)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移历史失败: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT `version`, `name`, `checksum`, `script_id`, `duration_ms`, `applied_at` FROM `%s` ORDER BY `version`",
+		config.HistoryTable)
+	rows, err := h.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移历史失败: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var entry HistoryEntry
+		if err := rows.Scan(&entry.Version, &entry.Name, &entry.Checksum, &entry.ScriptID, &entry.DurationMs, &entry.AppliedAt); err != nil {
+			return nil, fmt.Errorf("读取迁移历史失败: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Record 记录执行成功的版本
+func (h *History) Record(ctx context.Context, entry HistoryEntry) error {
+	query := fmt.Sprintf("INSERT INTO `%s` (`version`, `name`, `checksum`, `script_id`, `duration_ms`, `applied_at`) VALUES (?, ?, ?, ?, ?, ?)",
+		config.HistoryTable)
+	if _, err := h.db.ExecContext(ctx, query, entry.Version, entry.Name, entry.Checksum, entry.ScriptID,
+		entry.DurationMs, entry.AppliedAt); err != nil {
+		return fmt.Errorf("写入迁移历史失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package migration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/extractor"
+	"github.com/starvpn/schemapatch/internal/sqlgen"
+)
+
+// DefaultDir 项目未配置迁移目录时使用的目录
+const DefaultDir = "migrations"
+
+// Migration 版本化迁移文件
+// 每个版本由 0001_name.up.sql、0001_name.down.sql 和执行后的期望Schema快照 0001_name.schema.json 组成
+type Migration struct {
+	Version    int    `json:"version"`
+	Name       string `json:"name"`
+	UpPath     string `json:"up_path"`
+	DownPath   string `json:"down_path,omitempty"`
+	SchemaPath string `json:"schema_path,omitempty"`
+	Checksum   string `json:"checksum"` // 升级文件内容的散列
+}
+
+// ID 返回带版本号的名称，如 0001_add_users
+func (m *Migration) ID() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// migrationFile 迁移文件名格式
+var migrationFile = regexp.MustCompile(`^(\d+)_(.+)\.(up\.sql|down\.sql|schema\.json)$`)
+
+// List 读取目录中的迁移，按版本号排序
+func List(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取迁移目录失败: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("迁移版本 %d 重复: %s 与 %s", version, m.Name, match[2])
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		switch match[3] {
+		case "up.sql":
+			m.UpPath = path
+		case "down.sql":
+			m.DownPath = path
+		case "schema.json":
+			m.SchemaPath = path
+		}
+	}
+
+	var migrations []Migration
+	for _, m := range byVersion {
+		if m.UpPath == "" {
+			return nil, fmt.Errorf("迁移 %s 缺少升级文件", m.ID())
+		}
+		content, err := os.ReadFile(m.UpPath)
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+		}
+		m.Checksum = Checksum(string(content))
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Create 将脚本写入下一个版本的迁移文件，expected 为执行后的期望Schema（用于检测漂移）
+func Create(dir, name string, script *sqlgen.MigrationScript, expected *extractor.DatabaseSchema) (*Migration, error) {
+	for _, stmt := range script.Statements {
+		if stmt.OnlineCommand != "" {
+			return nil, fmt.Errorf("版本化迁移不支持在线变更工具，请去掉 gh-ost / pt-osc 选项")
+		}
+	}
+
+	migrations, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建迁移目录失败: %w", err)
+	}
+
+	m := &Migration{Version: 1, Name: slug(name)}
+	if len(migrations) > 0 {
+		m.Version = migrations[len(migrations)-1].Version + 1
+	}
+	m.UpPath = filepath.Join(dir, m.ID()+".up.sql")
+	m.DownPath = filepath.Join(dir, m.ID()+".down.sql")
+
+	up := renderUp(m, script)
+	if err := writeNew(m.UpPath, up); err != nil {
+		return nil, err
+	}
+	if err := writeNew(m.DownPath, renderDown(m, script)); err != nil {
+		return nil, err
+	}
+	if expected != nil {
+		m.SchemaPath = filepath.Join(dir, m.ID()+".schema.json")
+		if err := extractor.SaveSnapshot(expected, m.ID(), m.SchemaPath); err != nil {
+			return nil, err
+		}
+	}
+	m.Checksum = Checksum(up)
+	return m, nil
+}
+
+// Script 读取升级文件并拆分为语句
+func (m *Migration) Script() (*sqlgen.MigrationScript, error) {
+	content, err := os.ReadFile(m.UpPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移文件失败: %w", err)
+	}
+
+	script := &sqlgen.MigrationScript{
+		Version:     m.ID(),
+		Description: m.Name,
+		UpSQL:       string(content),
+	}
+	for _, stmt := range extractor.SplitSQLStatements(string(content)) {
+		if isCommentOnly(stmt) {
+			continue
+		}
+		script.Statements = append(script.Statements, sqlgen.SQLStatement{
+			SQL:        stmt,
+			ObjectType: "MIGRATION",
+			ObjectName: m.ID(),
+			Operation:  firstKeyword(stmt),
+			Severity:   diff.SeverityInfo,
+		})
+	}
+	return script, nil
+}
+
+// Checksum 计算迁移文件内容的散列，忽略换行符差异
+func Checksum(content string) string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(content, "\r\n", "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// renderUp 生成升级文件内容
+func renderUp(m *Migration, script *sqlgen.MigrationScript) string {
+	var builder strings.Builder
+	writeHeader(&builder, m, "升级", script)
+	for _, stmt := range script.Statements {
+		writeStatement(&builder, stmt.Comment, stmt.ObjectType, stmt.SQL)
+	}
+	return builder.String()
+}
+
+// renderDown 生成回滚文件内容，语句逆序排列，无法自动回滚的语句以注释保留
+func renderDown(m *Migration, script *sqlgen.MigrationScript) string {
+	var builder strings.Builder
+	writeHeader(&builder, m, "回滚", script)
+	for i := len(script.Statements) - 1; i >= 0; i-- {
+		stmt := script.Statements[i]
+		if stmt.ObjectType == "SESSION" {
+			// 会话设置不需要回滚
+			continue
+		}
+		if stmt.RollbackSQL == "" {
+			builder.WriteString(fmt.Sprintf("-- 无法自动回滚: %s\n\n", stmt.Comment))
+			continue
+		}
+		writeStatement(&builder, "回滚: "+stmt.Comment, stmt.ObjectType, stmt.RollbackSQL)
+	}
+	return builder.String()
+}
+
+// writeHeader 写入文件头注释
+func writeHeader(builder *strings.Builder, m *Migration, kind string, script *sqlgen.MigrationScript) {
+	builder.WriteString("-- ============================================\n")
+	builder.WriteString(fmt.Sprintf("-- SchemaPatch 迁移 %s（%s）\n", m.ID(), kind))
+	builder.WriteString(fmt.Sprintf("-- %s\n", script.Description))
+	builder.WriteString(fmt.Sprintf("-- 生成时间: %s\n", time.Now().Format("2006-01-02 15:04:05")))
+	builder.WriteString("-- ============================================\n\n")
+}
+
+// writeStatement 写入一条语句，触发器、存储过程、函数和事件使用 DELIMITER 包裹
+// DELIMITER 指令必须位于语句开头，因此注释写在指令之后
+func writeStatement(builder *strings.Builder, comment, objectType, sql string) {
+	delimited := objectType == "TRIGGER" || objectType == "PROCEDURE" || objectType == "FUNCTION" || objectType == "EVENT"
+	if delimited {
+		builder.WriteString("DELIMITER $$\n")
+	}
+	if comment != "" {
+		builder.WriteString("-- " + comment + "\n")
+	}
+	if delimited {
+		builder.WriteString(strings.TrimSuffix(strings.TrimSpace(sql), ";"))
+		builder.WriteString("\n$$\nDELIMITER ;\n\n")
+		return
+	}
+	builder.WriteString(sql)
+	builder.WriteString("\n\n")
+}
+
+// writeNew 创建文件，文件已存在时报错以免覆盖已发布的迁移
+func writeNew(path, content string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("创建迁移文件失败: %w", err)
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return fmt.Errorf("写入迁移文件失败: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("写入迁移文件失败: %w", err)
+	}
+	return nil
+}
+
+// slug 将名称转换为文件名安全的形式
+func slug(name string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			builder.WriteRune(r)
+		default:
+			builder.WriteByte('_')
+		}
+	}
+	s := strings.Trim(builder.String(), "_")
+	if s == "" {
+		return "migration"
+	}
+	return s
+}
+
+// isCommentOnly 语句是否只包含注释
+func isCommentOnly(stmt string) bool {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") && !strings.HasPrefix(line, "#") {
+			return false
+		}
+	}
+	return true
+}
+
+// firstKeyword 返回语句跳过注释后的第一个关键字
+func firstKeyword(stmt string) string {
+	for _, line := range strings.Split(stmt, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "--") || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.ToUpper(strings.Fields(line)[0])
+	}
+	return ""
+}
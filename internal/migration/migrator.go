@@ -0,0 +1,183 @@
+package migration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/executor"
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// State 迁移版本在环境中的状态
+type State string
+
+const (
+	StateApplied    State = "applied"      // 已执行
+	StatePending    State = "pending"      // 待执行
+	StateModified   State = "modified"     // 执行后文件被修改，校验和不一致
+	StateMissing    State = "missing"      // 已执行但迁移文件不存在
+	StateOutOfOrder State = "out_of_order" // 版本号小于已执行的最大版本
+)
+
+// VersionStatus 单个版本的状态
+type VersionStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	State     State      `json:"state"`
+	Checksum  string     `json:"checksum,omitempty"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Migration *Migration `json:"-"`
+}
+
+// Status 对照迁移文件和迁移历史，返回每个版本的状态
+func Status(migrations []Migration, history []HistoryEntry) []VersionStatus {
+	applied := make(map[int]HistoryEntry)
+	maxApplied := 0
+	for _, entry := range history {
+		applied[entry.Version] = entry
+		if entry.Version > maxApplied {
+			maxApplied = entry.Version
+		}
+	}
+
+	var statuses []VersionStatus
+	files := make(map[int]bool)
+	for i := range migrations {
+		m := &migrations[i]
+		files[m.Version] = true
+		status := VersionStatus{Version: m.Version, Name: m.Name, Checksum: m.Checksum, Migration: m}
+		if entry, ok := applied[m.Version]; ok {
+			appliedAt := entry.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.State = StateApplied
+			if entry.Checksum != m.Checksum {
+				status.State = StateModified
+			}
+		} else if m.Version < maxApplied {
+			status.State = StateOutOfOrder
+		} else {
+			status.State = StatePending
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, entry := range history {
+		if !files[entry.Version] {
+			appliedAt := entry.AppliedAt
+			statuses = append(statuses, VersionStatus{
+				Version:   entry.Version,
+				Name:      entry.Name,
+				State:     StateMissing,
+				Checksum:  entry.Checksum,
+				AppliedAt: &appliedAt,
+			})
+		}
+	}
+	return statuses
+}
+
+// Validate 检查是否存在被修改、丢失或乱序的版本
+func Validate(statuses []VersionStatus) error {
+	var problems []string
+	for _, status := range statuses {
+		id := fmt.Sprintf("%04d_%s", status.Version, status.Name)
+		switch status.State {
+		case StateModified:
+			problems = append(problems, id+" 执行后被修改")
+		case StateMissing:
+			problems = append(problems, id+" 已执行但文件不存在")
+		case StateOutOfOrder:
+			problems = append(problems, id+" 版本号小于已执行的版本")
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("迁移校验失败: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Up 按版本顺序执行待执行的迁移，每个版本执行成功后写入迁移历史
+// onVersion 在每个版本开始执行前回调
+func Up(ctx context.Context, x *executor.Executor, migrations []Migration, options executor.Options, onVersion func(m *Migration)) ([]HistoryEntry, error) {
+	history := NewHistory(x.DB())
+	if err := history.Init(ctx); err != nil {
+		return nil, err
+	}
+	entries, err := history.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := Status(migrations, entries)
+	if err := Validate(statuses); err != nil {
+		return nil, err
+	}
+
+	var applied []HistoryEntry
+	for _, status := range statuses {
+		if status.State != StatePending {
+			continue
+		}
+		m := status.Migration
+		if onVersion != nil {
+			onVersion(m)
+		}
+
+		script, err := m.Script()
+		if err != nil {
+			return applied, err
+		}
+		result, err := x.Apply(ctx, script, options)
+		if err != nil {
+			return applied, fmt.Errorf("迁移 %s 执行失败: %w", m.ID(), err)
+		}
+
+		entry := HistoryEntry{
+			Version:    m.Version,
+			Name:       m.Name,
+			Checksum:   m.Checksum,
+			ScriptID:   result.ScriptID,
+			DurationMs: result.Duration.Milliseconds(),
+			AppliedAt:  time.Now(),
+		}
+		if err := history.Record(ctx, entry); err != nil {
+			return applied, err
+		}
+		applied = append(applied, entry)
+	}
+	return applied, nil
+}
+
+// Drift 将环境的当前Schema与最后一个已执行版本的期望Schema对比，返回迁移之外的变更
+// 最后一个已执行版本没有期望Schema快照时返回 nil
+func Drift(statuses []VersionStatus, live *extractor.DatabaseSchema, ignoreRules config.IgnoreConfig) (*diff.SchemaDiff, *Migration, error) {
+	var latest *Migration
+	for _, status := range statuses {
+		if status.State == StateApplied || status.State == StateModified {
+			latest = status.Migration
+		}
+	}
+	if latest == nil || latest.SchemaPath == "" {
+		return nil, latest, nil
+	}
+
+	expected, err := extractor.LoadSnapshot(latest.SchemaPath)
+	if err != nil {
+		return nil, latest, err
+	}
+
+	// 视图定义中带有库名限定，统一为期望Schema的库名
+	if expected.Database != "" && expected.Database != live.Database {
+		for _, view := range live.Views {
+			view.Definition = strings.ReplaceAll(view.Definition, "`"+live.Database+"`.", "`"+expected.Database+"`.")
+		}
+	}
+
+	engine := diff.NewDiffEngine(ignoreRules)
+	engine.SetRenameRules(config.RenameConfig{DisableHeuristic: true})
+	return engine.Compare(expected, live), latest, nil
+}
@@ -0,0 +1,127 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// generateEventStatements 生成事件变更语句，返回删除语句和创建/修改语句
+// 事件在创建或修改时记录会话的 sql_mode，与存储过程相同在源环境的 sql_mode 下执行，回滚使用目标环境的 sql_mode
+func (g *MySQLGenerator) generateEventStatements(ed *diff.EventDiff, options GenerateOptions) ([]SQLStatement, []SQLStatement) {
+	switch ed.DiffType {
+	case diff.DiffTypeAdded:
+		if ed.NewEvent == nil {
+			return nil, nil
+		}
+		return nil, []SQLStatement{{
+			SQL:         g.buildCreateEventSQL(ed.NewEvent, extractor.DefinerClause(options.DefinerPolicy, ed.NewEvent.Definer)),
+			ObjectType:  "EVENT",
+			ObjectName:  ed.EventName,
+			Operation:   "CREATE",
+			Severity:    diff.SeverityInfo,
+			Comment:     "创建事件",
+			RollbackSQL: fmt.Sprintf("DROP EVENT IF EXISTS `%s`;", ed.EventName),
+			sqlMode:     ed.NewEvent.SQLMode,
+		}}
+	case diff.DiffTypeRemoved:
+		stmt := SQLStatement{
+			SQL:        fmt.Sprintf("DROP EVENT IF EXISTS `%s`;", ed.EventName),
+			ObjectType: "EVENT",
+			ObjectName: ed.EventName,
+			Operation:  "DROP",
+			Severity:   diff.SeverityWarning,
+			Comment:    "删除事件",
+		}
+		if ed.OldEvent != nil {
+			stmt.RollbackSQL = g.sqlModeScript(g.buildCreateEventSQL(ed.OldEvent, originalDefiner(ed.OldEvent.Definer)), ed.OldEvent.SQLMode)
+		}
+		return []SQLStatement{stmt}, nil
+	case diff.DiffTypeModified:
+		if ed.OldEvent == nil || ed.NewEvent == nil {
+			return nil, nil
+		}
+		var changed []string
+		for _, prop := range ed.PropertyDiffs {
+			changed = append(changed, prop.Property)
+		}
+		return nil, []SQLStatement{{
+			SQL:         g.buildAlterEventSQL(ed.NewEvent, ed.PropertyDiffs, extractor.DefinerClause(options.DefinerPolicy, ed.NewEvent.Definer)),
+			ObjectType:  "EVENT",
+			ObjectName:  ed.EventName,
+			Operation:   "ALTER",
+			Severity:    ed.Severity,
+			Comment:     fmt.Sprintf("修改事件%s", strings.Join(changed, "、")),
+			RollbackSQL: g.sqlModeScript(g.buildAlterEventSQL(ed.OldEvent, ed.PropertyDiffs, originalDefiner(ed.OldEvent.Definer)), ed.OldEvent.SQLMode),
+			sqlMode:     ed.NewEvent.SQLMode,
+		}}
+	}
+	return nil, nil
+}
+
+// buildCreateEventSQL 构建创建事件语句，definer 为 DEFINER 子句（含末尾空格），为空时由执行脚本的用户作为 DEFINER
+func (g *MySQLGenerator) buildCreateEventSQL(event *extractor.EventSchema, definer string) string {
+	sql := fmt.Sprintf("CREATE %sEVENT `%s` ON SCHEDULE %s ON COMPLETION %s %s",
+		definer, event.Name, event.Schedule(), event.OnCompletion, eventStatusClause(event.Status))
+	if event.Comment != "" {
+		sql += fmt.Sprintf(" COMMENT '%s'", g.escapeString(event.Comment))
+	}
+	return sql + " DO " + strings.TrimSuffix(strings.TrimSpace(event.Body), ";") + ";"
+}
+
+// buildAlterEventSQL 构建修改事件语句，只包含发生变化的子句；DEFINER 变化时写出 definer 子句
+func (g *MySQLGenerator) buildAlterEventSQL(event *extractor.EventSchema, props []diff.PropertyDiff, definer string) string {
+	changed := make(map[string]bool)
+	for _, prop := range props {
+		changed[prop.Property] = true
+	}
+
+	// ALTER EVENT 的子句必须按语法顺序出现，事件体在最后
+	var clauses []string
+	if changed["调度"] {
+		clauses = append(clauses, "ON SCHEDULE "+event.Schedule())
+	}
+	if changed["完成后"] {
+		clauses = append(clauses, "ON COMPLETION "+event.OnCompletion)
+	}
+	if changed["状态"] {
+		clauses = append(clauses, eventStatusClause(event.Status))
+	}
+	if changed["注释"] {
+		clauses = append(clauses, fmt.Sprintf("COMMENT '%s'", g.escapeString(event.Comment)))
+	}
+	if changed["事件体"] {
+		clauses = append(clauses, "DO "+strings.TrimSuffix(strings.TrimSpace(event.Body), ";"))
+	}
+	if len(clauses) == 0 {
+		// 只有 DEFINER 或 sql_mode 变化，ALTER EVENT 至少需要一个子句，重复当前状态
+		clauses = append(clauses, eventStatusClause(event.Status))
+	}
+
+	if !changed["定义者"] {
+		definer = ""
+	}
+	return fmt.Sprintf("ALTER %sEVENT `%s` %s;", definer, event.Name, strings.Join(clauses, " "))
+}
+
+// originalDefiner 返回恢复目标环境原有 DEFINER 的子句（含末尾空格），DEFINER 为空时返回空字符串
+func originalDefiner(definer string) string {
+	if definer == "" {
+		return ""
+	}
+	return "DEFINER=" + extractor.QuoteDefiner(definer) + " "
+}
+
+// eventStatusClause 返回事件状态对应的子句
+func eventStatusClause(status string) string {
+	switch status {
+	case "DISABLED":
+		return "DISABLE"
+	case "SLAVESIDE_DISABLED":
+		return "DISABLE ON SLAVE"
+	default:
+		return "ENABLE"
+	}
+}
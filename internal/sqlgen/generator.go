@@ -5,18 +5,24 @@ import (
 	"strings"
 	"time"
 
+	"github.com/starvpn/schemapatch/internal/config"
 	"github.com/starvpn/schemapatch/internal/diff"
 	"github.com/starvpn/schemapatch/internal/extractor"
 )
 
 // GenerateOptions 生成选项
 type GenerateOptions struct {
-	IncludeRollback  bool   // 是否生成回滚脚本
-	WrapTransaction  bool   // 是否包装事务
-	AddComments      bool   // 是否添加注释说明
-	SafeMode         bool   // 安全模式（危险操作需确认）
-	OnlineMode       bool   // 在线变更模式（大表友好）
-	Delimiter        string // 语句分隔符
+	IncludeRollback bool                 // 是否生成回滚脚本
+	WrapTransaction bool                 // 是否包装事务
+	AddComments     bool                 // 是否添加注释说明
+	SafeMode        bool                 // 安全模式（危险操作需确认）
+	OnlineMode      bool                 // 在线变更模式（大表友好）
+	OnlineTool      string               // 在线变更工具: native(默认), gh-ost, pt-osc
+	OnlineToolArgs  string               // 追加到 gh-ost / pt-osc 命令的参数（如连接信息）
+	Delimiter       string               // 语句分隔符
+	TargetVersion   string               // 目标库MySQL版本，为空时按8.0处理
+	SplitAlters     bool                 // 每项列/索引变更单独一条 ALTER（默认按表合并）
+	DefinerPolicy   config.DefinerPolicy // 视图、存储过程、函数和触发器的 DEFINER 处理策略
 }
 
 // DefaultGenerateOptions 默认生成选项
@@ -33,25 +39,32 @@ func DefaultGenerateOptions() GenerateOptions {
 
 // MigrationScript 迁移脚本
 type MigrationScript struct {
-	Version       string          `json:"version"`
-	Description   string          `json:"description"`
-	UpSQL         string          `json:"up_sql"`
-	DownSQL       string          `json:"down_sql"`
-	Statements    []SQLStatement  `json:"statements"`
-	Warnings      []string        `json:"warnings"`
-	EstimatedTime time.Duration   `json:"estimated_time"`
-	GeneratedAt   time.Time       `json:"generated_at"`
+	Version        string         `json:"version"`
+	Description    string         `json:"description"`
+	UpSQL          string         `json:"up_sql"`
+	DownSQL        string         `json:"down_sql"`
+	Statements     []SQLStatement `json:"statements"`
+	Warnings       []string       `json:"warnings"`
+	OnlineCommands []string       `json:"online_commands,omitempty"` // gh-ost / pt-osc 命令
+	EstimatedTime  time.Duration  `json:"estimated_time"`
+	GeneratedAt    time.Time      `json:"generated_at"`
 }
 
 // SQLStatement SQL语句
 type SQLStatement struct {
-	SQL        string            `json:"sql"`
-	ObjectType string            `json:"object_type"` // TABLE, INDEX, VIEW...
-	ObjectName string            `json:"object_name"`
-	Operation  string            `json:"operation"`   // CREATE, ALTER, DROP
-	Severity   diff.DiffSeverity `json:"severity"`
-	Comment    string            `json:"comment"`
-	RollbackSQL string           `json:"rollback_sql,omitempty"`
+	SQL           string            `json:"sql"`
+	ObjectType    string            `json:"object_type"` // TABLE, INDEX, VIEW...
+	ObjectName    string            `json:"object_name"`
+	Operation     string            `json:"operation"` // CREATE, ALTER, DROP
+	Severity      diff.DiffSeverity `json:"severity"`
+	Comment       string            `json:"comment"`
+	RollbackSQL   string            `json:"rollback_sql,omitempty"`
+	Algorithm     string            `json:"algorithm,omitempty"`      // 预计的 Online DDL 算法: INSTANT, INPLACE, COPY
+	Lock          string            `json:"lock,omitempty"`           // INPLACE 时允许的锁级别
+	OnlineCommand string            `json:"online_command,omitempty"` // 在线变更工具命令
+
+	clauses []string // 合并语句包含的子句
+	sqlMode string   // 需要在该 sql_mode 下执行，生成脚本时在前后加上切换和恢复会话 sql_mode 的语句
 }
 
 // SQLGenerator SQL生成器接口
@@ -78,6 +91,7 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 	}
 
 	// 按依赖顺序生成SQL
+	// 0. 重命名表（后续语句均使用新表名）
 	// 1. 先删除外键约束
 	// 2. 删除触发器
 	// 3. 删除视图、存储过程、函数
@@ -85,11 +99,14 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 	// 5. 创建新表
 	// 6. 添加列
 	// 7. 创建索引
-	// 8. 创建外键
-	// 9. 创建视图、存储过程、函数、触发器
+	// 8. 调整分区
+	// 9. 创建外键
+	// 10. 创建函数、存储过程、视图（按相互引用排序）、触发器、事件
 
 	// 收集所有需要删除的外键
+	var renameTableStatements []SQLStatement
 	var dropFKStatements []SQLStatement
+	var dropEventStatements []SQLStatement
 	var dropTriggerStatements []SQLStatement
 	var dropViewStatements []SQLStatement
 	var dropProcStatements []SQLStatement
@@ -98,11 +115,13 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 	var alterTableStatements []SQLStatement
 	var createTableStatements []SQLStatement
 	var createIndexStatements []SQLStatement
+	var partitionStatements []SQLStatement
 	var createFKStatements []SQLStatement
 	var createTriggerStatements []SQLStatement
 	var createViewStatements []SQLStatement
 	var createProcStatements []SQLStatement
 	var createFuncStatements []SQLStatement
+	var createEventStatements []SQLStatement
 
 	// 处理表差异
 	for _, td := range schemaDiff.TableDiffs {
@@ -147,6 +166,19 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 			script.Warnings = append(script.Warnings,
 				fmt.Sprintf("删除表 `%s` 将导致所有数据永久丢失", td.TableName))
 
+		case diff.DiffTypeRenamed:
+			// 重命名最先执行，之后的语句均使用新表名
+			renameTableStatements = append(renameTableStatements, SQLStatement{
+				SQL:         fmt.Sprintf("RENAME TABLE `%s` TO `%s`;", td.OldName, td.TableName),
+				ObjectType:  "TABLE",
+				ObjectName:  td.TableName,
+				Operation:   "RENAME",
+				Severity:    td.Severity,
+				Comment:     fmt.Sprintf("重命名表 %s -> %s", td.OldName, td.TableName),
+				RollbackSQL: fmt.Sprintf("RENAME TABLE `%s` TO `%s`;", td.TableName, td.OldName),
+			})
+			fallthrough
+
 		case diff.DiffTypeModified:
 			// 处理外键变更
 			for _, fkd := range td.FKeyDiffs {
@@ -159,6 +191,7 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 						Operation:  "DROP",
 						Severity:   diff.SeverityWarning,
 						Comment:    "删除外键约束",
+						Algorithm:  AlgorithmInplace,
 					})
 				case diff.DiffTypeAdded:
 					if fkd.NewFKey != nil {
@@ -173,6 +206,7 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 						Operation:  "DROP",
 						Severity:   diff.SeverityWarning,
 						Comment:    "删除外键约束（将重建）",
+						Algorithm:  AlgorithmInplace,
 					})
 					if fkd.NewFKey != nil {
 						createFKStatements = append(createFKStatements, g.generateAddForeignKey(td.TableName, fkd.NewFKey))
@@ -180,6 +214,13 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 				}
 			}
 
+			// 处理CHECK约束变更，与外键一样在列变更之前删除、之后添加
+			for _, ckd := range td.CheckDiffs {
+				drops, adds := g.generateCheckStatements(td.TableName, &ckd)
+				dropFKStatements = append(dropFKStatements, drops...)
+				createFKStatements = append(createFKStatements, adds...)
+			}
+
 			// 处理索引变更
 			for _, id := range td.IndexDiffs {
 				stmts := g.generateIndexStatements(td.TableName, &id)
@@ -194,17 +235,29 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 
 			// 处理列变更
 			for _, cd := range td.ColumnDiffs {
-				stmts := g.generateColumnStatements(td.TableName, &cd)
+				stmts := g.generateColumnStatements(&td, &cd, options)
 				alterTableStatements = append(alterTableStatements, stmts...)
 			}
 
 			// 处理表属性变更
 			for _, prop := range td.TableProps {
-				stmt := g.generateTablePropertyStatement(td.TableName, &prop)
+				stmt := g.generateTablePropertyStatement(td.TableName, &prop, options)
 				if stmt != nil {
 					alterTableStatements = append(alterTableStatements, *stmt)
 				}
 			}
+
+			// 处理分区变更（分区操作不能与其他 ALTER 子句合并）
+			if td.PartitionDiff != nil {
+				partitionStatements = append(partitionStatements, g.generatePartitionStatements(td.TableName, td.PartitionDiff)...)
+				if len(td.PartitionDiff.Removed) > 0 {
+					script.Warnings = append(script.Warnings,
+						fmt.Sprintf("删除表 `%s` 的分区 %s 将导致其中的数据永久丢失", td.TableName, strings.Join(td.PartitionDiff.Removed, ", ")))
+				}
+			}
+
+			// 数据预检发现的阻断问题
+			script.Warnings = append(script.Warnings, blockingCheckWarnings(&td)...)
 		}
 	}
 
@@ -214,7 +267,7 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 		case diff.DiffTypeAdded:
 			if vd.NewView != nil {
 				createViewStatements = append(createViewStatements, SQLStatement{
-					SQL:        fmt.Sprintf("CREATE VIEW `%s` AS %s;", vd.ViewName, vd.NewView.Definition),
+					SQL:        fmt.Sprintf("CREATE %sVIEW `%s` AS %s;", extractor.DefinerClause(options.DefinerPolicy, vd.NewView.Definer), vd.ViewName, vd.NewView.Definition),
 					ObjectType: "VIEW",
 					ObjectName: vd.ViewName,
 					Operation:  "CREATE",
@@ -234,7 +287,7 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 		case diff.DiffTypeModified:
 			if vd.NewView != nil {
 				createViewStatements = append(createViewStatements, SQLStatement{
-					SQL:        fmt.Sprintf("CREATE OR REPLACE VIEW `%s` AS %s;", vd.ViewName, vd.NewView.Definition),
+					SQL:        fmt.Sprintf("CREATE OR REPLACE %sVIEW `%s` AS %s;", extractor.DefinerClause(options.DefinerPolicy, vd.NewView.Definer), vd.ViewName, vd.NewView.Definition),
 					ObjectType: "VIEW",
 					ObjectName: vd.ViewName,
 					Operation:  "ALTER",
@@ -251,12 +304,13 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 		case diff.DiffTypeAdded:
 			if pd.NewProc != nil && pd.NewProc.Definition != "" {
 				createProcStatements = append(createProcStatements, SQLStatement{
-					SQL:        pd.NewProc.Definition + ";",
+					SQL:        extractor.ApplyDefinerPolicy(options.DefinerPolicy, pd.NewProc.Definition, pd.NewProc.Definer) + ";",
 					ObjectType: "PROCEDURE",
 					ObjectName: pd.ProcName,
 					Operation:  "CREATE",
 					Severity:   diff.SeverityInfo,
 					Comment:    "创建存储过程",
+					sqlMode:    pd.NewProc.SQLMode,
 				})
 			}
 		case diff.DiffTypeRemoved:
@@ -269,22 +323,44 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 				Comment:    "删除存储过程",
 			})
 		case diff.DiffTypeModified:
-			dropProcStatements = append(dropProcStatements, SQLStatement{
+			// 只有安全性、注释变化时直接 ALTER
+			if pd.OldProc != nil && pd.NewProc != nil && alterableOnly(pd.PropertyDiffs) {
+				createProcStatements = append(createProcStatements, SQLStatement{
+					SQL:         g.buildAlterRoutineSQL("PROCEDURE", pd.ProcName, pd.NewProc.Security, pd.NewProc.Comment, pd.PropertyDiffs),
+					ObjectType:  "PROCEDURE",
+					ObjectName:  pd.ProcName,
+					Operation:   "ALTER",
+					Severity:    pd.Severity,
+					Comment:     "修改存储过程特性",
+					RollbackSQL: g.buildAlterRoutineSQL("PROCEDURE", pd.ProcName, pd.OldProc.Security, pd.OldProc.Comment, pd.PropertyDiffs),
+				})
+				continue
+			}
+			if warning := sqlModeWarning("存储过程", pd.ProcName, pd.PropertyDiffs); warning != "" {
+				script.Warnings = append(script.Warnings, warning)
+			}
+			drop := SQLStatement{
 				SQL:        fmt.Sprintf("DROP PROCEDURE IF EXISTS `%s`;", pd.ProcName),
 				ObjectType: "PROCEDURE",
 				ObjectName: pd.ProcName,
 				Operation:  "DROP",
 				Severity:   diff.SeverityWarning,
 				Comment:    "删除存储过程（将重建）",
-			})
+			}
+			if pd.OldProc != nil && pd.OldProc.Definition != "" {
+				drop.RollbackSQL = g.sqlModeScript(pd.OldProc.Definition+";", pd.OldProc.SQLMode)
+			}
+			dropProcStatements = append(dropProcStatements, drop)
 			if pd.NewProc != nil && pd.NewProc.Definition != "" {
 				createProcStatements = append(createProcStatements, SQLStatement{
-					SQL:        pd.NewProc.Definition + ";",
-					ObjectType: "PROCEDURE",
-					ObjectName: pd.ProcName,
-					Operation:  "CREATE",
-					Severity:   diff.SeverityWarning,
-					Comment:    "重建存储过程",
+					SQL:         extractor.ApplyDefinerPolicy(options.DefinerPolicy, pd.NewProc.Definition, pd.NewProc.Definer) + ";",
+					ObjectType:  "PROCEDURE",
+					ObjectName:  pd.ProcName,
+					Operation:   "CREATE",
+					Severity:    diff.SeverityWarning,
+					Comment:     "重建存储过程",
+					sqlMode:     pd.NewProc.SQLMode,
+					RollbackSQL: fmt.Sprintf("DROP PROCEDURE IF EXISTS `%s`;", pd.ProcName),
 				})
 			}
 		}
@@ -296,12 +372,13 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 		case diff.DiffTypeAdded:
 			if fd.NewFunc != nil && fd.NewFunc.Definition != "" {
 				createFuncStatements = append(createFuncStatements, SQLStatement{
-					SQL:        fd.NewFunc.Definition + ";",
+					SQL:        extractor.ApplyDefinerPolicy(options.DefinerPolicy, fd.NewFunc.Definition, fd.NewFunc.Definer) + ";",
 					ObjectType: "FUNCTION",
 					ObjectName: fd.FuncName,
 					Operation:  "CREATE",
 					Severity:   diff.SeverityInfo,
 					Comment:    "创建函数",
+					sqlMode:    fd.NewFunc.SQLMode,
 				})
 			}
 		case diff.DiffTypeRemoved:
@@ -314,22 +391,44 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 				Comment:    "删除函数",
 			})
 		case diff.DiffTypeModified:
-			dropFuncStatements = append(dropFuncStatements, SQLStatement{
+			// 只有安全性、注释变化时直接 ALTER
+			if fd.OldFunc != nil && fd.NewFunc != nil && alterableOnly(fd.PropertyDiffs) {
+				createFuncStatements = append(createFuncStatements, SQLStatement{
+					SQL:         g.buildAlterRoutineSQL("FUNCTION", fd.FuncName, fd.NewFunc.Security, fd.NewFunc.Comment, fd.PropertyDiffs),
+					ObjectType:  "FUNCTION",
+					ObjectName:  fd.FuncName,
+					Operation:   "ALTER",
+					Severity:    fd.Severity,
+					Comment:     "修改函数特性",
+					RollbackSQL: g.buildAlterRoutineSQL("FUNCTION", fd.FuncName, fd.OldFunc.Security, fd.OldFunc.Comment, fd.PropertyDiffs),
+				})
+				continue
+			}
+			if warning := sqlModeWarning("函数", fd.FuncName, fd.PropertyDiffs); warning != "" {
+				script.Warnings = append(script.Warnings, warning)
+			}
+			drop := SQLStatement{
 				SQL:        fmt.Sprintf("DROP FUNCTION IF EXISTS `%s`;", fd.FuncName),
 				ObjectType: "FUNCTION",
 				ObjectName: fd.FuncName,
 				Operation:  "DROP",
 				Severity:   diff.SeverityWarning,
 				Comment:    "删除函数（将重建）",
-			})
+			}
+			if fd.OldFunc != nil && fd.OldFunc.Definition != "" {
+				drop.RollbackSQL = g.sqlModeScript(fd.OldFunc.Definition+";", fd.OldFunc.SQLMode)
+			}
+			dropFuncStatements = append(dropFuncStatements, drop)
 			if fd.NewFunc != nil && fd.NewFunc.Definition != "" {
 				createFuncStatements = append(createFuncStatements, SQLStatement{
-					SQL:        fd.NewFunc.Definition + ";",
-					ObjectType: "FUNCTION",
-					ObjectName: fd.FuncName,
-					Operation:  "CREATE",
-					Severity:   diff.SeverityWarning,
-					Comment:    "重建函数",
+					SQL:         extractor.ApplyDefinerPolicy(options.DefinerPolicy, fd.NewFunc.Definition, fd.NewFunc.Definer) + ";",
+					ObjectType:  "FUNCTION",
+					ObjectName:  fd.FuncName,
+					Operation:   "CREATE",
+					Severity:    diff.SeverityWarning,
+					Comment:     "重建函数",
+					sqlMode:     fd.NewFunc.SQLMode,
+					RollbackSQL: fmt.Sprintf("DROP FUNCTION IF EXISTS `%s`;", fd.FuncName),
 				})
 			}
 		}
@@ -341,7 +440,8 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 		case diff.DiffTypeAdded:
 			if td.NewTrigger != nil {
 				createTriggerStatements = append(createTriggerStatements, SQLStatement{
-					SQL: fmt.Sprintf("CREATE TRIGGER `%s` %s %s ON `%s` FOR EACH ROW %s;",
+					SQL: fmt.Sprintf("CREATE %sTRIGGER `%s` %s %s ON `%s` FOR EACH ROW %s;",
+						extractor.DefinerClause(options.DefinerPolicy, td.NewTrigger.Definer),
 						td.TriggerName, td.NewTrigger.Timing, td.NewTrigger.Event,
 						td.NewTrigger.Table, td.NewTrigger.Statement),
 					ObjectType: "TRIGGER",
@@ -371,7 +471,8 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 			})
 			if td.NewTrigger != nil {
 				createTriggerStatements = append(createTriggerStatements, SQLStatement{
-					SQL: fmt.Sprintf("CREATE TRIGGER `%s` %s %s ON `%s` FOR EACH ROW %s;",
+					SQL: fmt.Sprintf("CREATE %sTRIGGER `%s` %s %s ON `%s` FOR EACH ROW %s;",
+						extractor.DefinerClause(options.DefinerPolicy, td.NewTrigger.Definer),
 						td.TriggerName, td.NewTrigger.Timing, td.NewTrigger.Event,
 						td.NewTrigger.Table, td.NewTrigger.Statement),
 					ObjectType: "TRIGGER",
@@ -384,21 +485,60 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 		}
 	}
 
+	// 处理事件差异
+	for i := range schemaDiff.EventDiffs {
+		drops, creates := g.generateEventStatements(&schemaDiff.EventDiffs[i], options)
+		dropEventStatements = append(dropEventStatements, drops...)
+		createEventStatements = append(createEventStatements, creates...)
+	}
+
+	// 按依赖关系排序：被外键引用的新表先创建，循环外键拆成单独的 ALTER；
+	// 视图、存储过程和函数按定义中引用的对象排序，删除时顺序相反
+	createTableStatements, deferredFKs, orderWarnings := g.orderCreateTables(schemaDiff, createTableStatements)
+	createFKStatements = append(deferredFKs, createFKStatements...)
+	script.Warnings = append(script.Warnings, orderWarnings...)
+	dropTableStatements = orderDropTables(schemaDiff, dropTableStatements)
+
+	var dropDefinitionStatements []SQLStatement
+	dropDefinitionStatements = append(dropDefinitionStatements, dropViewStatements...)
+	dropDefinitionStatements = append(dropDefinitionStatements, dropProcStatements...)
+	dropDefinitionStatements = append(dropDefinitionStatements, dropFuncStatements...)
+	dropDefinitionStatements = orderDropDefinitions(dropDefinitionStatements, definitionsOf(schemaDiff, false))
+
+	var createDefinitionStatements []SQLStatement
+	createDefinitionStatements = append(createDefinitionStatements, createFuncStatements...)
+	createDefinitionStatements = append(createDefinitionStatements, createProcStatements...)
+	createDefinitionStatements = append(createDefinitionStatements, createViewStatements...)
+	createDefinitionStatements = orderDefinitions(createDefinitionStatements, definitionsOf(schemaDiff, true))
+
+	// 同一张表的变更合并为一条 ALTER，避免大表多次重建
+	// 外键的删除和添加必须分别在删表之前、建表之后执行，因此单独合并
+	if !options.SplitAlters {
+		dropFKStatements = mergeAlterStatements(dropFKStatements)
+		alterTableStatements = mergeAlterStatements(append(alterTableStatements, createIndexStatements...))
+		createIndexStatements = nil
+		createFKStatements = mergeAlterStatements(createFKStatements)
+	}
+
 	// 按顺序合并所有语句
+	script.Statements = append(script.Statements, renameTableStatements...)
 	script.Statements = append(script.Statements, dropFKStatements...)
+	script.Statements = append(script.Statements, dropEventStatements...)
 	script.Statements = append(script.Statements, dropTriggerStatements...)
-	script.Statements = append(script.Statements, dropViewStatements...)
-	script.Statements = append(script.Statements, dropProcStatements...)
-	script.Statements = append(script.Statements, dropFuncStatements...)
+	script.Statements = append(script.Statements, dropDefinitionStatements...)
 	script.Statements = append(script.Statements, dropTableStatements...)
 	script.Statements = append(script.Statements, alterTableStatements...)
 	script.Statements = append(script.Statements, createTableStatements...)
 	script.Statements = append(script.Statements, createIndexStatements...)
+	script.Statements = append(script.Statements, partitionStatements...)
 	script.Statements = append(script.Statements, createFKStatements...)
-	script.Statements = append(script.Statements, createFuncStatements...)
-	script.Statements = append(script.Statements, createProcStatements...)
-	script.Statements = append(script.Statements, createViewStatements...)
+	script.Statements = append(script.Statements, createDefinitionStatements...)
 	script.Statements = append(script.Statements, createTriggerStatements...)
+	script.Statements = append(script.Statements, createEventStatements...)
+	script.Statements = g.expandSQLMode(script.Statements)
+
+	// 在线变更模式
+	g.applyOnlineMode(script, schemaDiff, options)
 
 	// 生成完整SQL
 	script.UpSQL = g.buildFullSQL(script.Statements, options)
@@ -412,13 +552,14 @@ func (g *MySQLGenerator) Generate(schemaDiff *diff.SchemaDiff, options GenerateO
 }
 
 // generateColumnStatements 生成列变更语句
-func (g *MySQLGenerator) generateColumnStatements(tableName string, cd *diff.ColumnDiff) []SQLStatement {
+func (g *MySQLGenerator) generateColumnStatements(td *diff.TableDiff, cd *diff.ColumnDiff, options GenerateOptions) []SQLStatement {
 	var stmts []SQLStatement
+	tableName := td.TableName
 
 	switch cd.DiffType {
 	case diff.DiffTypeAdded:
 		if cd.NewColumn != nil {
-			sql := g.buildAddColumnSQL(tableName, cd.NewColumn)
+			sql := g.buildAddColumnSQL(tableName, cd.NewColumn, td.NewTable, columnPosition(cd))
 			stmts = append(stmts, SQLStatement{
 				SQL:        sql,
 				ObjectType: "COLUMN",
@@ -426,6 +567,7 @@ func (g *MySQLGenerator) generateColumnStatements(tableName string, cd *diff.Col
 				Operation:  "ADD",
 				Severity:   diff.SeverityInfo,
 				Comment:    fmt.Sprintf("添加列 %s", cd.ColumnName),
+				Algorithm:  columnAlgorithm(cd, options.TargetVersion),
 			})
 		}
 
@@ -437,11 +579,12 @@ func (g *MySQLGenerator) generateColumnStatements(tableName string, cd *diff.Col
 			Operation:  "DROP",
 			Severity:   diff.SeverityDanger,
 			Comment:    fmt.Sprintf("⚠️ 删除列 %s - 数据将丢失", cd.ColumnName),
+			Algorithm:  columnAlgorithm(cd, options.TargetVersion),
 		})
 
 	case diff.DiffTypeModified:
 		if cd.NewColumn != nil {
-			sql := g.buildModifyColumnSQL(tableName, cd.NewColumn)
+			sql := g.buildModifyColumnSQL(tableName, cd.NewColumn, td.NewTable, columnPosition(cd))
 			stmts = append(stmts, SQLStatement{
 				SQL:        sql,
 				ObjectType: "COLUMN",
@@ -449,6 +592,21 @@ func (g *MySQLGenerator) generateColumnStatements(tableName string, cd *diff.Col
 				Operation:  "MODIFY",
 				Severity:   cd.Severity,
 				Comment:    fmt.Sprintf("修改列 %s", cd.ColumnName),
+				Algorithm:  columnAlgorithm(cd, options.TargetVersion),
+			})
+		}
+
+	case diff.DiffTypeRenamed:
+		if cd.NewColumn != nil && cd.OldColumn != nil {
+			stmts = append(stmts, SQLStatement{
+				SQL:         g.buildRenameColumnSQL(tableName, cd.OldColumn, cd.NewColumn, td.NewTable, len(cd.Changes) == 0, columnPosition(cd), options),
+				ObjectType:  "COLUMN",
+				ObjectName:  fmt.Sprintf("%s.%s", tableName, cd.ColumnName),
+				Operation:   "RENAME",
+				Severity:    cd.Severity,
+				Comment:     fmt.Sprintf("重命名列 %s -> %s", cd.OldName, cd.ColumnName),
+				RollbackSQL: g.buildRenameColumnSQL(tableName, cd.NewColumn, cd.OldColumn, td.OldTable, len(cd.Changes) == 0, "", options),
+				Algorithm:   columnAlgorithm(cd, options.TargetVersion),
 			})
 		}
 	}
@@ -456,58 +614,110 @@ func (g *MySQLGenerator) generateColumnStatements(tableName string, cd *diff.Col
 	return stmts
 }
 
-// buildAddColumnSQL 构建添加列SQL
-func (g *MySQLGenerator) buildAddColumnSQL(tableName string, col *extractor.ColumnSchema) string {
-	var parts []string
-	parts = append(parts, fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s`", tableName, col.Name))
-	parts = append(parts, col.ColumnType)
-
-	if !col.IsNullable {
-		parts = append(parts, "NOT NULL")
-	} else {
-		parts = append(parts, "NULL")
-	}
-
-	if col.DefaultValue != nil {
-		parts = append(parts, fmt.Sprintf("DEFAULT %s", g.formatDefaultValue(*col.DefaultValue, col.DataType)))
+// buildRenameColumnSQL 构建重命名列SQL
+// 定义不变且目标库支持时使用 RENAME COLUMN，否则使用 CHANGE COLUMN 同时修改定义
+// gh-ost / pt-osc 只能识别 CHANGE COLUMN 形式的重命名；table 为 to 所在的表，用于判断列的字符集是否沿用表的默认值
+func (g *MySQLGenerator) buildRenameColumnSQL(tableName string, from, to *extractor.ColumnSchema, table *extractor.TableSchema, sameDefinition bool, position string, options GenerateOptions) string {
+	tool := onlineTool(options)
+	if sameDefinition && parseMySQLVersion(options.TargetVersion).atLeast(8, 0, 0) &&
+		tool != OnlineToolGhost && tool != OnlineToolPTOSC {
+		return fmt.Sprintf("ALTER TABLE `%s` RENAME COLUMN `%s` TO `%s`;", tableName, from.Name, to.Name)
 	}
+	return fmt.Sprintf("ALTER TABLE `%s` CHANGE COLUMN `%s` `%s` %s%s;", tableName, from.Name, to.Name, g.buildColumnDefinition(to, table), position)
+}
 
-	if col.IsAutoIncr {
-		parts = append(parts, "AUTO_INCREMENT")
+// columnPosition 返回列的位置子句: " FIRST"、" AFTER `col`" 或空（保持原位/追加到末尾）
+func columnPosition(cd *diff.ColumnDiff) string {
+	if cd.First {
+		return " FIRST"
 	}
-
-	if col.Comment != "" {
-		parts = append(parts, fmt.Sprintf("COMMENT '%s'", g.escapeString(col.Comment)))
+	if cd.AfterColumn != "" {
+		return fmt.Sprintf(" AFTER `%s`", cd.AfterColumn)
 	}
+	return ""
+}
 
-	return strings.Join(parts, " ") + ";"
+// buildAddColumnSQL 构建添加列SQL
+func (g *MySQLGenerator) buildAddColumnSQL(tableName string, col *extractor.ColumnSchema, table *extractor.TableSchema, position string) string {
+	return fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s%s;", tableName, col.Name, g.buildColumnDefinition(col, table), position)
 }
 
 // buildModifyColumnSQL 构建修改列SQL
-func (g *MySQLGenerator) buildModifyColumnSQL(tableName string, col *extractor.ColumnSchema) string {
+func (g *MySQLGenerator) buildModifyColumnSQL(tableName string, col *extractor.ColumnSchema, table *extractor.TableSchema, position string) string {
+	return fmt.Sprintf("ALTER TABLE `%s` MODIFY COLUMN `%s` %s%s;", tableName, col.Name, g.buildColumnDefinition(col, table), position)
+}
+
+// buildColumnDefinition 构建列定义（类型及属性）
+// MODIFY/CHANGE 会整体替换列定义，因此需要写出提取到的全部属性；
+// 字符集和排序规则与 table 的默认值不同（或 table 为 nil）时才显式写出
+func (g *MySQLGenerator) buildColumnDefinition(col *extractor.ColumnSchema, table *extractor.TableSchema) string {
 	var parts []string
-	parts = append(parts, fmt.Sprintf("ALTER TABLE `%s` MODIFY COLUMN `%s`", tableName, col.Name))
 	parts = append(parts, col.ColumnType)
 
+	if col.CollationName != "" && (table == nil || col.CollationName != table.Collation || col.CharsetName != table.Charset) {
+		if col.CharsetName != "" {
+			parts = append(parts, "CHARACTER SET "+col.CharsetName)
+		}
+		parts = append(parts, "COLLATE "+col.CollationName)
+	}
+
+	if col.IsGenerated {
+		storage := "VIRTUAL"
+		if hasExtraWord(col.Extra, "STORED") {
+			storage = "STORED"
+		}
+		parts = append(parts, fmt.Sprintf("GENERATED ALWAYS AS (%s) %s", col.GeneratedExpr, storage))
+	}
+
 	if !col.IsNullable {
 		parts = append(parts, "NOT NULL")
 	} else {
 		parts = append(parts, "NULL")
 	}
 
-	if col.DefaultValue != nil {
+	// 生成列不能有默认值和自增
+	if col.DefaultValue != nil && !col.IsGenerated {
 		parts = append(parts, fmt.Sprintf("DEFAULT %s", g.formatDefaultValue(*col.DefaultValue, col.DataType)))
 	}
 
-	if col.IsAutoIncr {
+	if onUpdate := onUpdateClause(col.Extra); onUpdate != "" {
+		parts = append(parts, onUpdate)
+	}
+
+	if col.IsAutoIncr && !col.IsGenerated {
 		parts = append(parts, "AUTO_INCREMENT")
 	}
 
+	if hasExtraWord(col.Extra, "INVISIBLE") {
+		parts = append(parts, "INVISIBLE")
+	}
+
 	if col.Comment != "" {
 		parts = append(parts, fmt.Sprintf("COMMENT '%s'", g.escapeString(col.Comment)))
 	}
 
-	return strings.Join(parts, " ") + ";"
+	return strings.Join(parts, " ")
+}
+
+// onUpdateClause 从 Extra 中取出 ON UPDATE 子句，如 "DEFAULT_GENERATED on update CURRENT_TIMESTAMP(3)"
+func onUpdateClause(extra string) string {
+	fields := strings.Fields(extra)
+	for i := 0; i+2 < len(fields); i++ {
+		if strings.EqualFold(fields[i], "on") && strings.EqualFold(fields[i+1], "update") {
+			return "ON UPDATE " + fields[i+2]
+		}
+	}
+	return ""
+}
+
+// hasExtraWord 判断 Extra 中是否包含指定的属性
+func hasExtraWord(extra, word string) bool {
+	for _, field := range strings.Fields(extra) {
+		if strings.EqualFold(field, word) {
+			return true
+		}
+	}
+	return false
 }
 
 // generateIndexStatements 生成索引变更语句
@@ -518,6 +728,7 @@ func (g *MySQLGenerator) generateIndexStatements(tableName string, id *diff.Inde
 	case diff.DiffTypeAdded:
 		if id.NewIndex != nil {
 			sql := g.buildCreateIndexSQL(tableName, id.NewIndex)
+			algorithm, lock := indexAlgorithm(id.NewIndex, false)
 			stmts = append(stmts, SQLStatement{
 				SQL:        sql,
 				ObjectType: "INDEX",
@@ -525,6 +736,8 @@ func (g *MySQLGenerator) generateIndexStatements(tableName string, id *diff.Inde
 				Operation:  "CREATE",
 				Severity:   diff.SeverityInfo,
 				Comment:    fmt.Sprintf("创建索引 %s", id.IndexName),
+				Algorithm:  algorithm,
+				Lock:       lock,
 			})
 		}
 
@@ -536,6 +749,7 @@ func (g *MySQLGenerator) generateIndexStatements(tableName string, id *diff.Inde
 			} else {
 				sql = fmt.Sprintf("ALTER TABLE `%s` DROP INDEX `%s`;", tableName, id.IndexName)
 			}
+			algorithm, lock := indexAlgorithm(id.OldIndex, true)
 			stmts = append(stmts, SQLStatement{
 				SQL:        sql,
 				ObjectType: "INDEX",
@@ -543,6 +757,8 @@ func (g *MySQLGenerator) generateIndexStatements(tableName string, id *diff.Inde
 				Operation:  "DROP",
 				Severity:   diff.SeverityWarning,
 				Comment:    fmt.Sprintf("删除索引 %s", id.IndexName),
+				Algorithm:  algorithm,
+				Lock:       lock,
 			})
 		}
 
@@ -555,6 +771,7 @@ func (g *MySQLGenerator) generateIndexStatements(tableName string, id *diff.Inde
 			} else {
 				dropSQL = fmt.Sprintf("ALTER TABLE `%s` DROP INDEX `%s`;", tableName, id.IndexName)
 			}
+			algorithm, lock := indexAlgorithm(id.OldIndex, true)
 			stmts = append(stmts, SQLStatement{
 				SQL:        dropSQL,
 				ObjectType: "INDEX",
@@ -562,10 +779,13 @@ func (g *MySQLGenerator) generateIndexStatements(tableName string, id *diff.Inde
 				Operation:  "DROP",
 				Severity:   diff.SeverityWarning,
 				Comment:    fmt.Sprintf("删除索引 %s（将重建）", id.IndexName),
+				Algorithm:  algorithm,
+				Lock:       lock,
 			})
 		}
 		if id.NewIndex != nil {
 			sql := g.buildCreateIndexSQL(tableName, id.NewIndex)
+			algorithm, lock := indexAlgorithm(id.NewIndex, false)
 			stmts = append(stmts, SQLStatement{
 				SQL:        sql,
 				ObjectType: "INDEX",
@@ -573,6 +793,8 @@ func (g *MySQLGenerator) generateIndexStatements(tableName string, id *diff.Inde
 				Operation:  "CREATE",
 				Severity:   diff.SeverityWarning,
 				Comment:    fmt.Sprintf("重建索引 %s", id.IndexName),
+				Algorithm:  algorithm,
+				Lock:       lock,
 			})
 		}
 	}
@@ -642,11 +864,91 @@ func (g *MySQLGenerator) generateAddForeignKey(tableName string, fk *extractor.F
 		Operation:  "ADD",
 		Severity:   diff.SeverityWarning,
 		Comment:    fmt.Sprintf("添加外键 %s", fk.Name),
+		Algorithm:  AlgorithmCopy, // 仅在 foreign_key_checks=0 时可以 INPLACE
 	}
 }
 
+// generateCheckStatements 生成CHECK约束变更语句，返回需要在列变更前后执行的语句
+func (g *MySQLGenerator) generateCheckStatements(tableName string, ckd *diff.CheckDiff) ([]SQLStatement, []SQLStatement) {
+	objectName := fmt.Sprintf("%s.%s", tableName, ckd.CheckName)
+
+	drop := SQLStatement{
+		SQL:        fmt.Sprintf("ALTER TABLE `%s` DROP CHECK `%s`;", tableName, ckd.CheckName),
+		ObjectType: "CHECK",
+		ObjectName: objectName,
+		Operation:  "DROP",
+		Severity:   diff.SeverityInfo,
+		Comment:    fmt.Sprintf("删除CHECK约束 %s", ckd.CheckName),
+		Algorithm:  AlgorithmInplace,
+	}
+	if ckd.OldCheck != nil {
+		drop.RollbackSQL = g.buildAddCheckSQL(tableName, ckd.OldCheck)
+	}
+
+	var add SQLStatement
+	if ckd.NewCheck != nil {
+		add = SQLStatement{
+			SQL:         g.buildAddCheckSQL(tableName, ckd.NewCheck),
+			ObjectType:  "CHECK",
+			ObjectName:  objectName,
+			Operation:   "ADD",
+			Severity:    ckd.Severity,
+			Comment:     fmt.Sprintf("添加CHECK约束 %s", ckd.CheckName),
+			RollbackSQL: fmt.Sprintf("ALTER TABLE `%s` DROP CHECK `%s`;", tableName, ckd.CheckName),
+			Algorithm:   AlgorithmInplace,
+		}
+		if ckd.NewCheck.Enforced {
+			// 启用的约束需要校验全部现有数据
+			add.Algorithm = AlgorithmCopy
+		}
+	}
+
+	switch ckd.DiffType {
+	case diff.DiffTypeAdded:
+		return nil, []SQLStatement{add}
+	case diff.DiffTypeRemoved:
+		return []SQLStatement{drop}, nil
+	}
+
+	// 只有启用状态变化时直接修改
+	if !ckd.ExprChanged {
+		return nil, []SQLStatement{{
+			SQL:         fmt.Sprintf("ALTER TABLE `%s` ALTER CHECK `%s` %s;", tableName, ckd.CheckName, enforcedClause(ckd.NewCheck.Enforced)),
+			ObjectType:  "CHECK",
+			ObjectName:  objectName,
+			Operation:   "ALTER",
+			Severity:    ckd.Severity,
+			Comment:     fmt.Sprintf("修改CHECK约束 %s 为 %s", ckd.CheckName, enforcedClause(ckd.NewCheck.Enforced)),
+			RollbackSQL: fmt.Sprintf("ALTER TABLE `%s` ALTER CHECK `%s` %s;", tableName, ckd.CheckName, enforcedClause(ckd.OldCheck.Enforced)),
+			Algorithm:   add.Algorithm,
+		}}
+	}
+
+	// 表达式变化时先删后加
+	drop.Comment = fmt.Sprintf("删除CHECK约束 %s（将重建）", ckd.CheckName)
+	add.Comment = fmt.Sprintf("重建CHECK约束 %s", ckd.CheckName)
+	return []SQLStatement{drop}, []SQLStatement{add}
+}
+
+// buildAddCheckSQL 构建添加CHECK约束语句
+func (g *MySQLGenerator) buildAddCheckSQL(tableName string, check *extractor.CheckConstraint) string {
+	sql := fmt.Sprintf("ALTER TABLE `%s` ADD CONSTRAINT `%s` CHECK (%s)", tableName, check.Name, check.Expression)
+	if !check.Enforced {
+		sql += " NOT ENFORCED"
+	}
+	return sql + ";"
+}
+
+// enforcedClause 返回CHECK约束的启用子句
+func enforcedClause(enforced bool) string {
+	if enforced {
+		return "ENFORCED"
+	}
+	return "NOT ENFORCED"
+}
+
 // generateTablePropertyStatement 生成表属性变更语句
-func (g *MySQLGenerator) generateTablePropertyStatement(tableName string, prop *diff.PropertyDiff) *SQLStatement {
+func (g *MySQLGenerator) generateTablePropertyStatement(tableName string, prop *diff.PropertyDiff, options GenerateOptions) *SQLStatement {
 	var sql string
 
 	switch prop.Property {
@@ -670,6 +972,7 @@ func (g *MySQLGenerator) generateTablePropertyStatement(tableName string, prop *
 		Operation:  "ALTER",
 		Severity:   diff.SeverityInfo,
 		Comment:    fmt.Sprintf("修改表属性 %s", prop.Property),
+		Algorithm:  tablePropertyAlgorithm(prop.Property, options.TargetVersion),
 	}
 }
 
@@ -696,6 +999,13 @@ func (g *MySQLGenerator) buildFullSQL(statements []SQLStatement, options Generat
 		if options.AddComments && stmt.Comment != "" {
 			builder.WriteString(fmt.Sprintf("-- [%d/%d] %s\n", i+1, len(statements), stmt.Comment))
 		}
+		if stmt.OnlineCommand != "" {
+			// 由在线变更工具执行，脚本中仅保留供参考的语句
+			builder.WriteString("-- 请在shell中执行:\n")
+			builder.WriteString("--   " + stmt.OnlineCommand + "\n")
+			builder.WriteString("-- " + strings.ReplaceAll(stmt.SQL, "\n", "\n-- ") + "\n\n")
+			continue
+		}
 		builder.WriteString(stmt.SQL)
 		builder.WriteString("\n\n")
 	}
@@ -759,3 +1069,24 @@ func (g *MySQLGenerator) escapeString(s string) string {
 	return s
 }
 
+// blockingCheckWarnings 返回表差异中会导致语句执行失败的预检结果
+func blockingCheckWarnings(td *diff.TableDiff) []string {
+	var checks []diff.DataCheck
+	for _, cd := range td.ColumnDiffs {
+		checks = append(checks, cd.DataChecks...)
+	}
+	for _, id := range td.IndexDiffs {
+		checks = append(checks, id.DataChecks...)
+	}
+	for _, fkd := range td.FKeyDiffs {
+		checks = append(checks, fkd.DataChecks...)
+	}
+
+	var warnings []string
+	for _, check := range checks {
+		if check.Blocking {
+			warnings = append(warnings, check.Message)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,193 @@
+package sqlgen
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/starvpn/schemapatch/internal/config"
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+var columnClausePattern = regexp.MustCompile("^ALTER TABLE `t` (?:MODIFY COLUMN|CHANGE COLUMN `[^`]+`) `([^`]+)` (.*?)(?: FIRST| AFTER `[^`]+`)?;$")
+
+// reparseColumn 把生成的 MODIFY/CHANGE 语句中的列定义放回建表语句重新解析
+func reparseColumn(t *testing.T, sql string, table *extractor.TableSchema) *extractor.ColumnSchema {
+	t.Helper()
+	m := columnClausePattern.FindStringSubmatch(sql)
+	if m == nil {
+		t.Fatalf("无法识别的列变更语句: %s", sql)
+	}
+	ddl := fmt.Sprintf("CREATE TABLE t (`%s` %s) DEFAULT CHARSET=%s COLLATE=%s", m[1], m[2], table.Charset, table.Collation)
+	schema, err := extractor.ParseDDL(ddl, "app")
+	if err != nil {
+		t.Fatalf("ParseDDL(%q) error = %v", ddl, err)
+	}
+	return schema.Tables["t"].Columns[0]
+}
+
+// assertSameColumn 比较除位置以外的列属性
+func assertSameColumn(t *testing.T, got, want *extractor.ColumnSchema) {
+	t.Helper()
+	g, w := *got, *want
+	g.Position, w.Position = 0, 0
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("列定义不一致:\n  got  %+v\n  want %+v", g, w)
+	}
+}
+
+func TestReorderColumnKeepsDefinition(t *testing.T) {
+	tests := []struct {
+		name   string
+		column string
+	}{
+		{"ON UPDATE", "c timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP"},
+		{"存储生成列", "c int GENERATED ALWAYS AS (x * 2) STORED COMMENT '两倍'"},
+		{"虚拟生成列", "c varchar(20) AS (concat(x, y)) VIRTUAL"},
+		{"列级排序规则", "c varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT ''"},
+		{"列级字符集", "c varchar(32) CHARACTER SET latin1 DEFAULT NULL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, err := extractor.ParseDDL("CREATE TABLE t (id int PRIMARY KEY, "+tt.column+", x int, y varchar(10)) DEFAULT CHARSET=utf8mb4", "app")
+			if err != nil {
+				t.Fatalf("ParseDDL() error = %v", err)
+			}
+			moved, err := extractor.ParseDDL("CREATE TABLE t (id int PRIMARY KEY, x int, y varchar(10), "+tt.column+") DEFAULT CHARSET=utf8mb4", "app")
+			if err != nil {
+				t.Fatalf("ParseDDL() error = %v", err)
+			}
+
+			schemaDiff := diff.NewDiffEngine(config.IgnoreConfig{}).Compare(moved, old)
+			options := DefaultGenerateOptions()
+			options.SplitAlters = true
+			script, err := NewMySQLGenerator().Generate(schemaDiff, options)
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			table := moved.Tables["t"]
+			found := false
+			for _, stmt := range script.Statements {
+				if stmt.Operation != "MODIFY" {
+					continue
+				}
+				found = true
+				got := reparseColumn(t, stmt.SQL, table)
+				for _, want := range table.Columns {
+					if want.Name == got.Name {
+						assertSameColumn(t, got, want)
+					}
+				}
+			}
+			if !found {
+				t.Fatalf("调整列顺序应生成 MODIFY 语句: %+v", script.Statements)
+			}
+		})
+	}
+}
+
+func TestRenameColumnKeepsDefinition(t *testing.T) {
+	schema, err := extractor.ParseDDL("CREATE TABLE t (id int, "+
+		"a varchar(32) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL, "+
+		"b datetime(3) NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3), "+
+		"c bigint AS (id + 1) STORED NOT NULL) DEFAULT CHARSET=utf8mb4", "app")
+	if err != nil {
+		t.Fatalf("ParseDDL() error = %v", err)
+	}
+	table := schema.Tables["t"]
+
+	// 8.0 以下只能用 CHANGE COLUMN 重命名
+	options := DefaultGenerateOptions()
+	options.TargetVersion = "5.7.40"
+	g := NewMySQLGenerator()
+	for _, col := range table.Columns[1:] {
+		from := *col
+		from.Name = "old_" + col.Name
+		sql := g.buildRenameColumnSQL("t", &from, col, table, true, "", options)
+		assertSameColumn(t, reparseColumn(t, sql, table), col)
+	}
+}
+
+func TestRoutineSQLModeStatements(t *testing.T) {
+	schemaDiff := &diff.SchemaDiff{ProcDiffs: []diff.ProcedureDiff{{
+		ProcName: "p",
+		DiffType: diff.DiffTypeModified,
+		OldProc:  &extractor.ProcedureSchema{Name: "p", Definition: "CREATE PROCEDURE p() SELECT 1", SQLMode: "ANSI_QUOTES"},
+		NewProc:  &extractor.ProcedureSchema{Name: "p", Definition: "CREATE PROCEDURE p() SELECT 2", SQLMode: "STRICT_TRANS_TABLES"},
+		PropertyDiffs: []diff.PropertyDiff{
+			{Property: "sql_mode", OldValue: "ANSI_QUOTES", NewValue: "STRICT_TRANS_TABLES"},
+		},
+	}}}
+	script, err := NewMySQLGenerator().Generate(schemaDiff, DefaultGenerateOptions())
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// 执行器逐条执行且不开启 multiStatements，切换 sql_mode 必须是单独的语句
+	want := []string{
+		"DROP PROCEDURE IF EXISTS `p`;",
+		"SET @saved_sql_mode = @@SESSION.sql_mode;",
+		"SET SESSION sql_mode = 'STRICT_TRANS_TABLES';",
+		"CREATE PROCEDURE p() SELECT 2;",
+		"SET SESSION sql_mode = @saved_sql_mode;",
+	}
+	var got []string
+	for _, stmt := range script.Statements {
+		got = append(got, stmt.SQL)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+
+	// 回滚脚本由 mysql 客户端执行，在目标环境的 sql_mode 下重建
+	if rollback := script.Statements[0].RollbackSQL; !strings.Contains(rollback, "SET SESSION sql_mode = 'ANSI_QUOTES';\nCREATE PROCEDURE p() SELECT 1;") {
+		t.Errorf("rollback = %q", rollback)
+	}
+}
+
+func TestEventSQLModeAndDefiner(t *testing.T) {
+	event := func(definer, sqlMode string) *extractor.EventSchema {
+		return &extractor.EventSchema{
+			Name: "e", Definer: definer, EventType: "RECURRING", IntervalValue: "1", IntervalField: "DAY",
+			Status: "ENABLED", OnCompletion: "NOT PRESERVE", Body: "DELETE FROM logs", SQLMode: sqlMode,
+		}
+	}
+	source := &extractor.DatabaseSchema{Events: map[string]*extractor.EventSchema{"e": event("app@%", "STRICT_TRANS_TABLES")}}
+	target := &extractor.DatabaseSchema{Events: map[string]*extractor.EventSchema{"e": event("root@localhost", "ANSI_QUOTES")}}
+
+	engine := diff.NewDiffEngine(config.IgnoreConfig{})
+	policy := config.DefinerPolicy{Mode: config.DefinerKeep}
+	engine.SetDefinerPolicy(policy)
+	schemaDiff := engine.Compare(source, target)
+	if len(schemaDiff.EventDiffs) != 1 || len(schemaDiff.EventDiffs[0].PropertyDiffs) != 2 {
+		t.Fatalf("应检测到 sql_mode 和定义者变化: %+v", schemaDiff.EventDiffs)
+	}
+
+	options := DefaultGenerateOptions()
+	options.DefinerPolicy = policy
+	script, err := NewMySQLGenerator().Generate(schemaDiff, options)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	var got []string
+	for _, stmt := range script.Statements {
+		got = append(got, stmt.SQL)
+	}
+	want := []string{
+		"SET @saved_sql_mode = @@SESSION.sql_mode;",
+		"SET SESSION sql_mode = 'STRICT_TRANS_TABLES';",
+		"ALTER DEFINER=`app`@`%` EVENT `e` ENABLE;",
+		"SET SESSION sql_mode = @saved_sql_mode;",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+	if rollback := script.Statements[2].RollbackSQL; !strings.Contains(rollback, "SET SESSION sql_mode = 'ANSI_QUOTES';\nALTER DEFINER=`root`@`localhost` EVENT `e` ENABLE;") {
+		t.Errorf("rollback = %q", rollback)
+	}
+}
@@ -0,0 +1,118 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/diff"
+)
+
+// alterClauseSeparator 合并后 ALTER 语句中子句的分隔符
+const alterClauseSeparator = ",\n  "
+
+// mergeAlterStatements 将同一张表的多条 ALTER TABLE 合并为一条
+// 合并后的语句位于该表第一条语句的位置，其他语句保持原有顺序
+func mergeAlterStatements(statements []SQLStatement) []SQLStatement {
+	groups := make(map[string][]int)
+	for i, stmt := range statements {
+		if table := alterTableName(stmt.SQL); table != "" {
+			groups[table] = append(groups[table], i)
+		}
+	}
+
+	var merged []SQLStatement
+	for i, stmt := range statements {
+		table := alterTableName(stmt.SQL)
+		indexes := groups[table]
+		if table == "" || len(indexes) == 1 {
+			merged = append(merged, stmt)
+			continue
+		}
+		if indexes[0] != i {
+			continue
+		}
+
+		group := make([]SQLStatement, len(indexes))
+		for j, idx := range indexes {
+			group[j] = statements[idx]
+		}
+		merged = append(merged, combineAlterStatements(table, group))
+	}
+
+	return merged
+}
+
+// combineAlterStatements 合并同一张表的 ALTER 语句
+func combineAlterStatements(table string, group []SQLStatement) SQLStatement {
+	combined := SQLStatement{
+		ObjectType: "TABLE",
+		ObjectName: table,
+		Operation:  "ALTER",
+		Severity:   diff.SeverityInfo,
+	}
+
+	var clauses, comments, rollbacks []string
+	for _, stmt := range group {
+		clauses = append(clauses, alterClauses(stmt, table)...)
+		if stmt.Comment != "" {
+			comments = append(comments, stmt.Comment)
+		}
+		if stmt.Severity > combined.Severity {
+			combined.Severity = stmt.Severity
+		}
+		combined.Algorithm = strongerAlgorithm(combined.Algorithm, stmt.Algorithm)
+		combined.Lock = strongerLock(combined.Lock, stmt.Lock)
+	}
+
+	// 回滚按相反顺序执行
+	for i := len(group) - 1; i >= 0; i-- {
+		if group[i].RollbackSQL != "" {
+			rollbacks = append(rollbacks, group[i].RollbackSQL)
+		}
+	}
+
+	combined.SQL = buildAlterSQL(table, clauses)
+	combined.Comment = fmt.Sprintf("修改表 %s: %s", table, strings.Join(comments, ", "))
+	combined.RollbackSQL = strings.Join(rollbacks, "\n")
+	combined.clauses = clauses
+	return combined
+}
+
+// buildAlterSQL 由子句构建 ALTER TABLE 语句
+func buildAlterSQL(table string, clauses []string) string {
+	if len(clauses) == 1 {
+		return fmt.Sprintf("ALTER TABLE `%s` %s;", table, clauses[0])
+	}
+	return fmt.Sprintf("ALTER TABLE `%s`\n  %s;", table, strings.Join(clauses, alterClauseSeparator))
+}
+
+// strongerLock 返回限制更严格的锁级别
+func strongerLock(a, b string) string {
+	rank := map[string]int{"": 0, "NONE": 1, "SHARED": 2, "EXCLUSIVE": 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// alterTableName 返回 ALTER TABLE 语句的表名
+func alterTableName(sql string) string {
+	if !strings.HasPrefix(sql, "ALTER TABLE `") {
+		return ""
+	}
+	rest := sql[len("ALTER TABLE `"):]
+	end := strings.IndexByte(rest, '`')
+	if end < 0 || end+1 >= len(rest) || (rest[end+1] != ' ' && rest[end+1] != '\n') {
+		return ""
+	}
+	return rest[:end]
+}
+
+// alterClauses 返回 ALTER 语句中的变更子句
+func alterClauses(stmt SQLStatement, table string) []string {
+	if len(stmt.clauses) > 0 {
+		return stmt.clauses
+	}
+	clause := strings.TrimPrefix(stmt.SQL, fmt.Sprintf("ALTER TABLE `%s`", table))
+	return []string{strings.TrimSuffix(strings.TrimSpace(clause), ";")}
+}
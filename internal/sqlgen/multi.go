@@ -0,0 +1,78 @@
+package sqlgen
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/starvpn/schemapatch/internal/diff"
+)
+
+// GenerateDatabases 为多库差异生成一个脚本，每个库的语句之前以 USE 切换到目标库
+// 目标环境缺少的库先 CREATE DATABASE；源环境中没有的库只给出警告，不生成删除语句
+func (g *MySQLGenerator) GenerateDatabases(multi *diff.MultiSchemaDiff, options GenerateOptions) (*MigrationScript, error) {
+	script := &MigrationScript{
+		Version:     time.Now().Format("20060102150405"),
+		Description: fmt.Sprintf("从 %s 迁移到 %s", multi.TargetEnv, multi.SourceEnv),
+		Statements:  []SQLStatement{},
+		Warnings:    []string{},
+		GeneratedAt: time.Now(),
+	}
+
+	// 回滚语句逆序输出，因此把切换库的语句放在每个库的语句之后
+	var rollbackStatements []SQLStatement
+	for _, db := range multi.Databases {
+		if db.DiffType == diff.DiffTypeRemoved {
+			script.Warnings = append(script.Warnings, fmt.Sprintf("数据库 %s 在源环境中不存在，未生成删除语句", db.TargetDatabase))
+			continue
+		}
+		if db.DiffType == diff.DiffTypeModified && !db.Diff.HasDiff() {
+			continue
+		}
+
+		dbScript, err := g.Generate(db.Diff, options)
+		if err != nil {
+			return nil, fmt.Errorf("生成数据库 %s 的脚本失败: %w", db.TargetDatabase, err)
+		}
+
+		if db.DiffType == diff.DiffTypeAdded {
+			script.Statements = append(script.Statements, SQLStatement{
+				SQL:        fmt.Sprintf("CREATE DATABASE IF NOT EXISTS `%s`;", db.TargetDatabase),
+				ObjectType: "DATABASE",
+				ObjectName: db.TargetDatabase,
+				Operation:  "CREATE",
+				Severity:   diff.SeverityInfo,
+				Comment:    fmt.Sprintf("创建数据库 %s", db.TargetDatabase),
+			})
+		}
+		script.Statements = append(script.Statements, useStatement(db.TargetDatabase))
+		script.Statements = append(script.Statements, dbScript.Statements...)
+		for _, warning := range dbScript.Warnings {
+			script.Warnings = append(script.Warnings, db.TargetDatabase+": "+warning)
+		}
+		script.OnlineCommands = append(script.OnlineCommands, dbScript.OnlineCommands...)
+		script.EstimatedTime += dbScript.EstimatedTime
+
+		use := useStatement(db.TargetDatabase)
+		use.RollbackSQL = use.SQL
+		rollbackStatements = append(rollbackStatements, dbScript.Statements...)
+		rollbackStatements = append(rollbackStatements, use)
+	}
+
+	script.UpSQL = g.buildFullSQL(script.Statements, options)
+	if options.IncludeRollback {
+		script.DownSQL = g.buildRollbackSQL(rollbackStatements)
+	}
+	return script, nil
+}
+
+// useStatement 切换当前数据库的语句
+func useStatement(database string) SQLStatement {
+	return SQLStatement{
+		SQL:        fmt.Sprintf("USE `%s`;", database),
+		ObjectType: "DATABASE",
+		ObjectName: database,
+		Operation:  "USE",
+		Severity:   diff.SeverityInfo,
+		Comment:    fmt.Sprintf("切换到数据库 %s", database),
+	}
+}
@@ -0,0 +1,413 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// 在线变更工具
+const (
+	OnlineToolNative = "native" // MySQL原生 Online DDL (ALGORITHM/LOCK)
+	OnlineToolGhost  = "gh-ost"
+	OnlineToolPTOSC  = "pt-osc" // pt-online-schema-change
+)
+
+// Online DDL 算法
+const (
+	AlgorithmInstant = "INSTANT"
+	AlgorithmInplace = "INPLACE"
+	AlgorithmCopy    = "COPY"
+)
+
+// mysqlVersion MySQL版本号
+type mysqlVersion struct {
+	major, minor, patch int
+}
+
+// parseMySQLVersion 解析版本号，如 8.0.35、5.7.44-log，为空时按8.0处理
+func parseMySQLVersion(version string) mysqlVersion {
+	if version == "" {
+		return mysqlVersion{8, 0, 0}
+	}
+	var v mysqlVersion
+	fmt.Sscanf(version, "%d.%d.%d", &v.major, &v.minor, &v.patch)
+	return v
+}
+
+// atLeast 检查版本是否不低于指定版本
+func (v mysqlVersion) atLeast(major, minor, patch int) bool {
+	if v.major != major {
+		return v.major > major
+	}
+	if v.minor != minor {
+		return v.minor > minor
+	}
+	return v.patch >= patch
+}
+
+// onlineTool 返回实际使用的在线变更工具
+func onlineTool(options GenerateOptions) string {
+	if !options.OnlineMode {
+		return ""
+	}
+	if options.OnlineTool == "" {
+		return OnlineToolNative
+	}
+	return options.OnlineTool
+}
+
+// strongerAlgorithm 返回代价更高的算法
+func strongerAlgorithm(a, b string) string {
+	rank := map[string]int{"": 0, AlgorithmInstant: 1, AlgorithmInplace: 2, AlgorithmCopy: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
+
+// columnAlgorithm 估算列变更可使用的 Online DDL 算法
+func columnAlgorithm(cd *diff.ColumnDiff, version string) string {
+	v := parseMySQLVersion(version)
+
+	switch cd.DiffType {
+	case diff.DiffTypeAdded:
+		if cd.NewColumn != nil && (cd.NewColumn.IsAutoIncr || cd.NewColumn.IsGenerated) {
+			return AlgorithmCopy
+		}
+		if v.atLeast(8, 0, 29) {
+			return AlgorithmInstant
+		}
+		// 8.0.12 起追加到末尾的列可以 INSTANT
+		if v.atLeast(8, 0, 12) && cd.AfterColumn == "" && !cd.First {
+			return AlgorithmInstant
+		}
+		return AlgorithmInplace
+
+	case diff.DiffTypeRemoved:
+		if v.atLeast(8, 0, 29) {
+			return AlgorithmInstant
+		}
+		return AlgorithmInplace
+
+	case diff.DiffTypeRenamed, diff.DiffTypeModified:
+		algorithm := ""
+		if cd.DiffType == diff.DiffTypeRenamed {
+			algorithm = AlgorithmInplace
+			if v.atLeast(8, 0, 28) {
+				algorithm = AlgorithmInstant
+			}
+		}
+		for _, change := range cd.Changes {
+			algorithm = strongerAlgorithm(algorithm, propertyAlgorithm(cd, change, v))
+		}
+		return algorithm
+	}
+
+	return ""
+}
+
+// propertyAlgorithm 估算单项列属性变更可使用的算法
+func propertyAlgorithm(cd *diff.ColumnDiff, change diff.PropertyDiff, v mysqlVersion) string {
+	switch change.Property {
+	case "默认值":
+		if v.atLeast(8, 0, 0) {
+			return AlgorithmInstant
+		}
+		return AlgorithmInplace
+	case "注释", "可空", "位置":
+		return AlgorithmInplace
+	case "类型":
+		if cd.OldColumn != nil && cd.NewColumn != nil && isVarcharExtension(cd.OldColumn, cd.NewColumn) {
+			return AlgorithmInplace
+		}
+		return AlgorithmCopy
+	default:
+		// 自增、字符集、排序规则等需要重建数据
+		return AlgorithmCopy
+	}
+}
+
+// isVarcharExtension 检查是否为长度字节数不变的 VARCHAR 扩容
+func isVarcharExtension(oldCol, newCol *extractor.ColumnSchema) bool {
+	if oldCol.DataType != "varchar" || newCol.DataType != "varchar" ||
+		oldCol.CharMaxLen == nil || newCol.CharMaxLen == nil ||
+		oldCol.CharsetName != newCol.CharsetName {
+		return false
+	}
+	if *newCol.CharMaxLen < *oldCol.CharMaxLen {
+		return false
+	}
+
+	maxBytes := int64(4)
+	switch strings.ToLower(oldCol.CharsetName) {
+	case "latin1", "ascii", "binary":
+		maxBytes = 1
+	case "utf8", "utf8mb3":
+		maxBytes = 3
+	case "ucs2", "gbk", "gb2312":
+		maxBytes = 2
+	}
+	return (*oldCol.CharMaxLen*maxBytes < 256) == (*newCol.CharMaxLen*maxBytes < 256)
+}
+
+// indexAlgorithm 估算索引变更可使用的算法及锁级别
+func indexAlgorithm(idx *extractor.IndexSchema, drop bool) (string, string) {
+	if idx == nil {
+		return "", ""
+	}
+	if drop {
+		if idx.IsPrimary {
+			return AlgorithmCopy, ""
+		}
+		return AlgorithmInplace, "NONE"
+	}
+	if idx.Type == extractor.IndexTypeFulltext || idx.Type == extractor.IndexTypeSpatial {
+		return AlgorithmInplace, "SHARED"
+	}
+	return AlgorithmInplace, "NONE"
+}
+
+// tablePropertyAlgorithm 估算表属性变更可使用的算法
+func tablePropertyAlgorithm(property, version string) string {
+	switch property {
+	case "COMMENT":
+		if parseMySQLVersion(version).atLeast(8, 0, 0) {
+			return AlgorithmInstant
+		}
+		return AlgorithmInplace
+	default:
+		return AlgorithmCopy
+	}
+}
+
+// applyOnlineMode 按在线变更模式改写语句
+func (g *MySQLGenerator) applyOnlineMode(script *MigrationScript, schemaDiff *diff.SchemaDiff, options GenerateOptions) {
+	switch onlineTool(options) {
+	case "":
+		return
+	case OnlineToolNative:
+		g.applyNativeOnline(script, options)
+	case OnlineToolGhost, OnlineToolPTOSC:
+		g.applyOnlineTool(script, schemaDiff, options)
+	default:
+		script.Warnings = append(script.Warnings,
+			fmt.Sprintf("不支持的在线变更工具 %s，已按普通语句生成", options.OnlineTool))
+	}
+}
+
+// applyNativeOnline 为 ALTER 语句追加 ALGORITHM/LOCK 子句
+func (g *MySQLGenerator) applyNativeOnline(script *MigrationScript, options GenerateOptions) {
+	if !parseMySQLVersion(options.TargetVersion).atLeast(5, 6, 0) {
+		script.Warnings = append(script.Warnings,
+			fmt.Sprintf("MySQL %s 不支持 Online DDL，所有表变更都将锁表执行", options.TargetVersion))
+		return
+	}
+
+	for i := range script.Statements {
+		stmt := &script.Statements[i]
+		if !strings.HasPrefix(stmt.SQL, "ALTER TABLE ") || stmt.Algorithm == "" {
+			continue
+		}
+
+		switch stmt.Algorithm {
+		case AlgorithmInstant:
+			stmt.SQL = appendAlterOptions(stmt.SQL, "ALGORITHM=INSTANT")
+		case AlgorithmInplace:
+			lock := stmt.Lock
+			if lock == "" {
+				lock = "NONE"
+			}
+			stmt.SQL = appendAlterOptions(stmt.SQL, "ALGORITHM=INPLACE", "LOCK="+lock)
+		case AlgorithmCopy:
+			script.Warnings = append(script.Warnings,
+				fmt.Sprintf("%s: %s 无法在线执行（需要 COPY 算法，执行期间阻塞写入），大表请改用 gh-ost 或 pt-online-schema-change", stmt.ObjectName, stmt.Comment))
+		}
+	}
+}
+
+// appendAlterOptions 在 ALTER 语句末尾追加选项
+func appendAlterOptions(sql string, opts ...string) string {
+	return strings.TrimSuffix(sql, ";") + ", " + strings.Join(opts, ", ") + ";"
+}
+
+// applyOnlineTool 将每张表的 ALTER 合并为一条，并生成 gh-ost / pt-osc 命令
+func (g *MySQLGenerator) applyOnlineTool(script *MigrationScript, schemaDiff *diff.SchemaDiff, options GenerateOptions) {
+	tables := make(map[string]*diff.TableDiff)
+	for i := range schemaDiff.TableDiffs {
+		td := &schemaDiff.TableDiffs[i]
+		if td.DiffType == diff.DiffTypeModified || td.DiffType == diff.DiffTypeRenamed {
+			tables[td.TableName] = td
+		}
+	}
+
+	// 收集每张表可合并的 ALTER 子句
+	type onlineAlter struct {
+		first    int
+		indexes  []int
+		clauses  []string
+		instant  bool
+		renamed  bool
+		severity diff.DiffSeverity
+	}
+	alters := make(map[string]*onlineAlter)
+	var order []string
+	for i, stmt := range script.Statements {
+		if stmt.ObjectType == "FOREIGN KEY" || stmt.ObjectType == "CHECK" || stmt.ObjectType == "PARTITION" {
+			continue
+		}
+		table := alterTableName(stmt.SQL)
+		if table == "" || tables[table] == nil {
+			continue
+		}
+		alter, ok := alters[table]
+		if !ok {
+			alter = &onlineAlter{first: i, instant: true}
+			alters[table] = alter
+			order = append(order, table)
+		}
+		alter.indexes = append(alter.indexes, i)
+		alter.clauses = append(alter.clauses, alterClauses(stmt, table)...)
+		alter.instant = alter.instant && stmt.Algorithm == AlgorithmInstant
+		alter.renamed = alter.renamed || stmt.Operation == "RENAME"
+		if stmt.Severity > alter.severity {
+			alter.severity = stmt.Severity
+		}
+	}
+
+	tool := onlineTool(options)
+	replaced := make(map[int]SQLStatement)
+	removed := make(map[int]bool)
+	for _, table := range order {
+		alter := alters[table]
+		td := tables[table]
+
+		// 全部可 INSTANT 执行时无需复制整表
+		if alter.instant {
+			for _, i := range alter.indexes {
+				script.Statements[i].SQL = appendAlterOptions(script.Statements[i].SQL, "ALGORITHM=INSTANT")
+			}
+			continue
+		}
+
+		if reason := onlineToolUnsupported(tool, td, schemaDiff); reason != "" {
+			script.Warnings = append(script.Warnings,
+				fmt.Sprintf("表 `%s` 无法使用 %s 在线变更（%s），已保留普通 ALTER 语句", table, tool, reason))
+			continue
+		}
+
+		command := buildOnlineCommand(tool, schemaDiff.TargetEnv, table, strings.Join(alter.clauses, ", "), alter.renamed, options.OnlineToolArgs)
+
+		var rollbacks []string
+		for j := len(alter.indexes) - 1; j >= 0; j-- {
+			if rollback := script.Statements[alter.indexes[j]].RollbackSQL; rollback != "" {
+				rollbacks = append(rollbacks, rollback)
+			}
+		}
+
+		replaced[alter.first] = SQLStatement{
+			SQL:           buildAlterSQL(table, alter.clauses),
+			ObjectType:    "TABLE",
+			ObjectName:    table,
+			Operation:     "ALTER",
+			Severity:      alter.severity,
+			Comment:       fmt.Sprintf("通过 %s 在线变更表 %s", tool, table),
+			RollbackSQL:   strings.Join(rollbacks, "\n"),
+			Algorithm:     AlgorithmCopy,
+			OnlineCommand: command,
+		}
+		for _, i := range alter.indexes[1:] {
+			removed[i] = true
+		}
+		script.OnlineCommands = append(script.OnlineCommands, command)
+	}
+
+	statements := make([]SQLStatement, 0, len(script.Statements))
+	for i, stmt := range script.Statements {
+		if removed[i] {
+			continue
+		}
+		if r, ok := replaced[i]; ok {
+			stmt = r
+		}
+		statements = append(statements, stmt)
+	}
+	script.Statements = statements
+}
+
+// onlineToolUnsupported 检查表是否满足在线变更工具的前提条件，返回不满足的原因
+func onlineToolUnsupported(tool string, td *diff.TableDiff, schemaDiff *diff.SchemaDiff) string {
+	for _, table := range []*extractor.TableSchema{td.OldTable, td.NewTable} {
+		if table == nil {
+			continue
+		}
+		hasKey := false
+		for _, idx := range table.Indexes {
+			if idx.IsPrimary || idx.IsUnique {
+				hasKey = true
+				break
+			}
+		}
+		if !hasKey {
+			return "缺少主键或唯一索引"
+		}
+		if tool == OnlineToolGhost && len(table.ForeignKeys) > 0 {
+			return "gh-ost 不支持带外键的表"
+		}
+	}
+
+	// gh-ost 同样不支持被其他表外键引用的表（只能检查差异中出现的表）
+	if tool == OnlineToolGhost {
+		for _, other := range schemaDiff.TableDiffs {
+			for _, table := range []*extractor.TableSchema{other.OldTable, other.NewTable} {
+				if table == nil {
+					continue
+				}
+				for _, fk := range table.ForeignKeys {
+					if fk.RefTable == td.TableName || (td.OldName != "" && fk.RefTable == td.OldName) {
+						return fmt.Sprintf("被表 `%s` 的外键引用，gh-ost 不支持", table.Name)
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// buildOnlineCommand 构建在线变更工具命令
+func buildOnlineCommand(tool, database, table, clause string, renamed bool, extraArgs string) string {
+	var args []string
+	switch tool {
+	case OnlineToolGhost:
+		args = []string{
+			"gh-ost",
+			"--database=" + shellQuote(database),
+			"--table=" + shellQuote(table),
+			"--alter=" + shellQuote(clause),
+		}
+		if renamed {
+			args = append(args, "--approve-renamed-columns")
+		}
+	case OnlineToolPTOSC:
+		args = []string{
+			"pt-online-schema-change",
+			"--alter " + shellQuote(clause),
+			shellQuote(fmt.Sprintf("D=%s,t=%s", database, table)),
+		}
+		if renamed {
+			args = append(args, "--no-check-alter")
+		}
+	}
+	if extraArgs != "" {
+		args = append(args, extraArgs)
+	}
+	args = append(args, "--execute")
+	return strings.Join(args, " ")
+}
+
+// shellQuote 用单引号包装shell参数
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,281 @@
+package sqlgen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// topoSort 按依赖关系稳定排序，deps[i] 为第 i 项依赖的项
+// 每次取出依赖都已就绪的下标最小的项，没有依赖关系的项保持原有顺序
+// 返回排序后的下标，以及处于循环依赖（或依赖循环中的项）而无法排序的下标
+func topoSort(n int, deps [][]int) ([]int, []int) {
+	emitted := make([]bool, n)
+	var order []int
+	for len(order) < n {
+		next := -1
+		for i := 0; i < n && next < 0; i++ {
+			if emitted[i] {
+				continue
+			}
+			ready := true
+			for _, dep := range deps[i] {
+				if dep != i && !emitted[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		emitted[next] = true
+		order = append(order, next)
+	}
+
+	var cyclic []int
+	for i := 0; i < n; i++ {
+		if !emitted[i] {
+			cyclic = append(cyclic, i)
+		}
+	}
+	return order, cyclic
+}
+
+// orderCreateTables 按外键依赖排序新建表，被引用的表先创建
+// 存在循环外键时，只从同一强连通分量内的表的建表语句里去掉相互引用的外键，改为建表之后单独 ALTER 添加
+func (g *MySQLGenerator) orderCreateTables(schemaDiff *diff.SchemaDiff, creates []SQLStatement) ([]SQLStatement, []SQLStatement, []string) {
+	tables := make(map[string]*extractor.TableSchema)
+	for _, td := range schemaDiff.TableDiffs {
+		if td.DiffType == diff.DiffTypeAdded && td.NewTable != nil {
+			tables[td.TableName] = td.NewTable
+		}
+	}
+	index := make(map[string]int)
+	for i, stmt := range creates {
+		index[stmt.ObjectName] = i
+	}
+
+	deps := make([][]int, len(creates))
+	for i, stmt := range creates {
+		if table := tables[stmt.ObjectName]; table != nil {
+			for _, fk := range table.ForeignKeys {
+				if j, ok := index[fk.RefTable]; ok && j != i {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+
+	// 只有同一强连通分量内的表之间才构成循环，位于循环下游的表保留外键，排在循环之后创建
+	component, size := stronglyConnected(len(creates), deps)
+	var deferred []SQLStatement
+	var warnings []string
+	for c := range creates {
+		if size[component[c]] < 2 {
+			continue
+		}
+		name := creates[c].ObjectName
+		refTables := make(map[string]bool)
+		for _, dep := range deps[c] {
+			if component[dep] == component[c] {
+				refTables[creates[dep].ObjectName] = true
+			}
+		}
+		if len(refTables) == 0 {
+			continue
+		}
+
+		if sql, ok := extractor.RemoveForeignKeys(creates[c].SQL, refTables); ok {
+			creates[c].SQL = sql
+			creates[c].Comment += "（循环引用的外键在建表后添加）"
+			var fkNames []string
+			for fkName, fk := range tables[name].ForeignKeys {
+				if refTables[fk.RefTable] {
+					fkNames = append(fkNames, fkName)
+				}
+			}
+			sort.Strings(fkNames)
+			for _, fkName := range fkNames {
+				deferred = append(deferred, g.generateAddForeignKey(name, tables[name].ForeignKeys[fkName]))
+			}
+
+			var remaining []int
+			for _, dep := range deps[c] {
+				if !refTables[creates[dep].ObjectName] {
+					remaining = append(remaining, dep)
+				}
+			}
+			deps[c] = remaining
+		} else {
+			warnings = append(warnings, fmt.Sprintf("表 `%s` 与其他新表存在循环外键，且无法从建表语句中拆出外键，请执行前调整顺序或临时关闭 foreign_key_checks", name))
+		}
+	}
+
+	return applyOrder(creates, deps), deferred, warnings
+}
+
+// stronglyConnected 用 Tarjan 算法计算依赖图的强连通分量
+// 返回每一项所属分量的编号，以及每个分量包含的项数
+func stronglyConnected(n int, deps [][]int) ([]int, []int) {
+	index := make([]int, n)
+	low := make([]int, n)
+	onStack := make([]bool, n)
+	component := make([]int, n)
+	for i := range index {
+		index[i] = -1
+	}
+	var stack []int
+	var size []int
+	next := 0
+
+	var visit func(v int)
+	visit = func(v int) {
+		index[v] = next
+		low[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range deps[v] {
+			if index[w] < 0 {
+				visit(w)
+				if low[w] < low[v] {
+					low[v] = low[w]
+				}
+			} else if onStack[w] && index[w] < low[v] {
+				low[v] = index[w]
+			}
+		}
+
+		if low[v] == index[v] {
+			id := len(size)
+			count := 0
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				component[w] = id
+				count++
+				if w == v {
+					break
+				}
+			}
+			size = append(size, count)
+		}
+	}
+
+	for v := 0; v < n; v++ {
+		if index[v] < 0 {
+			visit(v)
+		}
+	}
+	return component, size
+}
+
+// orderDropTables 按外键依赖排序删除的表，引用其他表的表先删除
+func orderDropTables(schemaDiff *diff.SchemaDiff, drops []SQLStatement) []SQLStatement {
+	tables := make(map[string]*extractor.TableSchema)
+	for _, td := range schemaDiff.TableDiffs {
+		if td.DiffType == diff.DiffTypeRemoved && td.OldTable != nil {
+			tables[td.TableName] = td.OldTable
+		}
+	}
+
+	// 按创建方向排序后逆序输出
+	reversed := reverseStatements(drops)
+	index := make(map[string]int)
+	for i, stmt := range reversed {
+		index[stmt.ObjectName] = i
+	}
+	deps := make([][]int, len(reversed))
+	for i, stmt := range reversed {
+		if table := tables[stmt.ObjectName]; table != nil {
+			for _, fk := range table.ForeignKeys {
+				if j, ok := index[fk.RefTable]; ok && j != i {
+					deps[i] = append(deps[i], j)
+				}
+			}
+		}
+	}
+	return reverseStatements(applyOrder(reversed, deps))
+}
+
+// orderDefinitions 按定义中引用的对象名排序视图、存储过程和函数的创建语句，被引用的对象先创建
+// definitions 为 对象类型:名称 到定义的映射；循环引用的对象保持原有顺序
+func orderDefinitions(creates []SQLStatement, definitions map[string]string) []SQLStatement {
+	deps := make([][]int, len(creates))
+	for i, stmt := range creates {
+		refs := extractor.Identifiers(definitions[stmt.ObjectType+":"+stmt.ObjectName])
+		for j, other := range creates {
+			if j != i && other.ObjectName != stmt.ObjectName && refs[strings.ToLower(other.ObjectName)] {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	return applyOrder(creates, deps)
+}
+
+// orderDropDefinitions 与 orderDefinitions 相反，引用其他对象的视图、存储过程和函数先删除
+func orderDropDefinitions(drops []SQLStatement, definitions map[string]string) []SQLStatement {
+	return reverseStatements(orderDefinitions(reverseStatements(drops), definitions))
+}
+
+// applyOrder 按依赖排序语句，无法排序的语句按原有顺序放在最后
+func applyOrder(stmts []SQLStatement, deps [][]int) []SQLStatement {
+	order, cyclic := topoSort(len(stmts), deps)
+	ordered := make([]SQLStatement, 0, len(stmts))
+	for _, idx := range append(order, cyclic...) {
+		ordered = append(ordered, stmts[idx])
+	}
+	return ordered
+}
+
+// reverseStatements 返回逆序的语句
+func reverseStatements(stmts []SQLStatement) []SQLStatement {
+	reversed := make([]SQLStatement, len(stmts))
+	for i, stmt := range stmts {
+		reversed[len(stmts)-1-i] = stmt
+	}
+	return reversed
+}
+
+// definitionsOf 收集差异中视图、存储过程和函数的定义，键为 对象类型:名称
+// newSide 为 true 时取源环境（新）的定义，否则取目标环境（旧）的定义
+func definitionsOf(schemaDiff *diff.SchemaDiff, newSide bool) map[string]string {
+	definitions := make(map[string]string)
+	for _, vd := range schemaDiff.ViewDiffs {
+		view := vd.OldView
+		if newSide {
+			view = vd.NewView
+		}
+		if view != nil {
+			definitions["VIEW:"+vd.ViewName] = view.Definition
+		}
+	}
+	for _, pd := range schemaDiff.ProcDiffs {
+		proc := pd.OldProc
+		if newSide {
+			proc = pd.NewProc
+		}
+		if proc != nil {
+			definitions["PROCEDURE:"+pd.ProcName] = proc.Definition
+		}
+	}
+	for _, fd := range schemaDiff.FuncDiffs {
+		fn := fd.OldFunc
+		if newSide {
+			fn = fd.NewFunc
+		}
+		if fn != nil {
+			definitions["FUNCTION:"+fd.FuncName] = fn.Definition
+		}
+	}
+	return definitions
+}
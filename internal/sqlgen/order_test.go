@@ -0,0 +1,117 @@
+package sqlgen
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// orderCreates 解析建表脚本，按 names 的顺序生成新建表语句并排序
+func orderCreates(t *testing.T, script string, names ...string) ([]SQLStatement, []SQLStatement, []string) {
+	t.Helper()
+	schema, err := extractor.ParseDDL(script, "app")
+	if err != nil {
+		t.Fatalf("ParseDDL() error = %v", err)
+	}
+
+	schemaDiff := &diff.SchemaDiff{}
+	var creates []SQLStatement
+	for _, name := range names {
+		table := schema.Tables[name]
+		if table == nil {
+			t.Fatalf("表 %s 不存在", name)
+		}
+		schemaDiff.TableDiffs = append(schemaDiff.TableDiffs, diff.TableDiff{
+			TableName: name,
+			DiffType:  diff.DiffTypeAdded,
+			NewTable:  table,
+		})
+		creates = append(creates, SQLStatement{
+			SQL:        table.CreateSQL,
+			ObjectType: "TABLE",
+			ObjectName: name,
+			Operation:  "CREATE",
+		})
+	}
+	return NewMySQLGenerator().orderCreateTables(schemaDiff, creates)
+}
+
+func objectNames(stmts []SQLStatement) []string {
+	var names []string
+	for _, stmt := range stmts {
+		names = append(names, stmt.ObjectName)
+	}
+	return names
+}
+
+func TestOrderCreateTablesChain(t *testing.T) {
+	ordered, deferred, warnings := orderCreates(t, `
+		CREATE TABLE c (id int PRIMARY KEY, b_id int, CONSTRAINT fk_c_b FOREIGN KEY (b_id) REFERENCES b (id));
+		CREATE TABLE b (id int PRIMARY KEY, a_id int, CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a (id));
+		CREATE TABLE a (id int PRIMARY KEY);`, "c", "b", "a")
+
+	if got, want := objectNames(ordered), []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if len(deferred) != 0 || len(warnings) != 0 {
+		t.Errorf("无循环时不应拆出外键: deferred = %v, warnings = %v", objectNames(deferred), warnings)
+	}
+}
+
+func TestOrderCreateTablesCycle(t *testing.T) {
+	ordered, deferred, warnings := orderCreates(t, `
+		CREATE TABLE a (id int PRIMARY KEY, b_id int, CONSTRAINT fk_a_b FOREIGN KEY (b_id) REFERENCES b (id));
+		CREATE TABLE b (id int PRIMARY KEY, a_id int, CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a (id));`, "a", "b")
+
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v", warnings)
+	}
+	if got, want := objectNames(ordered), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	for _, stmt := range ordered {
+		if strings.Contains(stmt.SQL, "FOREIGN KEY") {
+			t.Errorf("循环内的外键应从建表语句中拆出: %s", stmt.SQL)
+		}
+	}
+	if got, want := objectNames(deferred), []string{"a.fk_a_b", "b.fk_b_a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deferred = %v, want %v", got, want)
+	}
+}
+
+func TestOrderCreateTablesSelfReference(t *testing.T) {
+	ordered, deferred, warnings := orderCreates(t, `
+		CREATE TABLE node (id int PRIMARY KEY, parent_id int, CONSTRAINT fk_parent FOREIGN KEY (parent_id) REFERENCES node (id));
+		CREATE TABLE tree (id int PRIMARY KEY);`, "node", "tree")
+
+	if got, want := objectNames(ordered), []string{"node", "tree"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if !strings.Contains(ordered[0].SQL, "fk_parent") {
+		t.Errorf("自引用外键应保留在建表语句中: %s", ordered[0].SQL)
+	}
+	if len(deferred) != 0 || len(warnings) != 0 {
+		t.Errorf("自引用不应拆出外键: deferred = %v, warnings = %v", objectNames(deferred), warnings)
+	}
+}
+
+func TestOrderCreateTablesDownstreamOfCycle(t *testing.T) {
+	// c 引用循环中的 a 但自身不在循环内，外键应保留，并排在 a 之后创建
+	ordered, deferred, _ := orderCreates(t, `
+		CREATE TABLE c (id int PRIMARY KEY, a_id int, CONSTRAINT fk_c_a FOREIGN KEY (a_id) REFERENCES a (id));
+		CREATE TABLE a (id int PRIMARY KEY, b_id int, CONSTRAINT fk_a_b FOREIGN KEY (b_id) REFERENCES b (id));
+		CREATE TABLE b (id int PRIMARY KEY, a_id int, CONSTRAINT fk_b_a FOREIGN KEY (a_id) REFERENCES a (id));`, "c", "a", "b")
+
+	if got, want := objectNames(ordered), []string{"a", "c", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if c := ordered[1]; !strings.Contains(c.SQL, "fk_c_a") || strings.Contains(c.Comment, "循环") {
+		t.Errorf("下游表的外键不应拆出: %+v", c)
+	}
+	if got, want := objectNames(deferred), []string{"a.fk_a_b", "b.fk_b_a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deferred = %v, want %v", got, want)
+	}
+}
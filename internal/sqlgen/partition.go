@@ -0,0 +1,158 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/diff"
+	"github.com/starvpn/schemapatch/internal/extractor"
+)
+
+// generatePartitionStatements 生成分区变更语句
+func (g *MySQLGenerator) generatePartitionStatements(tableName string, pd *diff.PartitionDiff) []SQLStatement {
+	statement := func(sql string, severity diff.DiffSeverity, comment string) SQLStatement {
+		return SQLStatement{
+			SQL:        sql,
+			ObjectType: "PARTITION",
+			ObjectName: tableName,
+			Operation:  "ALTER",
+			Severity:   severity,
+			Comment:    comment,
+		}
+	}
+
+	switch {
+	case pd.DiffType == diff.DiffTypeRemoved:
+		return []SQLStatement{statement(
+			fmt.Sprintf("ALTER TABLE `%s` REMOVE PARTITIONING;", tableName),
+			pd.Severity, "取消分区（需要重建表）")}
+	case pd.Repartition:
+		comment := "重新分区（需要重建表）"
+		if pd.DiffType == diff.DiffTypeAdded {
+			comment = "改为分区表（需要重建表）"
+		}
+		return []SQLStatement{statement(
+			fmt.Sprintf("ALTER TABLE `%s` %s;", tableName, g.buildPartitionClause(pd.NewPartition)),
+			pd.Severity, comment)}
+	}
+
+	source, target := pd.NewPartition, pd.OldPartition
+
+	// HASH / KEY 分区调整分区数量
+	if !source.IsRange() && !source.IsList() {
+		delta := len(source.Partitions) - len(target.Partitions)
+		if delta > 0 {
+			return []SQLStatement{statement(
+				fmt.Sprintf("ALTER TABLE `%s` ADD PARTITION PARTITIONS %d;", tableName, delta),
+				pd.Severity, fmt.Sprintf("增加 %d 个分区", delta))}
+		}
+		return []SQLStatement{statement(
+			fmt.Sprintf("ALTER TABLE `%s` COALESCE PARTITION %d;", tableName, -delta),
+			pd.Severity, fmt.Sprintf("减少 %d 个分区", -delta))}
+	}
+
+	var statements []SQLStatement
+	if len(pd.Removed) > 0 {
+		statements = append(statements, statement(
+			fmt.Sprintf("ALTER TABLE `%s` DROP PARTITION %s;", tableName, quoteNames(pd.Removed)),
+			diff.SeverityDanger, fmt.Sprintf("⚠️ 删除分区 %s - 数据将丢失", strings.Join(pd.Removed, ", "))))
+	}
+
+	// RANGE 分区插入到已有分区之前时拆分该分区，LIST 分区和末尾的 RANGE 分区直接添加
+	existing := make(map[string]bool)
+	for _, def := range target.Partitions {
+		existing[def.Name] = true
+	}
+	var pending []extractor.PartitionDef
+	for _, def := range source.Partitions {
+		if !existing[def.Name] {
+			pending = append(pending, def)
+			continue
+		}
+		if len(pending) > 0 && source.IsRange() {
+			statements = append(statements, statement(
+				fmt.Sprintf("ALTER TABLE `%s` REORGANIZE PARTITION `%s` INTO (%s);",
+					tableName, def.Name, g.buildPartitionDefinitions(source, append(pending, def))),
+				diff.SeverityWarning, fmt.Sprintf("拆分分区 %s", def.Name)))
+			pending = nil
+		}
+	}
+	if len(pending) > 0 {
+		names := make([]string, len(pending))
+		for i, def := range pending {
+			names[i] = def.Name
+		}
+		statements = append(statements, statement(
+			fmt.Sprintf("ALTER TABLE `%s` ADD PARTITION (%s);", tableName, g.buildPartitionDefinitions(source, pending)),
+			diff.SeverityInfo, "新增分区 "+strings.Join(names, ", ")))
+	}
+
+	return statements
+}
+
+// buildPartitionClause 构建完整的 PARTITION BY 子句
+func (g *MySQLGenerator) buildPartitionClause(p *extractor.PartitionInfo) string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "PARTITION BY %s (%s)", p.Method, p.Expression)
+
+	if !p.IsRange() && !p.IsList() {
+		fmt.Fprintf(&builder, " PARTITIONS %d", len(p.Partitions))
+		return builder.String()
+	}
+
+	if p.SubMethod != "" {
+		fmt.Fprintf(&builder, "\nSUBPARTITION BY %s (%s)", p.SubMethod, p.SubExpression)
+	}
+	builder.WriteString("\n(")
+	builder.WriteString(g.buildPartitionDefinitions(p, p.Partitions))
+	builder.WriteString(")")
+	return builder.String()
+}
+
+// buildPartitionDefinitions 构建分区定义列表
+func (g *MySQLGenerator) buildPartitionDefinitions(p *extractor.PartitionInfo, defs []extractor.PartitionDef) string {
+	parts := make([]string, len(defs))
+	for i, def := range defs {
+		parts[i] = g.buildPartitionDefinition(p, def)
+	}
+	return strings.Join(parts, ",\n ")
+}
+
+// buildPartitionDefinition 构建单个分区定义
+func (g *MySQLGenerator) buildPartitionDefinition(p *extractor.PartitionInfo, def extractor.PartitionDef) string {
+	sql := fmt.Sprintf("PARTITION `%s`", def.Name)
+
+	switch {
+	case p.IsRange():
+		if strings.EqualFold(def.Description, "MAXVALUE") {
+			sql += " VALUES LESS THAN MAXVALUE"
+		} else {
+			sql += fmt.Sprintf(" VALUES LESS THAN (%s)", def.Description)
+		}
+	case p.IsList():
+		sql += fmt.Sprintf(" VALUES IN (%s)", def.Description)
+	}
+
+	if def.Comment != "" {
+		sql += fmt.Sprintf(" COMMENT = '%s'", g.escapeString(def.Comment))
+	}
+
+	if len(def.SubPartitions) > 0 {
+		subs := make([]string, len(def.SubPartitions))
+		for i, name := range def.SubPartitions {
+			subs[i] = fmt.Sprintf("SUBPARTITION `%s`", name)
+		}
+		sql += " (" + strings.Join(subs, ", ") + ")"
+	}
+
+	return sql
+}
+
+// quoteNames 用反引号包装名称列表
+func quoteNames(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = "`" + name + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
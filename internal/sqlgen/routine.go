@@ -0,0 +1,92 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/starvpn/schemapatch/internal/diff"
+)
+
+// alterableOnly 判断存储过程/函数的变更是否都能通过 ALTER 完成，无需删除重建
+func alterableOnly(props []diff.PropertyDiff) bool {
+	if len(props) == 0 {
+		return false
+	}
+	for _, prop := range props {
+		if !diff.AlterableRoutineProperties[prop.Property] {
+			return false
+		}
+	}
+	return true
+}
+
+// buildAlterRoutineSQL 构建修改存储过程/函数特性的语句，只包含发生变化的特性
+func (g *MySQLGenerator) buildAlterRoutineSQL(objectType, name, security, comment string, props []diff.PropertyDiff) string {
+	var clauses []string
+	for _, prop := range props {
+		switch prop.Property {
+		case "安全性":
+			if security == "" {
+				security = "DEFINER"
+			}
+			clauses = append(clauses, "SQL SECURITY "+strings.ToUpper(security))
+		case "注释":
+			clauses = append(clauses, fmt.Sprintf("COMMENT '%s'", g.escapeString(comment)))
+		}
+	}
+	return fmt.Sprintf("ALTER %s `%s` %s;", objectType, name, strings.Join(clauses, " "))
+}
+
+// sqlModeWarning 重建的存储过程/函数 sql_mode 不同时给出提示，ALTER 无法修改 sql_mode，因此在重建语句前切换会话的 sql_mode
+func sqlModeWarning(objectName, name string, props []diff.PropertyDiff) string {
+	for _, prop := range props {
+		if prop.Property == "sql_mode" {
+			return fmt.Sprintf("%s `%s` 的 sql_mode 不同（%s -> %s），将在源环境的 sql_mode 下重建",
+				objectName, name, prop.OldValue, prop.NewValue)
+		}
+	}
+	return ""
+}
+
+// withSQLMode 与 mysqldump 相同，在指定的 sql_mode 下执行创建语句，之后恢复会话原有的 sql_mode
+// 存储过程/函数在创建时记录会话的 sql_mode，不指定时会使用执行脚本时的会话设置；sqlMode 为空（DDL文件）时只返回创建语句
+// 切换和恢复 sql_mode 是单独的语句，依赖执行器在同一个连接上依次执行
+func (g *MySQLGenerator) withSQLMode(stmt SQLStatement, sqlMode string) []SQLStatement {
+	if sqlMode == "" {
+		return []SQLStatement{stmt}
+	}
+	session := func(sql, comment string) SQLStatement {
+		return SQLStatement{
+			SQL:        sql,
+			ObjectType: "SESSION",
+			ObjectName: stmt.ObjectName,
+			Operation:  "SET",
+			Severity:   diff.SeverityInfo,
+			Comment:    comment,
+		}
+	}
+	return []SQLStatement{
+		session("SET @saved_sql_mode = @@SESSION.sql_mode;", "保存会话的 sql_mode"),
+		session(fmt.Sprintf("SET SESSION sql_mode = '%s';", g.escapeString(sqlMode)), fmt.Sprintf("切换到 `%s` 的 sql_mode", stmt.ObjectName)),
+		stmt,
+		session("SET SESSION sql_mode = @saved_sql_mode;", "恢复会话的 sql_mode"),
+	}
+}
+
+// sqlModeScript 返回 withSQLMode 的脚本形式，用于由 mysql 客户端执行的回滚脚本
+func (g *MySQLGenerator) sqlModeScript(sql, sqlMode string) string {
+	var parts []string
+	for _, stmt := range g.withSQLMode(SQLStatement{SQL: sql}, sqlMode) {
+		parts = append(parts, stmt.SQL)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// expandSQLMode 在排序完成后为需要特定 sql_mode 的语句加上切换和恢复语句，保证它们相邻
+func (g *MySQLGenerator) expandSQLMode(stmts []SQLStatement) []SQLStatement {
+	expanded := make([]SQLStatement, 0, len(stmts))
+	for _, stmt := range stmts {
+		expanded = append(expanded, g.withSQLMode(stmt, stmt.sqlMode)...)
+	}
+	return expanded
+}